@@ -0,0 +1,270 @@
+// Package chaos injects faults into gRPC servers at the application layer,
+// following the semantics of Istio fault injection, so that experiments can
+// compare faults injected by the mesh with faults injected by the services
+// themselves.
+//
+// Faults are described by a YAML rules file:
+//
+//	rules:
+//	- name: slow-catalog
+//	  match:
+//	    service: genproto.ProductCatalogService
+//	    method: Get*
+//	    metadata:
+//	      x-chaos: "on"
+//	  delay:
+//	    fixedDelay: 2s
+//	    percentage: 50
+//	  abort:
+//	    grpcStatus: UNAVAILABLE
+//	    percentage: 10
+//
+// The first rule that matches a call applies to it. The file is reloaded
+// when it changes.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultReloadInterval is how often the rules file is checked for changes
+// unless CHAOS_RELOAD_INTERVAL says otherwise.
+const DefaultReloadInterval = 5 * time.Second
+
+// Injector injects the faults of a rules file into the calls of a gRPC
+// server. The zero Injector, and a nil one, inject nothing.
+type Injector struct {
+	// Logf logs rule changes and reload failures.
+	Logf func(format string, args ...interface{})
+
+	path  string
+	rules atomic.Pointer[Rules]
+	// roll returns a random number in [0, 100).
+	roll func() float64
+
+	mu sync.Mutex // serializes reloads
+	// modTime and size are those of the rules file when it was last read.
+	modTime time.Time
+	size    int64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns an injector with the rules of the file at path. It logs with
+// logf, or the standard logger if logf is nil.
+func New(path string, logf func(format string, args ...interface{})) (*Injector, error) {
+	if logf == nil {
+		logf = log.Printf
+	}
+	i := &Injector{Logf: logf, path: path}
+	if err := i.Reload(); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// FromEnv returns an injector with the rules of the file named by
+// CHAOS_RULES_FILE, reloaded every CHAOS_RELOAD_INTERVAL, or nil if
+// CHAOS_RULES_FILE is not set.
+func FromEnv(logf func(format string, args ...interface{})) (*Injector, error) {
+	path := os.Getenv("CHAOS_RULES_FILE")
+	if path == "" {
+		return nil, nil
+	}
+	interval := DefaultReloadInterval
+	if s := os.Getenv("CHAOS_RELOAD_INTERVAL"); s != "" {
+		var err error
+		if interval, err = time.ParseDuration(s); err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid CHAOS_RELOAD_INTERVAL %q", s)
+		}
+	}
+	i, err := New(path, logf)
+	if err != nil {
+		return nil, err
+	}
+	i.Watch(interval)
+	return i, nil
+}
+
+// Rules returns the rules in use.
+func (i *Injector) Rules() *Rules {
+	if i == nil {
+		return &Rules{}
+	}
+	if r := i.rules.Load(); r != nil {
+		return r
+	}
+	return &Rules{}
+}
+
+// SetRules validates r and replaces the rules in use with it.
+func (i *Injector) SetRules(r *Rules) error {
+	for n := range r.Rules {
+		if err := r.Rules[n].validate(); err != nil {
+			return fmt.Errorf("rule %d (%s): %w", n, r.Rules[n].Name, err)
+		}
+	}
+	i.rules.Store(r)
+	return nil
+}
+
+// Reload reads the rules file again. On error the rules in use are kept.
+func (i *Injector) Reload() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	info, err := os.Stat(i.path)
+	if err != nil {
+		return fmt.Errorf("failed to read chaos rules: %w", err)
+	}
+	b, err := os.ReadFile(i.path)
+	if err != nil {
+		return fmt.Errorf("failed to read chaos rules: %w", err)
+	}
+	rules, err := ParseRules(b)
+	if err != nil {
+		return fmt.Errorf("invalid chaos rules in %s: %w", i.path, err)
+	}
+	i.modTime, i.size = info.ModTime(), info.Size()
+	i.rules.Store(rules)
+	i.Logf("loaded %d chaos rules from %s", len(rules.Rules), i.path)
+	return nil
+}
+
+// Watch reloads the rules file whenever it changes, checking every interval,
+// until Close is called. Files are polled rather than watched so that the
+// symlinks Kubernetes swaps to update ConfigMap volumes are followed.
+func (i *Injector) Watch(interval time.Duration) {
+	i.stop, i.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(i.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-i.stop:
+				return
+			case <-t.C:
+				if !i.changed() {
+					continue
+				}
+				if err := i.Reload(); err != nil {
+					i.Logf("keeping the current chaos rules: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// changed reports whether the rules file may have changed since it was last
+// read.
+func (i *Injector) changed() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	info, err := os.Stat(i.path)
+	return err != nil || !info.ModTime().Equal(i.modTime) || info.Size() != i.size
+}
+
+// Close stops watching the rules file.
+func (i *Injector) Close() {
+	if i != nil && i.stop != nil {
+		close(i.stop)
+		<-i.done
+	}
+}
+
+// ServerOptions returns the options that install the injector in a gRPC
+// server. They chain with other interceptors.
+func (i *Injector) ServerOptions() []grpc.ServerOption {
+	if i == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(i.StreamServerInterceptor),
+	}
+}
+
+// UnaryServerInterceptor injects faults into unary calls.
+func (i *Injector) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := i.inject(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor injects faults into streams before they are
+// handled.
+func (i *Injector) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := i.inject(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// inject applies the first rule matching the call, if any. It returns the
+// error the call fails with, or nil if the call should be handled.
+func (i *Injector) inject(ctx context.Context, fullMethod string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	rules := i.Rules()
+	for n := range rules.Rules {
+		r := &rules.Rules[n]
+		if r.matches(fullMethod, md) {
+			return i.apply(ctx, r)
+		}
+	}
+	return nil
+}
+
+func (i *Injector) apply(ctx context.Context, r *Rule) error {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("chaos.rule", r.Name))
+
+	if r.Delay != nil && sampled(r.Delay.Percentage, i.rand()) {
+		span.SetAttributes(attribute.String("chaos.delay", r.Delay.FixedDelay.String()))
+		t := time.NewTimer(r.Delay.FixedDelay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if r.Abort != nil && sampled(r.Abort.Percentage, i.rand()) {
+		span.SetAttributes(attribute.String("chaos.abort", r.Abort.code.String()))
+		return status.Errorf(r.Abort.code, "fault injected by chaos rule %q", r.Name)
+	}
+	if r.Drop != nil && sampled(r.Drop.Percentage, i.rand()) {
+		span.SetAttributes(attribute.Bool("chaos.drop", true))
+		t := time.NewTimer(r.Drop.Timeout)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			return status.Errorf(codes.Unavailable, "call dropped by chaos rule %q", r.Name)
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	return nil
+}
+
+func (i *Injector) rand() float64 {
+	if i.roll != nil {
+		return i.roll()
+	}
+	return rand.Float64() * 100
+}
@@ -0,0 +1,174 @@
+package chaos
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testRules = `
+rules:
+- name: abort-carts
+  match:
+    service: genproto.CartService
+    method: Get*
+    metadata:
+      x-chaos: "on*"
+  abort:
+    grpcStatus: UNAVAILABLE
+- name: slow-catalog
+  match:
+    service: genproto.ProductCatalogService
+  delay:
+    fixedDelay: 20ms
+    percentage: 50
+- name: drop-payments
+  match:
+    method: Charge
+  drop: {}
+- name: drop-emails
+  match:
+    method: SendOrderConfirmation
+  drop:
+    timeout: 10ms
+`
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules.Rules) != 4 {
+		t.Fatalf("got %d rules, want 4", len(rules.Rules))
+	}
+	if d := rules.Rules[1].Delay; d.FixedDelay != 20*time.Millisecond || *d.Percentage != 50 {
+		t.Errorf("delay = %+v", d)
+	}
+	if c := rules.Rules[0].Abort.code; c != codes.Unavailable {
+		t.Errorf("abort code = %v, want Unavailable", c)
+	}
+	if d := rules.Rules[2].Drop; d.Timeout != defaultDropTimeout {
+		t.Errorf("drop timeout = %v, want %v", d.Timeout, defaultDropTimeout)
+	}
+
+	if rules, err := ParseRules(nil); err != nil || len(rules.Rules) != 0 {
+		t.Errorf("ParseRules(empty) = %v, %v; want no rules", rules, err)
+	}
+	for _, invalid := range []string{
+		"rules: [{name: nothing}]",
+		"rules: [{abort: {grpcStatus: OK}}]",
+		"rules: [{abort: {grpcStatus: SLOW}}]",
+		"rules: [{delay: {fixedDelay: 0s}}]",
+		"rules: [{drop: {timeout: -1s}}]",
+		"rules: [{delay: {fixedDelay: 1s, percentage: 120}}]",
+		"rules: [{match: {method: '['}, drop: {}}]",
+		"rules: [{drop: {}, retry: {}}]",
+	} {
+		if _, err := ParseRules([]byte(invalid)); err == nil {
+			t.Errorf("ParseRules(%q) = nil error", invalid)
+		}
+	}
+}
+
+func TestInjector(t *testing.T) {
+	rules, err := ParseRules([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roll float64
+	i := &Injector{roll: func() float64 { return roll }}
+	if err := i.SetRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	handled := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handled = true
+		return "ok", nil
+	}
+	call := func(ctx context.Context, method string) error {
+		handled = false
+		_, err := i.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	chaos := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-chaos", "only-me"))
+
+	if err := call(chaos, "/genproto.CartService/GetCart"); status.Code(err) != codes.Unavailable || handled {
+		t.Errorf("GetCart with metadata: got %v, handled %v; want Unavailable", err, handled)
+	}
+	if err := call(context.Background(), "/genproto.CartService/GetCart"); err != nil || !handled {
+		t.Errorf("GetCart without metadata: got %v, handled %v; want it handled", err, handled)
+	}
+	if err := call(chaos, "/genproto.CartService/AddItem"); err != nil || !handled {
+		t.Errorf("AddItem: got %v, handled %v; want it handled", err, handled)
+	}
+
+	// Half of the catalog calls are delayed.
+	roll = 75
+	start := time.Now()
+	if err := call(context.Background(), "/genproto.ProductCatalogService/ListProducts"); err != nil || time.Since(start) >= 20*time.Millisecond {
+		t.Errorf("ListProducts outside the percentage: got %v after %v", err, time.Since(start))
+	}
+	roll = 25
+	start = time.Now()
+	if err := call(context.Background(), "/genproto.ProductCatalogService/ListProducts"); err != nil || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("ListProducts within the percentage: got %v after %v", err, time.Since(start))
+	}
+
+	// Dropped calls end with the call.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := call(ctx, "/genproto.PaymentService/Charge"); status.Code(err) != codes.DeadlineExceeded || handled {
+		t.Errorf("Charge: got %v, handled %v; want DeadlineExceeded", err, handled)
+	}
+	// Calls without a deadline are only held until the timeout of the drop.
+	if err := call(context.Background(), "/genproto.EmailService/SendOrderConfirmation"); status.Code(err) != codes.Unavailable || handled {
+		t.Errorf("SendOrderConfirmation: got %v, handled %v; want Unavailable", err, handled)
+	}
+
+	// A nil injector injects nothing.
+	var none *Injector
+	if opts := none.ServerOptions(); opts != nil {
+		t.Errorf("nil ServerOptions() = %v", opts)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("rules: [{name: a, drop: {}}]")
+	i, err := New(path, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.Watch(5 * time.Millisecond)
+	defer i.Close()
+
+	waitFor := func(name string) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if r := i.Rules().Rules; len(r) == 1 && r[0].Name == name {
+				return
+			}
+		}
+		t.Fatalf("rules = %+v, want rule %q", i.Rules().Rules, name)
+	}
+	write("rules: [{name: bb, drop: {}}]")
+	waitFor("bb")
+
+	// Invalid rules are not loaded.
+	write("rules: [{name: ccc}]")
+	time.Sleep(50 * time.Millisecond)
+	waitFor("bb")
+}
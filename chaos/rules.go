@@ -0,0 +1,182 @@
+package chaos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules is the content of a rules file.
+type Rules struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule injects faults into the calls it matches. As with Istio, a call is
+// first delayed, then aborted, and a fault applies to the given percentage of
+// calls, or to all of them if no percentage is given.
+type Rule struct {
+	// Name identifies the rule in logs and traces.
+	Name  string `yaml:"name"`
+	Match Match  `yaml:"match"`
+
+	Delay *Delay `yaml:"delay"`
+	Abort *Abort `yaml:"abort"`
+	Drop  *Drop  `yaml:"drop"`
+}
+
+// Match selects calls by the patterns of path.Match. Empty patterns match
+// everything, so a rule with an empty match also applies to health checks.
+type Match struct {
+	// Service is the full name of the service, such as
+	// "genproto.ProductCatalogService".
+	Service string `yaml:"service"`
+	// Method is the name of the method, such as "GetProduct".
+	Method string `yaml:"method"`
+	// Metadata maps incoming metadata keys to patterns one of their values
+	// must match.
+	Metadata map[string]string `yaml:"metadata"`
+}
+
+// Delay holds calls back before they are handled.
+type Delay struct {
+	FixedDelay time.Duration `yaml:"fixedDelay"`
+	Percentage *float64      `yaml:"percentage"`
+}
+
+// Abort fails calls with a gRPC status instead of handling them.
+type Abort struct {
+	// GRPCStatus is the name of the code, such as "UNAVAILABLE".
+	GRPCStatus string   `yaml:"grpcStatus"`
+	Percentage *float64 `yaml:"percentage"`
+
+	code codes.Code
+}
+
+// Drop never answers calls, like a lost request: the call is held until the
+// client gives up, or fails with Unavailable after Timeout, and is not handled.
+type Drop struct {
+	Percentage *float64 `yaml:"percentage"`
+	// Timeout bounds how long a call is held, so that calls without a
+	// deadline do not hold their handler forever. defaultDropTimeout if zero.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+const defaultDropTimeout = 30 * time.Second
+
+// ParseRules parses and validates a rules file.
+func ParseRules(b []byte) (*Rules, error) {
+	var rules Rules
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	// An empty file has no rules.
+	if err := dec.Decode(&rules); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for i := range rules.Rules {
+		if err := rules.Rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, rules.Rules[i].Name, err)
+		}
+	}
+	return &rules, nil
+}
+
+func (r *Rule) validate() error {
+	for _, p := range append([]string{r.Match.Service, r.Match.Method}, values(r.Match.Metadata)...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	if r.Delay == nil && r.Abort == nil && r.Drop == nil {
+		return errors.New("no delay, abort or drop")
+	}
+	if r.Delay != nil {
+		if r.Delay.FixedDelay <= 0 {
+			return fmt.Errorf("delay %v is not positive", r.Delay.FixedDelay)
+		}
+		if err := checkPercentage(r.Delay.Percentage); err != nil {
+			return err
+		}
+	}
+	if r.Abort != nil {
+		if err := r.Abort.code.UnmarshalJSON([]byte(`"` + r.Abort.GRPCStatus + `"`)); err != nil || r.Abort.code == codes.OK {
+			return fmt.Errorf("invalid abort status %q", r.Abort.GRPCStatus)
+		}
+		if err := checkPercentage(r.Abort.Percentage); err != nil {
+			return err
+		}
+	}
+	if r.Drop != nil {
+		if r.Drop.Timeout < 0 {
+			return fmt.Errorf("drop timeout %v is negative", r.Drop.Timeout)
+		}
+		if r.Drop.Timeout == 0 {
+			r.Drop.Timeout = defaultDropTimeout
+		}
+		if err := checkPercentage(r.Drop.Percentage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func values(m map[string]string) []string {
+	var vs []string
+	for _, v := range m {
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+func checkPercentage(p *float64) error {
+	if p != nil && (*p < 0 || *p > 100) {
+		return fmt.Errorf("percentage %v is not between 0 and 100", *p)
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to a call of fullMethod, such as
+// "/genproto.CartService/GetCart", with the incoming metadata md.
+func (r *Rule) matches(fullMethod string, md metadata.MD) bool {
+	service, method := path.Split(strings.TrimPrefix(fullMethod, "/"))
+	service = strings.TrimSuffix(service, "/")
+	if !match(r.Match.Service, service) || !match(r.Match.Method, method) {
+		return false
+	}
+	for key, pattern := range r.Match.Metadata {
+		if !matchAny(pattern, md.Get(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+func match(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+func matchAny(pattern string, values []string) bool {
+	for _, v := range values {
+		if match(pattern, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// sampled reports whether a fault with percentage applies to a call, given a
+// random number in [0, 100).
+func sampled(percentage *float64, roll float64) bool {
+	return percentage == nil || roll < *percentage
+}
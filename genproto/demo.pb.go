@@ -35,10 +35,167 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ProductSortOrder int32
+
+const (
+	// Catalog order.
+	ProductSortOrder_PRODUCT_SORT_ORDER_UNSPECIFIED ProductSortOrder = 0
+	ProductSortOrder_SORT_BY_NAME                   ProductSortOrder = 1
+	ProductSortOrder_SORT_BY_PRICE_ASCENDING        ProductSortOrder = 2
+	ProductSortOrder_SORT_BY_PRICE_DESCENDING       ProductSortOrder = 3
+)
+
+// Enum value maps for ProductSortOrder.
+var (
+	ProductSortOrder_name = map[int32]string{
+		0: "PRODUCT_SORT_ORDER_UNSPECIFIED",
+		1: "SORT_BY_NAME",
+		2: "SORT_BY_PRICE_ASCENDING",
+		3: "SORT_BY_PRICE_DESCENDING",
+	}
+	ProductSortOrder_value = map[string]int32{
+		"PRODUCT_SORT_ORDER_UNSPECIFIED": 0,
+		"SORT_BY_NAME":                   1,
+		"SORT_BY_PRICE_ASCENDING":        2,
+		"SORT_BY_PRICE_DESCENDING":       3,
+	}
+)
+
+func (x ProductSortOrder) Enum() *ProductSortOrder {
+	p := new(ProductSortOrder)
+	*p = x
+	return p
+}
+
+func (x ProductSortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProductSortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_demo_proto_enumTypes[0].Descriptor()
+}
+
+func (ProductSortOrder) Type() protoreflect.EnumType {
+	return &file_demo_proto_enumTypes[0]
+}
+
+func (x ProductSortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProductSortOrder.Descriptor instead.
+func (ProductSortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{0}
+}
+
+type ShipmentStatus int32
+
+const (
+	ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED ShipmentStatus = 0
+	ShipmentStatus_LABEL_CREATED               ShipmentStatus = 1
+	ShipmentStatus_IN_TRANSIT                  ShipmentStatus = 2
+	ShipmentStatus_DELIVERED                   ShipmentStatus = 3
+)
+
+// Enum value maps for ShipmentStatus.
+var (
+	ShipmentStatus_name = map[int32]string{
+		0: "SHIPMENT_STATUS_UNSPECIFIED",
+		1: "LABEL_CREATED",
+		2: "IN_TRANSIT",
+		3: "DELIVERED",
+	}
+	ShipmentStatus_value = map[string]int32{
+		"SHIPMENT_STATUS_UNSPECIFIED": 0,
+		"LABEL_CREATED":               1,
+		"IN_TRANSIT":                  2,
+		"DELIVERED":                   3,
+	}
+)
+
+func (x ShipmentStatus) Enum() *ShipmentStatus {
+	p := new(ShipmentStatus)
+	*p = x
+	return p
+}
+
+func (x ShipmentStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ShipmentStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_demo_proto_enumTypes[1].Descriptor()
+}
+
+func (ShipmentStatus) Type() protoreflect.EnumType {
+	return &file_demo_proto_enumTypes[1]
+}
+
+func (x ShipmentStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ShipmentStatus.Descriptor instead.
+func (ShipmentStatus) EnumDescriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{1}
+}
+
+type AdEventType int32
+
+const (
+	AdEventType_AD_EVENT_TYPE_UNSPECIFIED AdEventType = 0
+	AdEventType_AD_IMPRESSION             AdEventType = 1
+	AdEventType_AD_CLICK                  AdEventType = 2
+)
+
+// Enum value maps for AdEventType.
+var (
+	AdEventType_name = map[int32]string{
+		0: "AD_EVENT_TYPE_UNSPECIFIED",
+		1: "AD_IMPRESSION",
+		2: "AD_CLICK",
+	}
+	AdEventType_value = map[string]int32{
+		"AD_EVENT_TYPE_UNSPECIFIED": 0,
+		"AD_IMPRESSION":             1,
+		"AD_CLICK":                  2,
+	}
+)
+
+func (x AdEventType) Enum() *AdEventType {
+	p := new(AdEventType)
+	*p = x
+	return p
+}
+
+func (x AdEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AdEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_demo_proto_enumTypes[2].Descriptor()
+}
+
+func (AdEventType) Type() protoreflect.EnumType {
+	return &file_demo_proto_enumTypes[2]
+}
+
+func (x AdEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AdEventType.Descriptor instead.
+func (AdEventType) EnumDescriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{2}
+}
+
 type CartItem struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity  int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// SKU of the variant of the product, such as a size. Required for
+	// products that have variants, and empty for those that do not.
+	VariantSku    string `protobuf:"bytes,3,opt,name=variant_sku,json=variantSku,proto3" json:"variant_sku,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -87,6 +244,13 @@ func (x *CartItem) GetQuantity() int32 {
 	return 0
 }
 
+func (x *CartItem) GetVariantSku() string {
+	if x != nil {
+		return x.VariantSku
+	}
+	return ""
+}
+
 type AddItemRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
@@ -368,10 +532,17 @@ func (x *ListRecommendationsRequest) GetProductIds() []string {
 }
 
 type ListRecommendationsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ProductIds    []string               `protobuf:"bytes,1,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	ProductIds []string               `protobuf:"bytes,1,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"`
+	// The experiment the user takes part in, and the arm they were assigned
+	// to, named after the strategy that recommended the products.
+	Experiment string `protobuf:"bytes,2,opt,name=experiment,proto3" json:"experiment,omitempty"`
+	Arm        string `protobuf:"bytes,3,opt,name=arm,proto3" json:"arm,omitempty"`
+	// The products of product_ids, in the same order, with why they were
+	// recommended.
+	Recommendations []*Recommendation `protobuf:"bytes,4,rep,name=recommendations,proto3" json:"recommendations,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ListRecommendationsResponse) Reset() {
@@ -411,6 +582,142 @@ func (x *ListRecommendationsResponse) GetProductIds() []string {
 	return nil
 }
 
+func (x *ListRecommendationsResponse) GetExperiment() string {
+	if x != nil {
+		return x.Experiment
+	}
+	return ""
+}
+
+func (x *ListRecommendationsResponse) GetArm() string {
+	if x != nil {
+		return x.Arm
+	}
+	return ""
+}
+
+func (x *ListRecommendationsResponse) GetRecommendations() []*Recommendation {
+	if x != nil {
+		return x.Recommendations
+	}
+	return nil
+}
+
+type Recommendation struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	// Such as "bought-together", "same-category", "similar-content" or
+	// "random".
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Recommendation) Reset() {
+	*x = Recommendation{}
+	mi := &file_demo_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Recommendation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Recommendation) ProtoMessage() {}
+
+func (x *Recommendation) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Recommendation.ProtoReflect.Descriptor instead.
+func (*Recommendation) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Recommendation) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *Recommendation) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type OrderEvent struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	OrderId string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	UserId  string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// The distinct products of the order.
+	ProductIds    []string `protobuf:"bytes,3,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	mi := &file_demo_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *OrderEvent) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetProductIds() []string {
+	if x != nil {
+		return x.ProductIds
+	}
+	return nil
+}
+
 type Product struct {
 	state       protoimpl.MessageState `protogen:"open.v1"`
 	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -420,14 +727,25 @@ type Product struct {
 	PriceUsd    *Money                 `protobuf:"bytes,5,opt,name=price_usd,json=priceUsd,proto3" json:"price_usd,omitempty"`
 	// Categories such as "clothing" or "kitchen" that can be used to look up
 	// other related products.
-	Categories    []string `protobuf:"bytes,6,rep,name=categories,proto3" json:"categories,omitempty"`
+	Categories []string `protobuf:"bytes,6,rep,name=categories,proto3" json:"categories,omitempty"`
+	// Shipping weight of a single unit, including packaging.
+	WeightGrams int32 `protobuf:"varint,7,opt,name=weight_grams,json=weightGrams,proto3" json:"weight_grams,omitempty"`
+	// Outer dimensions of a single packaged unit.
+	Dimensions *Dimensions `protobuf:"bytes,8,opt,name=dimensions,proto3" json:"dimensions,omitempty"`
+	// Opaque version of the product, set by the catalog whenever the product
+	// changes. Pass it back when updating or deleting the product to only do
+	// so if nobody else changed it in the meantime.
+	Etag string `protobuf:"bytes,9,opt,name=etag,proto3" json:"etag,omitempty"`
+	// Versions of the product that can be bought, such as sizes. Products
+	// without variants are bought as they are.
+	Variants      []*ProductVariant `protobuf:"bytes,10,rep,name=variants,proto3" json:"variants,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Product) Reset() {
 	*x = Product{}
-	mi := &file_demo_proto_msgTypes[8]
+	mi := &file_demo_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -439,7 +757,7 @@ func (x *Product) String() string {
 func (*Product) ProtoMessage() {}
 
 func (x *Product) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[8]
+	mi := &file_demo_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -452,7 +770,7 @@ func (x *Product) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Product.ProtoReflect.Descriptor instead.
 func (*Product) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{8}
+	return file_demo_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *Product) GetId() string {
@@ -497,72 +815,61 @@ func (x *Product) GetCategories() []string {
 	return nil
 }
 
-type ListProductsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Products      []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *ListProductsResponse) Reset() {
-	*x = ListProductsResponse{}
-	mi := &file_demo_proto_msgTypes[9]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *ListProductsResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Product) GetWeightGrams() int32 {
+	if x != nil {
+		return x.WeightGrams
+	}
+	return 0
 }
 
-func (*ListProductsResponse) ProtoMessage() {}
-
-func (x *ListProductsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[9]
+func (x *Product) GetDimensions() *Dimensions {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Dimensions
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use ListProductsResponse.ProtoReflect.Descriptor instead.
-func (*ListProductsResponse) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{9}
+func (x *Product) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
 }
 
-func (x *ListProductsResponse) GetProducts() []*Product {
+func (x *Product) GetVariants() []*ProductVariant {
 	if x != nil {
-		return x.Products
+		return x.Variants
 	}
 	return nil
 }
 
-type GetProductRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+type ProductVariant struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Stock keeping unit, unique across the catalog.
+	Sku string `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	// What sets the variant apart, such as {"size": "M"}.
+	Attributes map[string]string `protobuf:"bytes,2,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Price of the variant, if it differs from the product's price_usd.
+	PriceUsd      *Money `protobuf:"bytes,3,opt,name=price_usd,json=priceUsd,proto3" json:"price_usd,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetProductRequest) Reset() {
-	*x = GetProductRequest{}
-	mi := &file_demo_proto_msgTypes[10]
+func (x *ProductVariant) Reset() {
+	*x = ProductVariant{}
+	mi := &file_demo_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetProductRequest) String() string {
+func (x *ProductVariant) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetProductRequest) ProtoMessage() {}
+func (*ProductVariant) ProtoMessage() {}
 
-func (x *GetProductRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[10]
+func (x *ProductVariant) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -573,40 +880,56 @@ func (x *GetProductRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetProductRequest.ProtoReflect.Descriptor instead.
-func (*GetProductRequest) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ProductVariant.ProtoReflect.Descriptor instead.
+func (*ProductVariant) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *GetProductRequest) GetId() string {
+func (x *ProductVariant) GetSku() string {
 	if x != nil {
-		return x.Id
+		return x.Sku
 	}
 	return ""
 }
 
-type SearchProductsRequest struct {
+func (x *ProductVariant) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *ProductVariant) GetPriceUsd() *Money {
+	if x != nil {
+		return x.PriceUsd
+	}
+	return nil
+}
+
+type Dimensions struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	LengthMm      int32                  `protobuf:"varint,1,opt,name=length_mm,json=lengthMm,proto3" json:"length_mm,omitempty"`
+	WidthMm       int32                  `protobuf:"varint,2,opt,name=width_mm,json=widthMm,proto3" json:"width_mm,omitempty"`
+	HeightMm      int32                  `protobuf:"varint,3,opt,name=height_mm,json=heightMm,proto3" json:"height_mm,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SearchProductsRequest) Reset() {
-	*x = SearchProductsRequest{}
-	mi := &file_demo_proto_msgTypes[11]
+func (x *Dimensions) Reset() {
+	*x = Dimensions{}
+	mi := &file_demo_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SearchProductsRequest) String() string {
+func (x *Dimensions) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SearchProductsRequest) ProtoMessage() {}
+func (*Dimensions) ProtoMessage() {}
 
-func (x *SearchProductsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[11]
+func (x *Dimensions) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -617,84 +940,65 @@ func (x *SearchProductsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SearchProductsRequest.ProtoReflect.Descriptor instead.
-func (*SearchProductsRequest) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use Dimensions.ProtoReflect.Descriptor instead.
+func (*Dimensions) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *SearchProductsRequest) GetQuery() string {
+func (x *Dimensions) GetLengthMm() int32 {
 	if x != nil {
-		return x.Query
+		return x.LengthMm
 	}
-	return ""
-}
-
-type SearchProductsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Results       []*Product             `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *SearchProductsResponse) Reset() {
-	*x = SearchProductsResponse{}
-	mi := &file_demo_proto_msgTypes[12]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *SearchProductsResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+	return 0
 }
 
-func (*SearchProductsResponse) ProtoMessage() {}
-
-func (x *SearchProductsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[12]
+func (x *Dimensions) GetWidthMm() int32 {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.WidthMm
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use SearchProductsResponse.ProtoReflect.Descriptor instead.
-func (*SearchProductsResponse) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{12}
+	return 0
 }
 
-func (x *SearchProductsResponse) GetResults() []*Product {
+func (x *Dimensions) GetHeightMm() int32 {
 	if x != nil {
-		return x.Results
+		return x.HeightMm
 	}
-	return nil
+	return 0
 }
 
-type GetQuoteRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Address       *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	Items         []*CartItem            `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+type ListProductsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Maximum number of products to return. The server picks a default when
+	// zero and caps larger values.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous response, to get the following page.
+	// The other fields must not change between pages.
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Only list products in this category, if set.
+	Category string `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	// Only list products priced within these bounds, if set. The bounds may
+	// be in any supported currency.
+	MinPrice      *Money           `protobuf:"bytes,4,opt,name=min_price,json=minPrice,proto3" json:"min_price,omitempty"`
+	MaxPrice      *Money           `protobuf:"bytes,5,opt,name=max_price,json=maxPrice,proto3" json:"max_price,omitempty"`
+	SortOrder     ProductSortOrder `protobuf:"varint,6,opt,name=sort_order,json=sortOrder,proto3,enum=genproto.ProductSortOrder" json:"sort_order,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetQuoteRequest) Reset() {
-	*x = GetQuoteRequest{}
+func (x *ListProductsRequest) Reset() {
+	*x = ListProductsRequest{}
 	mi := &file_demo_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetQuoteRequest) String() string {
+func (x *ListProductsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetQuoteRequest) ProtoMessage() {}
+func (*ListProductsRequest) ProtoMessage() {}
 
-func (x *GetQuoteRequest) ProtoReflect() protoreflect.Message {
+func (x *ListProductsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -706,46 +1010,79 @@ func (x *GetQuoteRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetQuoteRequest.ProtoReflect.Descriptor instead.
-func (*GetQuoteRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListProductsRequest.ProtoReflect.Descriptor instead.
+func (*ListProductsRequest) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *GetQuoteRequest) GetAddress() *Address {
+func (x *ListProductsRequest) GetPageSize() int32 {
 	if x != nil {
-		return x.Address
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListProductsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ListProductsRequest) GetMinPrice() *Money {
+	if x != nil {
+		return x.MinPrice
 	}
 	return nil
 }
 
-func (x *GetQuoteRequest) GetItems() []*CartItem {
+func (x *ListProductsRequest) GetMaxPrice() *Money {
 	if x != nil {
-		return x.Items
+		return x.MaxPrice
 	}
 	return nil
 }
 
-type GetQuoteResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	CostUsd       *Money                 `protobuf:"bytes,1,opt,name=cost_usd,json=costUsd,proto3" json:"cost_usd,omitempty"`
+func (x *ListProductsRequest) GetSortOrder() ProductSortOrder {
+	if x != nil {
+		return x.SortOrder
+	}
+	return ProductSortOrder_PRODUCT_SORT_ORDER_UNSPECIFIED
+}
+
+type ListProductsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Products []*Product             `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
+	// Number of matching products across all pages. Only set by
+	// ListProductsPage.
+	TotalSize int32 `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	// Token for the next page, empty on the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetQuoteResponse) Reset() {
-	*x = GetQuoteResponse{}
+func (x *ListProductsResponse) Reset() {
+	*x = ListProductsResponse{}
 	mi := &file_demo_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetQuoteResponse) String() string {
+func (x *ListProductsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetQuoteResponse) ProtoMessage() {}
+func (*ListProductsResponse) ProtoMessage() {}
 
-func (x *GetQuoteResponse) ProtoReflect() protoreflect.Message {
+func (x *ListProductsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -757,40 +1094,53 @@ func (x *GetQuoteResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetQuoteResponse.ProtoReflect.Descriptor instead.
-func (*GetQuoteResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListProductsResponse.ProtoReflect.Descriptor instead.
+func (*ListProductsResponse) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *GetQuoteResponse) GetCostUsd() *Money {
+func (x *ListProductsResponse) GetProducts() []*Product {
 	if x != nil {
-		return x.CostUsd
+		return x.Products
 	}
 	return nil
 }
 
-type ShipOrderRequest struct {
+func (x *ListProductsResponse) GetTotalSize() int32 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *ListProductsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetProductRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Address       *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	Items         []*CartItem            `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ShipOrderRequest) Reset() {
-	*x = ShipOrderRequest{}
+func (x *GetProductRequest) Reset() {
+	*x = GetProductRequest{}
 	mi := &file_demo_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ShipOrderRequest) String() string {
+func (x *GetProductRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ShipOrderRequest) ProtoMessage() {}
+func (*GetProductRequest) ProtoMessage() {}
 
-func (x *ShipOrderRequest) ProtoReflect() protoreflect.Message {
+func (x *GetProductRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -802,46 +1152,40 @@ func (x *ShipOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ShipOrderRequest.ProtoReflect.Descriptor instead.
-func (*ShipOrderRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetProductRequest.ProtoReflect.Descriptor instead.
+func (*GetProductRequest) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *ShipOrderRequest) GetAddress() *Address {
-	if x != nil {
-		return x.Address
-	}
-	return nil
-}
-
-func (x *ShipOrderRequest) GetItems() []*CartItem {
+func (x *GetProductRequest) GetId() string {
 	if x != nil {
-		return x.Items
+		return x.Id
 	}
-	return nil
+	return ""
 }
 
-type ShipOrderResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TrackingId    string                 `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+type CreateProductRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The product to add. Its ID must not be in use yet.
+	Product       *Product `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ShipOrderResponse) Reset() {
-	*x = ShipOrderResponse{}
+func (x *CreateProductRequest) Reset() {
+	*x = CreateProductRequest{}
 	mi := &file_demo_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ShipOrderResponse) String() string {
+func (x *CreateProductRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ShipOrderResponse) ProtoMessage() {}
+func (*CreateProductRequest) ProtoMessage() {}
 
-func (x *ShipOrderResponse) ProtoReflect() protoreflect.Message {
+func (x *CreateProductRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -853,43 +1197,41 @@ func (x *ShipOrderResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ShipOrderResponse.ProtoReflect.Descriptor instead.
-func (*ShipOrderResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use CreateProductRequest.ProtoReflect.Descriptor instead.
+func (*CreateProductRequest) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *ShipOrderResponse) GetTrackingId() string {
+func (x *CreateProductRequest) GetProduct() *Product {
 	if x != nil {
-		return x.TrackingId
+		return x.Product
 	}
-	return ""
+	return nil
 }
 
-type Address struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	StreetAddress string                 `protobuf:"bytes,1,opt,name=street_address,json=streetAddress,proto3" json:"street_address,omitempty"`
-	City          string                 `protobuf:"bytes,2,opt,name=city,proto3" json:"city,omitempty"`
-	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
-	Country       string                 `protobuf:"bytes,4,opt,name=country,proto3" json:"country,omitempty"`
-	ZipCode       int32                  `protobuf:"varint,5,opt,name=zip_code,json=zipCode,proto3" json:"zip_code,omitempty"`
+type UpdateProductRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The new version of the product with the same ID. Its etag is required
+	// and must match the current one.
+	Product       *Product `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Address) Reset() {
-	*x = Address{}
+func (x *UpdateProductRequest) Reset() {
+	*x = UpdateProductRequest{}
 	mi := &file_demo_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Address) String() string {
+func (x *UpdateProductRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Address) ProtoMessage() {}
+func (*UpdateProductRequest) ProtoMessage() {}
 
-func (x *Address) ProtoReflect() protoreflect.Message {
+func (x *UpdateProductRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -901,79 +1243,41 @@ func (x *Address) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Address.ProtoReflect.Descriptor instead.
-func (*Address) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateProductRequest.ProtoReflect.Descriptor instead.
+func (*UpdateProductRequest) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *Address) GetStreetAddress() string {
-	if x != nil {
-		return x.StreetAddress
-	}
-	return ""
-}
-
-func (x *Address) GetCity() string {
-	if x != nil {
-		return x.City
-	}
-	return ""
-}
-
-func (x *Address) GetState() string {
-	if x != nil {
-		return x.State
-	}
-	return ""
-}
-
-func (x *Address) GetCountry() string {
-	if x != nil {
-		return x.Country
-	}
-	return ""
-}
-
-func (x *Address) GetZipCode() int32 {
+func (x *UpdateProductRequest) GetProduct() *Product {
 	if x != nil {
-		return x.ZipCode
+		return x.Product
 	}
-	return 0
+	return nil
 }
 
-// Represents an amount of money with its currency type.
-type Money struct {
+type DeleteProductRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The 3-letter currency code defined in ISO 4217.
-	CurrencyCode string `protobuf:"bytes,1,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
-	// The whole units of the amount.
-	// For example if `currencyCode` is `"USD"`, then 1 unit is one US dollar.
-	Units int64 `protobuf:"varint,2,opt,name=units,proto3" json:"units,omitempty"`
-	// Number of nano (10^-9) units of the amount.
-	// The value must be between -999,999,999 and +999,999,999 inclusive.
-	// If `units` is positive, `nanos` must be positive or zero.
-	// If `units` is zero, `nanos` can be positive, zero, or negative.
-	// If `units` is negative, `nanos` must be negative or zero.
-	// For example $-1.75 is represented as `units`=-1 and `nanos`=-750,000,000.
-	Nanos         int32 `protobuf:"varint,3,opt,name=nanos,proto3" json:"nanos,omitempty"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Required. The product is only deleted if its etag still matches.
+	Etag          string `protobuf:"bytes,2,opt,name=etag,proto3" json:"etag,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Money) Reset() {
-	*x = Money{}
+func (x *DeleteProductRequest) Reset() {
+	*x = DeleteProductRequest{}
 	mi := &file_demo_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Money) String() string {
+func (x *DeleteProductRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Money) ProtoMessage() {}
+func (*DeleteProductRequest) ProtoMessage() {}
 
-func (x *Money) ProtoReflect() protoreflect.Message {
+func (x *DeleteProductRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -985,54 +1289,51 @@ func (x *Money) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Money.ProtoReflect.Descriptor instead.
-func (*Money) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeleteProductRequest.ProtoReflect.Descriptor instead.
+func (*DeleteProductRequest) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *Money) GetCurrencyCode() string {
+func (x *DeleteProductRequest) GetId() string {
 	if x != nil {
-		return x.CurrencyCode
+		return x.Id
 	}
 	return ""
 }
 
-func (x *Money) GetUnits() int64 {
-	if x != nil {
-		return x.Units
-	}
-	return 0
-}
-
-func (x *Money) GetNanos() int32 {
+func (x *DeleteProductRequest) GetEtag() string {
 	if x != nil {
-		return x.Nanos
+		return x.Etag
 	}
-	return 0
+	return ""
 }
 
-type GetSupportedCurrenciesResponse struct {
+type SearchProductsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The 3-letter currency code defined in ISO 4217.
-	CurrencyCodes []string `protobuf:"bytes,1,rep,name=currency_codes,json=currencyCodes,proto3" json:"currency_codes,omitempty"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Maximum number of results to return. The server picks a default when
+	// zero and caps larger values.
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token from a previous response, to get the following page.
+	PageToken     string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetSupportedCurrenciesResponse) Reset() {
-	*x = GetSupportedCurrenciesResponse{}
+func (x *SearchProductsRequest) Reset() {
+	*x = SearchProductsRequest{}
 	mi := &file_demo_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetSupportedCurrenciesResponse) String() string {
+func (x *SearchProductsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetSupportedCurrenciesResponse) ProtoMessage() {}
+func (*SearchProductsRequest) ProtoMessage() {}
 
-func (x *GetSupportedCurrenciesResponse) ProtoReflect() protoreflect.Message {
+func (x *SearchProductsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1044,41 +1345,58 @@ func (x *GetSupportedCurrenciesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetSupportedCurrenciesResponse.ProtoReflect.Descriptor instead.
-func (*GetSupportedCurrenciesResponse) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use SearchProductsRequest.ProtoReflect.Descriptor instead.
+func (*SearchProductsRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *GetSupportedCurrenciesResponse) GetCurrencyCodes() []string {
+func (x *SearchProductsRequest) GetQuery() string {
 	if x != nil {
-		return x.CurrencyCodes
+		return x.Query
 	}
-	return nil
+	return ""
 }
 
-type CurrencyConversionRequest struct {
+func (x *SearchProductsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *SearchProductsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type SearchProductsResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	From  *Money                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
-	// The 3-letter currency code defined in ISO 4217.
-	ToCode        string `protobuf:"bytes,2,opt,name=to_code,json=toCode,proto3" json:"to_code,omitempty"`
+	// Matching products, most relevant first.
+	Results []*Product `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	// Number of matching products across all pages.
+	TotalSize int32 `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	// Token for the next page, empty on the last page.
+	NextPageToken string `protobuf:"bytes,3,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CurrencyConversionRequest) Reset() {
-	*x = CurrencyConversionRequest{}
+func (x *SearchProductsResponse) Reset() {
+	*x = SearchProductsResponse{}
 	mi := &file_demo_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CurrencyConversionRequest) String() string {
+func (x *SearchProductsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CurrencyConversionRequest) ProtoMessage() {}
+func (*SearchProductsResponse) ProtoMessage() {}
 
-func (x *CurrencyConversionRequest) ProtoReflect() protoreflect.Message {
+func (x *SearchProductsResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1090,49 +1408,54 @@ func (x *CurrencyConversionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CurrencyConversionRequest.ProtoReflect.Descriptor instead.
-func (*CurrencyConversionRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use SearchProductsResponse.ProtoReflect.Descriptor instead.
+func (*SearchProductsResponse) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *CurrencyConversionRequest) GetFrom() *Money {
+func (x *SearchProductsResponse) GetResults() []*Product {
 	if x != nil {
-		return x.From
+		return x.Results
 	}
 	return nil
 }
 
-func (x *CurrencyConversionRequest) GetToCode() string {
+func (x *SearchProductsResponse) GetTotalSize() int32 {
 	if x != nil {
-		return x.ToCode
+		return x.TotalSize
+	}
+	return 0
+}
+
+func (x *SearchProductsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
 	}
 	return ""
 }
 
-type CreditCardInfo struct {
-	state                     protoimpl.MessageState `protogen:"open.v1"`
-	CreditCardNumber          string                 `protobuf:"bytes,1,opt,name=credit_card_number,json=creditCardNumber,proto3" json:"credit_card_number,omitempty"`
-	CreditCardCvv             int32                  `protobuf:"varint,2,opt,name=credit_card_cvv,json=creditCardCvv,proto3" json:"credit_card_cvv,omitempty"`
-	CreditCardExpirationYear  int32                  `protobuf:"varint,3,opt,name=credit_card_expiration_year,json=creditCardExpirationYear,proto3" json:"credit_card_expiration_year,omitempty"`
-	CreditCardExpirationMonth int32                  `protobuf:"varint,4,opt,name=credit_card_expiration_month,json=creditCardExpirationMonth,proto3" json:"credit_card_expiration_month,omitempty"`
-	unknownFields             protoimpl.UnknownFields
-	sizeCache                 protoimpl.SizeCache
+type GetQuoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Items         []*CartItem            `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreditCardInfo) Reset() {
-	*x = CreditCardInfo{}
+func (x *GetQuoteRequest) Reset() {
+	*x = GetQuoteRequest{}
 	mi := &file_demo_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreditCardInfo) String() string {
+func (x *GetQuoteRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreditCardInfo) ProtoMessage() {}
+func (*GetQuoteRequest) ProtoMessage() {}
 
-func (x *CreditCardInfo) ProtoReflect() protoreflect.Message {
+func (x *GetQuoteRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1144,61 +1467,49 @@ func (x *CreditCardInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreditCardInfo.ProtoReflect.Descriptor instead.
-func (*CreditCardInfo) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetQuoteRequest.ProtoReflect.Descriptor instead.
+func (*GetQuoteRequest) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{21}
 }
 
-func (x *CreditCardInfo) GetCreditCardNumber() string {
-	if x != nil {
-		return x.CreditCardNumber
-	}
-	return ""
-}
-
-func (x *CreditCardInfo) GetCreditCardCvv() int32 {
-	if x != nil {
-		return x.CreditCardCvv
-	}
-	return 0
-}
-
-func (x *CreditCardInfo) GetCreditCardExpirationYear() int32 {
+func (x *GetQuoteRequest) GetAddress() *Address {
 	if x != nil {
-		return x.CreditCardExpirationYear
+		return x.Address
 	}
-	return 0
+	return nil
 }
 
-func (x *CreditCardInfo) GetCreditCardExpirationMonth() int32 {
+func (x *GetQuoteRequest) GetItems() []*CartItem {
 	if x != nil {
-		return x.CreditCardExpirationMonth
+		return x.Items
 	}
-	return 0
+	return nil
 }
 
-type ChargeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Amount        *Money                 `protobuf:"bytes,1,opt,name=amount,proto3" json:"amount,omitempty"`
-	CreditCard    *CreditCardInfo        `protobuf:"bytes,2,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+type GetQuoteResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cost of the cheapest option, for callers that do not pick a method.
+	CostUsd *Money `protobuf:"bytes,1,opt,name=cost_usd,json=costUsd,proto3" json:"cost_usd,omitempty"`
+	// Every shipping method available for the destination, cheapest first.
+	Options       []*ShippingOption `protobuf:"bytes,2,rep,name=options,proto3" json:"options,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ChargeRequest) Reset() {
-	*x = ChargeRequest{}
+func (x *GetQuoteResponse) Reset() {
+	*x = GetQuoteResponse{}
 	mi := &file_demo_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChargeRequest) String() string {
+func (x *GetQuoteResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChargeRequest) ProtoMessage() {}
+func (*GetQuoteResponse) ProtoMessage() {}
 
-func (x *ChargeRequest) ProtoReflect() protoreflect.Message {
+func (x *GetQuoteResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1210,46 +1521,52 @@ func (x *ChargeRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChargeRequest.ProtoReflect.Descriptor instead.
-func (*ChargeRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetQuoteResponse.ProtoReflect.Descriptor instead.
+func (*GetQuoteResponse) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{22}
 }
 
-func (x *ChargeRequest) GetAmount() *Money {
+func (x *GetQuoteResponse) GetCostUsd() *Money {
 	if x != nil {
-		return x.Amount
+		return x.CostUsd
 	}
 	return nil
 }
 
-func (x *ChargeRequest) GetCreditCard() *CreditCardInfo {
+func (x *GetQuoteResponse) GetOptions() []*ShippingOption {
 	if x != nil {
-		return x.CreditCard
+		return x.Options
 	}
 	return nil
 }
 
-type ChargeResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+type ShippingOption struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Method identifier such as "standard" or "express".
+	Method      string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	DisplayName string `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	CostUsd     *Money `protobuf:"bytes,3,opt,name=cost_usd,json=costUsd,proto3" json:"cost_usd,omitempty"`
+	// Estimated delivery window in days.
+	MinDays       int32 `protobuf:"varint,4,opt,name=min_days,json=minDays,proto3" json:"min_days,omitempty"`
+	MaxDays       int32 `protobuf:"varint,5,opt,name=max_days,json=maxDays,proto3" json:"max_days,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ChargeResponse) Reset() {
-	*x = ChargeResponse{}
+func (x *ShippingOption) Reset() {
+	*x = ShippingOption{}
 	mi := &file_demo_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChargeResponse) String() string {
+func (x *ShippingOption) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChargeResponse) ProtoMessage() {}
+func (*ShippingOption) ProtoMessage() {}
 
-func (x *ChargeResponse) ProtoReflect() protoreflect.Message {
+func (x *ShippingOption) ProtoReflect() protoreflect.Message {
 	mi := &file_demo_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1261,41 +1578,1664 @@ func (x *ChargeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChargeResponse.ProtoReflect.Descriptor instead.
-func (*ChargeResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ShippingOption.ProtoReflect.Descriptor instead.
+func (*ShippingOption) Descriptor() ([]byte, []int) {
 	return file_demo_proto_rawDescGZIP(), []int{23}
 }
 
-func (x *ChargeResponse) GetTransactionId() string {
+func (x *ShippingOption) GetMethod() string {
 	if x != nil {
-		return x.TransactionId
+		return x.Method
 	}
 	return ""
 }
 
-type OrderItem struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Item          *CartItem              `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
-	Cost          *Money                 `protobuf:"bytes,2,opt,name=cost,proto3" json:"cost,omitempty"`
+func (x *ShippingOption) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *ShippingOption) GetCostUsd() *Money {
+	if x != nil {
+		return x.CostUsd
+	}
+	return nil
+}
+
+func (x *ShippingOption) GetMinDays() int32 {
+	if x != nil {
+		return x.MinDays
+	}
+	return 0
+}
+
+func (x *ShippingOption) GetMaxDays() int32 {
+	if x != nil {
+		return x.MaxDays
+	}
+	return 0
+}
+
+type ShipOrderRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Items   []*CartItem            `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	// Method chosen from GetQuoteResponse.options. Empty means the cheapest.
+	ShippingMethod string `protobuf:"bytes,3,opt,name=shipping_method,json=shippingMethod,proto3" json:"shipping_method,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ShipOrderRequest) Reset() {
+	*x = ShipOrderRequest{}
+	mi := &file_demo_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShipOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShipOrderRequest) ProtoMessage() {}
+
+func (x *ShipOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShipOrderRequest.ProtoReflect.Descriptor instead.
+func (*ShipOrderRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ShipOrderRequest) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *ShipOrderRequest) GetItems() []*CartItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ShipOrderRequest) GetShippingMethod() string {
+	if x != nil {
+		return x.ShippingMethod
+	}
+	return ""
+}
+
+type ShipOrderResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Tracking ID of the first package, for callers that expect one.
+	TrackingId string `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	// One entry per package the order was split into.
+	Shipments     []*Shipment `protobuf:"bytes,2,rep,name=shipments,proto3" json:"shipments,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *OrderItem) Reset() {
-	*x = OrderItem{}
-	mi := &file_demo_proto_msgTypes[24]
+func (x *ShipOrderResponse) Reset() {
+	*x = ShipOrderResponse{}
+	mi := &file_demo_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShipOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShipOrderResponse) ProtoMessage() {}
+
+func (x *ShipOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShipOrderResponse.ProtoReflect.Descriptor instead.
+func (*ShipOrderResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ShipOrderResponse) GetTrackingId() string {
+	if x != nil {
+		return x.TrackingId
+	}
+	return ""
+}
+
+func (x *ShipOrderResponse) GetShipments() []*Shipment {
+	if x != nil {
+		return x.Shipments
+	}
+	return nil
+}
+
+// A package of an order with its own tracking ID.
+type Shipment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TrackingId    string                 `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	Items         []*CartItem            `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	WeightGrams   int32                  `protobuf:"varint,3,opt,name=weight_grams,json=weightGrams,proto3" json:"weight_grams,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Shipment) Reset() {
+	*x = Shipment{}
+	mi := &file_demo_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Shipment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Shipment) ProtoMessage() {}
+
+func (x *Shipment) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Shipment.ProtoReflect.Descriptor instead.
+func (*Shipment) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Shipment) GetTrackingId() string {
+	if x != nil {
+		return x.TrackingId
+	}
+	return ""
+}
+
+func (x *Shipment) GetItems() []*CartItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *Shipment) GetWeightGrams() int32 {
+	if x != nil {
+		return x.WeightGrams
+	}
+	return 0
+}
+
+type TrackShipmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TrackingId    string                 `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackShipmentRequest) Reset() {
+	*x = TrackShipmentRequest{}
+	mi := &file_demo_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackShipmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackShipmentRequest) ProtoMessage() {}
+
+func (x *TrackShipmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackShipmentRequest.ProtoReflect.Descriptor instead.
+func (*TrackShipmentRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *TrackShipmentRequest) GetTrackingId() string {
+	if x != nil {
+		return x.TrackingId
+	}
+	return ""
+}
+
+type ShipmentEvent struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status ShipmentStatus         `protobuf:"varint,1,opt,name=status,proto3,enum=genproto.ShipmentStatus" json:"status,omitempty"`
+	// Seconds since the Unix epoch.
+	TimeUnix      int64  `protobuf:"varint,2,opt,name=time_unix,json=timeUnix,proto3" json:"time_unix,omitempty"`
+	Description   string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShipmentEvent) Reset() {
+	*x = ShipmentEvent{}
+	mi := &file_demo_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShipmentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShipmentEvent) ProtoMessage() {}
+
+func (x *ShipmentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShipmentEvent.ProtoReflect.Descriptor instead.
+func (*ShipmentEvent) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ShipmentEvent) GetStatus() ShipmentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED
+}
+
+func (x *ShipmentEvent) GetTimeUnix() int64 {
+	if x != nil {
+		return x.TimeUnix
+	}
+	return 0
+}
+
+func (x *ShipmentEvent) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type TrackShipmentResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TrackingId     string                 `protobuf:"bytes,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	Status         ShipmentStatus         `protobuf:"varint,2,opt,name=status,proto3,enum=genproto.ShipmentStatus" json:"status,omitempty"`
+	ShippingMethod string                 `protobuf:"bytes,3,opt,name=shipping_method,json=shippingMethod,proto3" json:"shipping_method,omitempty"`
+	Address        *Address               `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	// Events reached so far, oldest first.
+	Events []*ShipmentEvent `protobuf:"bytes,5,rep,name=events,proto3" json:"events,omitempty"`
+	// Expected delivery time in seconds since the Unix epoch.
+	EstimatedDeliveryUnix int64 `protobuf:"varint,6,opt,name=estimated_delivery_unix,json=estimatedDeliveryUnix,proto3" json:"estimated_delivery_unix,omitempty"`
+	// Items in this package.
+	Items         []*CartItem `protobuf:"bytes,7,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackShipmentResponse) Reset() {
+	*x = TrackShipmentResponse{}
+	mi := &file_demo_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackShipmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackShipmentResponse) ProtoMessage() {}
+
+func (x *TrackShipmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackShipmentResponse.ProtoReflect.Descriptor instead.
+func (*TrackShipmentResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TrackShipmentResponse) GetTrackingId() string {
+	if x != nil {
+		return x.TrackingId
+	}
+	return ""
+}
+
+func (x *TrackShipmentResponse) GetStatus() ShipmentStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ShipmentStatus_SHIPMENT_STATUS_UNSPECIFIED
+}
+
+func (x *TrackShipmentResponse) GetShippingMethod() string {
+	if x != nil {
+		return x.ShippingMethod
+	}
+	return ""
+}
+
+func (x *TrackShipmentResponse) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *TrackShipmentResponse) GetEvents() []*ShipmentEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *TrackShipmentResponse) GetEstimatedDeliveryUnix() int64 {
+	if x != nil {
+		return x.EstimatedDeliveryUnix
+	}
+	return 0
+}
+
+func (x *TrackShipmentResponse) GetItems() []*CartItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type ValidateAddressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateAddressRequest) Reset() {
+	*x = ValidateAddressRequest{}
+	mi := &file_demo_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateAddressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateAddressRequest) ProtoMessage() {}
+
+func (x *ValidateAddressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateAddressRequest.ProtoReflect.Descriptor instead.
+func (*ValidateAddressRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ValidateAddressRequest) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+type ValidateAddressResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Valid bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	// The address in canonical form: trimmed fields, canonical country name
+	// and postal code formatted for the country. Set even when invalid.
+	Address       *Address            `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Violations    []*AddressViolation `protobuf:"bytes,3,rep,name=violations,proto3" json:"violations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateAddressResponse) Reset() {
+	*x = ValidateAddressResponse{}
+	mi := &file_demo_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateAddressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateAddressResponse) ProtoMessage() {}
+
+func (x *ValidateAddressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateAddressResponse.ProtoReflect.Descriptor instead.
+func (*ValidateAddressResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ValidateAddressResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ValidateAddressResponse) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *ValidateAddressResponse) GetViolations() []*AddressViolation {
+	if x != nil {
+		return x.Violations
+	}
+	return nil
+}
+
+type AddressViolation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Address field name, such as "postal_code".
+	Field         string `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Description   string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddressViolation) Reset() {
+	*x = AddressViolation{}
+	mi := &file_demo_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddressViolation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddressViolation) ProtoMessage() {}
+
+func (x *AddressViolation) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddressViolation.ProtoReflect.Descriptor instead.
+func (*AddressViolation) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *AddressViolation) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *AddressViolation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type Address struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StreetAddress string                 `protobuf:"bytes,1,opt,name=street_address,json=streetAddress,proto3" json:"street_address,omitempty"`
+	City          string                 `protobuf:"bytes,2,opt,name=city,proto3" json:"city,omitempty"`
+	State         string                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Country       string                 `protobuf:"bytes,4,opt,name=country,proto3" json:"country,omitempty"`
+	// Numeric postal code. Superseded by postal_code, which keeps leading
+	// zeros and letters; only read when postal_code is empty.
+	ZipCode       int32  `protobuf:"varint,5,opt,name=zip_code,json=zipCode,proto3" json:"zip_code,omitempty"`
+	PostalCode    string `protobuf:"bytes,6,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_demo_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Address) GetStreetAddress() string {
+	if x != nil {
+		return x.StreetAddress
+	}
+	return ""
+}
+
+func (x *Address) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Address) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Address) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Address) GetZipCode() int32 {
+	if x != nil {
+		return x.ZipCode
+	}
+	return 0
+}
+
+func (x *Address) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+// Represents an amount of money with its currency type.
+type Money struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The 3-letter currency code defined in ISO 4217.
+	CurrencyCode string `protobuf:"bytes,1,opt,name=currency_code,json=currencyCode,proto3" json:"currency_code,omitempty"`
+	// The whole units of the amount.
+	// For example if `currencyCode` is `"USD"`, then 1 unit is one US dollar.
+	Units int64 `protobuf:"varint,2,opt,name=units,proto3" json:"units,omitempty"`
+	// Number of nano (10^-9) units of the amount.
+	// The value must be between -999,999,999 and +999,999,999 inclusive.
+	// If `units` is positive, `nanos` must be positive or zero.
+	// If `units` is zero, `nanos` can be positive, zero, or negative.
+	// If `units` is negative, `nanos` must be negative or zero.
+	// For example $-1.75 is represented as `units`=-1 and `nanos`=-750,000,000.
+	Nanos         int32 `protobuf:"varint,3,opt,name=nanos,proto3" json:"nanos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Money) Reset() {
+	*x = Money{}
+	mi := &file_demo_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Money) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Money) ProtoMessage() {}
+
+func (x *Money) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Money.ProtoReflect.Descriptor instead.
+func (*Money) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Money) GetCurrencyCode() string {
+	if x != nil {
+		return x.CurrencyCode
+	}
+	return ""
+}
+
+func (x *Money) GetUnits() int64 {
+	if x != nil {
+		return x.Units
+	}
+	return 0
+}
+
+func (x *Money) GetNanos() int32 {
+	if x != nil {
+		return x.Nanos
+	}
+	return 0
+}
+
+type GetSupportedCurrenciesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The 3-letter currency code defined in ISO 4217.
+	CurrencyCodes []string `protobuf:"bytes,1,rep,name=currency_codes,json=currencyCodes,proto3" json:"currency_codes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSupportedCurrenciesResponse) Reset() {
+	*x = GetSupportedCurrenciesResponse{}
+	mi := &file_demo_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSupportedCurrenciesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSupportedCurrenciesResponse) ProtoMessage() {}
+
+func (x *GetSupportedCurrenciesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSupportedCurrenciesResponse.ProtoReflect.Descriptor instead.
+func (*GetSupportedCurrenciesResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *GetSupportedCurrenciesResponse) GetCurrencyCodes() []string {
+	if x != nil {
+		return x.CurrencyCodes
+	}
+	return nil
+}
+
+type CurrencyConversionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	From  *Money                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	// The 3-letter currency code defined in ISO 4217.
+	ToCode        string `protobuf:"bytes,2,opt,name=to_code,json=toCode,proto3" json:"to_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CurrencyConversionRequest) Reset() {
+	*x = CurrencyConversionRequest{}
+	mi := &file_demo_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CurrencyConversionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CurrencyConversionRequest) ProtoMessage() {}
+
+func (x *CurrencyConversionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CurrencyConversionRequest.ProtoReflect.Descriptor instead.
+func (*CurrencyConversionRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *CurrencyConversionRequest) GetFrom() *Money {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *CurrencyConversionRequest) GetToCode() string {
+	if x != nil {
+		return x.ToCode
+	}
+	return ""
+}
+
+type CreditCardInfo struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	CreditCardNumber          string                 `protobuf:"bytes,1,opt,name=credit_card_number,json=creditCardNumber,proto3" json:"credit_card_number,omitempty"`
+	CreditCardCvv             int32                  `protobuf:"varint,2,opt,name=credit_card_cvv,json=creditCardCvv,proto3" json:"credit_card_cvv,omitempty"`
+	CreditCardExpirationYear  int32                  `protobuf:"varint,3,opt,name=credit_card_expiration_year,json=creditCardExpirationYear,proto3" json:"credit_card_expiration_year,omitempty"`
+	CreditCardExpirationMonth int32                  `protobuf:"varint,4,opt,name=credit_card_expiration_month,json=creditCardExpirationMonth,proto3" json:"credit_card_expiration_month,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *CreditCardInfo) Reset() {
+	*x = CreditCardInfo{}
+	mi := &file_demo_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreditCardInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreditCardInfo) ProtoMessage() {}
+
+func (x *CreditCardInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreditCardInfo.ProtoReflect.Descriptor instead.
+func (*CreditCardInfo) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *CreditCardInfo) GetCreditCardNumber() string {
+	if x != nil {
+		return x.CreditCardNumber
+	}
+	return ""
+}
+
+func (x *CreditCardInfo) GetCreditCardCvv() int32 {
+	if x != nil {
+		return x.CreditCardCvv
+	}
+	return 0
+}
+
+func (x *CreditCardInfo) GetCreditCardExpirationYear() int32 {
+	if x != nil {
+		return x.CreditCardExpirationYear
+	}
+	return 0
+}
+
+func (x *CreditCardInfo) GetCreditCardExpirationMonth() int32 {
+	if x != nil {
+		return x.CreditCardExpirationMonth
+	}
+	return 0
+}
+
+type ChargeRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Amount *Money                 `protobuf:"bytes,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	// Raw card details. Only used when payment_token is empty; kept for
+	// callers that do not tokenize yet.
+	CreditCard *CreditCardInfo `protobuf:"bytes,2,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	// Token returned by TokenizeCard. Takes precedence over credit_card.
+	PaymentToken  string `protobuf:"bytes,3,opt,name=payment_token,json=paymentToken,proto3" json:"payment_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChargeRequest) Reset() {
+	*x = ChargeRequest{}
+	mi := &file_demo_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChargeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChargeRequest) ProtoMessage() {}
+
+func (x *ChargeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChargeRequest.ProtoReflect.Descriptor instead.
+func (*ChargeRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ChargeRequest) GetAmount() *Money {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
+func (x *ChargeRequest) GetCreditCard() *CreditCardInfo {
+	if x != nil {
+		return x.CreditCard
+	}
+	return nil
+}
+
+func (x *ChargeRequest) GetPaymentToken() string {
+	if x != nil {
+		return x.PaymentToken
+	}
+	return ""
+}
+
+type ChargeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TransactionId string                 `protobuf:"bytes,1,opt,name=transaction_id,json=transactionId,proto3" json:"transaction_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChargeResponse) Reset() {
+	*x = ChargeResponse{}
+	mi := &file_demo_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChargeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChargeResponse) ProtoMessage() {}
+
+func (x *ChargeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChargeResponse.ProtoReflect.Descriptor instead.
+func (*ChargeResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ChargeResponse) GetTransactionId() string {
+	if x != nil {
+		return x.TransactionId
+	}
+	return ""
+}
+
+type TokenizeCardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CreditCard    *CreditCardInfo        `protobuf:"bytes,1,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenizeCardRequest) Reset() {
+	*x = TokenizeCardRequest{}
+	mi := &file_demo_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenizeCardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenizeCardRequest) ProtoMessage() {}
+
+func (x *TokenizeCardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenizeCardRequest.ProtoReflect.Descriptor instead.
+func (*TokenizeCardRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *TokenizeCardRequest) GetCreditCard() *CreditCardInfo {
+	if x != nil {
+		return x.CreditCard
+	}
+	return nil
+}
+
+type TokenizeCardResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Opaque token that stands in for the card in PlaceOrder and Charge.
+	// The same card always yields the same token.
+	PaymentToken string `protobuf:"bytes,1,opt,name=payment_token,json=paymentToken,proto3" json:"payment_token,omitempty"`
+	// Card brand, such as "visa" or "mastercard".
+	CardType      string `protobuf:"bytes,2,opt,name=card_type,json=cardType,proto3" json:"card_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenizeCardResponse) Reset() {
+	*x = TokenizeCardResponse{}
+	mi := &file_demo_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenizeCardResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenizeCardResponse) ProtoMessage() {}
+
+func (x *TokenizeCardResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenizeCardResponse.ProtoReflect.Descriptor instead.
+func (*TokenizeCardResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *TokenizeCardResponse) GetPaymentToken() string {
+	if x != nil {
+		return x.PaymentToken
+	}
+	return ""
+}
+
+func (x *TokenizeCardResponse) GetCardType() string {
+	if x != nil {
+		return x.CardType
+	}
+	return ""
+}
+
+type GetCardDetailsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PaymentToken  string                 `protobuf:"bytes,1,opt,name=payment_token,json=paymentToken,proto3" json:"payment_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCardDetailsRequest) Reset() {
+	*x = GetCardDetailsRequest{}
+	mi := &file_demo_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCardDetailsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCardDetailsRequest) ProtoMessage() {}
+
+func (x *GetCardDetailsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCardDetailsRequest.ProtoReflect.Descriptor instead.
+func (*GetCardDetailsRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *GetCardDetailsRequest) GetPaymentToken() string {
+	if x != nil {
+		return x.PaymentToken
+	}
+	return ""
+}
+
+type GetCardDetailsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Card brand, such as "visa" or "mastercard".
+	CardType string `protobuf:"bytes,1,opt,name=card_type,json=cardType,proto3" json:"card_type,omitempty"`
+	// Bank identification number: the first six digits of the card number,
+	// which tell the issuer.
+	Bin           string `protobuf:"bytes,2,opt,name=bin,proto3" json:"bin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCardDetailsResponse) Reset() {
+	*x = GetCardDetailsResponse{}
+	mi := &file_demo_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCardDetailsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCardDetailsResponse) ProtoMessage() {}
+
+func (x *GetCardDetailsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCardDetailsResponse.ProtoReflect.Descriptor instead.
+func (*GetCardDetailsResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *GetCardDetailsResponse) GetCardType() string {
+	if x != nil {
+		return x.CardType
+	}
+	return ""
+}
+
+func (x *GetCardDetailsResponse) GetBin() string {
+	if x != nil {
+		return x.Bin
+	}
+	return ""
+}
+
+type OrderItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Item          *CartItem              `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Cost          *Money                 `protobuf:"bytes,2,opt,name=cost,proto3" json:"cost,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderItem) Reset() {
+	*x = OrderItem{}
+	mi := &file_demo_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderItem) ProtoMessage() {}
+
+func (x *OrderItem) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderItem.ProtoReflect.Descriptor instead.
+func (*OrderItem) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *OrderItem) GetItem() *CartItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *OrderItem) GetCost() *Money {
+	if x != nil {
+		return x.Cost
+	}
+	return nil
+}
+
+type OrderResult struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	OrderId            string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ShippingTrackingId string                 `protobuf:"bytes,2,opt,name=shipping_tracking_id,json=shippingTrackingId,proto3" json:"shipping_tracking_id,omitempty"`
+	ShippingCost       *Money                 `protobuf:"bytes,3,opt,name=shipping_cost,json=shippingCost,proto3" json:"shipping_cost,omitempty"`
+	ShippingAddress    *Address               `protobuf:"bytes,4,opt,name=shipping_address,json=shippingAddress,proto3" json:"shipping_address,omitempty"`
+	Items              []*OrderItem           `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
+	ShippingMethod     string                 `protobuf:"bytes,6,opt,name=shipping_method,json=shippingMethod,proto3" json:"shipping_method,omitempty"`
+	// Packages the order ships in. shipping_tracking_id is the first one's.
+	Shipments     []*Shipment `protobuf:"bytes,7,rep,name=shipments,proto3" json:"shipments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderResult) Reset() {
+	*x = OrderResult{}
+	mi := &file_demo_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderResult) ProtoMessage() {}
+
+func (x *OrderResult) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderResult.ProtoReflect.Descriptor instead.
+func (*OrderResult) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *OrderResult) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderResult) GetShippingTrackingId() string {
+	if x != nil {
+		return x.ShippingTrackingId
+	}
+	return ""
+}
+
+func (x *OrderResult) GetShippingCost() *Money {
+	if x != nil {
+		return x.ShippingCost
+	}
+	return nil
+}
+
+func (x *OrderResult) GetShippingAddress() *Address {
+	if x != nil {
+		return x.ShippingAddress
+	}
+	return nil
+}
+
+func (x *OrderResult) GetItems() []*OrderItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *OrderResult) GetShippingMethod() string {
+	if x != nil {
+		return x.ShippingMethod
+	}
+	return ""
+}
+
+func (x *OrderResult) GetShipments() []*Shipment {
+	if x != nil {
+		return x.Shipments
+	}
+	return nil
+}
+
+type SendOrderConfirmationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Order         *OrderResult           `protobuf:"bytes,2,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendOrderConfirmationRequest) Reset() {
+	*x = SendOrderConfirmationRequest{}
+	mi := &file_demo_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendOrderConfirmationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendOrderConfirmationRequest) ProtoMessage() {}
+
+func (x *SendOrderConfirmationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendOrderConfirmationRequest.ProtoReflect.Descriptor instead.
+func (*SendOrderConfirmationRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *SendOrderConfirmationRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *SendOrderConfirmationRequest) GetOrder() *OrderResult {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type PlaceOrderRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	UserId       string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UserCurrency string                 `protobuf:"bytes,2,opt,name=user_currency,json=userCurrency,proto3" json:"user_currency,omitempty"`
+	Address      *Address               `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	Email        string                 `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
+	// Raw card details. Only used when payment_token is empty.
+	CreditCard *CreditCardInfo `protobuf:"bytes,6,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	// Token returned by PaymentService.TokenizeCard.
+	PaymentToken string `protobuf:"bytes,7,opt,name=payment_token,json=paymentToken,proto3" json:"payment_token,omitempty"`
+	// Method chosen from ShippingService.GetQuote. Empty means the cheapest.
+	ShippingMethod string `protobuf:"bytes,8,opt,name=shipping_method,json=shippingMethod,proto3" json:"shipping_method,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PlaceOrderRequest) Reset() {
+	*x = PlaceOrderRequest{}
+	mi := &file_demo_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderRequest) ProtoMessage() {}
+
+func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderRequest.ProtoReflect.Descriptor instead.
+func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *PlaceOrderRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetUserCurrency() string {
+	if x != nil {
+		return x.UserCurrency
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *PlaceOrderRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetCreditCard() *CreditCardInfo {
+	if x != nil {
+		return x.CreditCard
+	}
+	return nil
+}
+
+func (x *PlaceOrderRequest) GetPaymentToken() string {
+	if x != nil {
+		return x.PaymentToken
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetShippingMethod() string {
+	if x != nil {
+		return x.ShippingMethod
+	}
+	return ""
+}
+
+type PlaceOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *OrderResult           `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderResponse) Reset() {
+	*x = PlaceOrderResponse{}
+	mi := &file_demo_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderResponse) ProtoMessage() {}
+
+func (x *PlaceOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderResponse.ProtoReflect.Descriptor instead.
+func (*PlaceOrderResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *PlaceOrderResponse) GetOrder() *OrderResult {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type AdRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// List of important key words from the current page describing the context.
+	ContextKeys []string `protobuf:"bytes,1,rep,name=context_keys,json=contextKeys,proto3" json:"context_keys,omitempty"`
+	// Session of the user the ads are for, to cap how often they see an ad.
+	SessionId     string `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdRequest) Reset() {
+	*x = AdRequest{}
+	mi := &file_demo_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdRequest) ProtoMessage() {}
+
+func (x *AdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdRequest.ProtoReflect.Descriptor instead.
+func (*AdRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *AdRequest) GetContextKeys() []string {
+	if x != nil {
+		return x.ContextKeys
+	}
+	return nil
+}
+
+func (x *AdRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type AdResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ads           []*Ad                  `protobuf:"bytes,1,rep,name=ads,proto3" json:"ads,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdResponse) Reset() {
+	*x = AdResponse{}
+	mi := &file_demo_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdResponse) ProtoMessage() {}
+
+func (x *AdResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdResponse.ProtoReflect.Descriptor instead.
+func (*AdResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *AdResponse) GetAds() []*Ad {
+	if x != nil {
+		return x.Ads
+	}
+	return nil
+}
+
+type GetAdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAdRequest) Reset() {
+	*x = GetAdRequest{}
+	mi := &file_demo_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *OrderItem) String() string {
+func (x *GetAdRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OrderItem) ProtoMessage() {}
+func (*GetAdRequest) ProtoMessage() {}
 
-func (x *OrderItem) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[24]
+func (x *GetAdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1306,51 +3246,45 @@ func (x *OrderItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OrderItem.ProtoReflect.Descriptor instead.
-func (*OrderItem) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{24}
-}
-
-func (x *OrderItem) GetItem() *CartItem {
-	if x != nil {
-		return x.Item
-	}
-	return nil
+// Deprecated: Use GetAdRequest.ProtoReflect.Descriptor instead.
+func (*GetAdRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{51}
 }
 
-func (x *OrderItem) GetCost() *Money {
+func (x *GetAdRequest) GetId() string {
 	if x != nil {
-		return x.Cost
+		return x.Id
 	}
-	return nil
+	return ""
 }
 
-type OrderResult struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	OrderId            string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
-	ShippingTrackingId string                 `protobuf:"bytes,2,opt,name=shipping_tracking_id,json=shippingTrackingId,proto3" json:"shipping_tracking_id,omitempty"`
-	ShippingCost       *Money                 `protobuf:"bytes,3,opt,name=shipping_cost,json=shippingCost,proto3" json:"shipping_cost,omitempty"`
-	ShippingAddress    *Address               `protobuf:"bytes,4,opt,name=shipping_address,json=shippingAddress,proto3" json:"shipping_address,omitempty"`
-	Items              []*OrderItem           `protobuf:"bytes,5,rep,name=items,proto3" json:"items,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+type Ad struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// url to redirect to when an ad is clicked.
+	RedirectUrl string `protobuf:"bytes,1,opt,name=redirect_url,json=redirectUrl,proto3" json:"redirect_url,omitempty"`
+	// short advertisement text to display.
+	Text string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// Identifies the ad in the events reported about it.
+	Id            string `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *OrderResult) Reset() {
-	*x = OrderResult{}
-	mi := &file_demo_proto_msgTypes[25]
+func (x *Ad) Reset() {
+	*x = Ad{}
+	mi := &file_demo_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *OrderResult) String() string {
+func (x *Ad) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OrderResult) ProtoMessage() {}
+func (*Ad) ProtoMessage() {}
 
-func (x *OrderResult) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[25]
+func (x *Ad) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1361,69 +3295,58 @@ func (x *OrderResult) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OrderResult.ProtoReflect.Descriptor instead.
-func (*OrderResult) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{25}
+// Deprecated: Use Ad.ProtoReflect.Descriptor instead.
+func (*Ad) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *OrderResult) GetOrderId() string {
+func (x *Ad) GetRedirectUrl() string {
 	if x != nil {
-		return x.OrderId
+		return x.RedirectUrl
 	}
 	return ""
 }
 
-func (x *OrderResult) GetShippingTrackingId() string {
+func (x *Ad) GetText() string {
 	if x != nil {
-		return x.ShippingTrackingId
+		return x.Text
 	}
 	return ""
 }
 
-func (x *OrderResult) GetShippingCost() *Money {
-	if x != nil {
-		return x.ShippingCost
-	}
-	return nil
-}
-
-func (x *OrderResult) GetShippingAddress() *Address {
-	if x != nil {
-		return x.ShippingAddress
-	}
-	return nil
-}
-
-func (x *OrderResult) GetItems() []*OrderItem {
+func (x *Ad) GetId() string {
 	if x != nil {
-		return x.Items
+		return x.Id
 	}
-	return nil
+	return ""
 }
 
-type SendOrderConfirmationRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
-	Order         *OrderResult           `protobuf:"bytes,2,opt,name=order,proto3" json:"order,omitempty"`
+type AdEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	AdId  string                 `protobuf:"bytes,1,opt,name=ad_id,json=adId,proto3" json:"ad_id,omitempty"`
+	Type  AdEventType            `protobuf:"varint,2,opt,name=type,proto3,enum=genproto.AdEventType" json:"type,omitempty"`
+	// Session the ad was shown to, whose impressions are capped by the
+	// session frequency cap of the ad.
+	SessionId     string `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendOrderConfirmationRequest) Reset() {
-	*x = SendOrderConfirmationRequest{}
-	mi := &file_demo_proto_msgTypes[26]
+func (x *AdEvent) Reset() {
+	*x = AdEvent{}
+	mi := &file_demo_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendOrderConfirmationRequest) String() string {
+func (x *AdEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendOrderConfirmationRequest) ProtoMessage() {}
+func (*AdEvent) ProtoMessage() {}
 
-func (x *SendOrderConfirmationRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[26]
+func (x *AdEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1434,51 +3357,54 @@ func (x *SendOrderConfirmationRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendOrderConfirmationRequest.ProtoReflect.Descriptor instead.
-func (*SendOrderConfirmationRequest) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{26}
+// Deprecated: Use AdEvent.ProtoReflect.Descriptor instead.
+func (*AdEvent) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *SendOrderConfirmationRequest) GetEmail() string {
+func (x *AdEvent) GetAdId() string {
 	if x != nil {
-		return x.Email
+		return x.AdId
 	}
 	return ""
 }
 
-func (x *SendOrderConfirmationRequest) GetOrder() *OrderResult {
+func (x *AdEvent) GetType() AdEventType {
 	if x != nil {
-		return x.Order
+		return x.Type
 	}
-	return nil
+	return AdEventType_AD_EVENT_TYPE_UNSPECIFIED
 }
 
-type PlaceOrderRequest struct {
+func (x *AdEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type ReportAdEventsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	UserCurrency  string                 `protobuf:"bytes,2,opt,name=user_currency,json=userCurrency,proto3" json:"user_currency,omitempty"`
-	Address       *Address               `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
-	Email         string                 `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
-	CreditCard    *CreditCardInfo        `protobuf:"bytes,6,opt,name=credit_card,json=creditCard,proto3" json:"credit_card,omitempty"`
+	Events        []*AdEvent             `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PlaceOrderRequest) Reset() {
-	*x = PlaceOrderRequest{}
-	mi := &file_demo_proto_msgTypes[27]
+func (x *ReportAdEventsRequest) Reset() {
+	*x = ReportAdEventsRequest{}
+	mi := &file_demo_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PlaceOrderRequest) String() string {
+func (x *ReportAdEventsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PlaceOrderRequest) ProtoMessage() {}
+func (*ReportAdEventsRequest) ProtoMessage() {}
 
-func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[27]
+func (x *ReportAdEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1489,68 +3415,42 @@ func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PlaceOrderRequest.ProtoReflect.Descriptor instead.
-func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{27}
-}
-
-func (x *PlaceOrderRequest) GetUserId() string {
-	if x != nil {
-		return x.UserId
-	}
-	return ""
-}
-
-func (x *PlaceOrderRequest) GetUserCurrency() string {
-	if x != nil {
-		return x.UserCurrency
-	}
-	return ""
-}
-
-func (x *PlaceOrderRequest) GetAddress() *Address {
-	if x != nil {
-		return x.Address
-	}
-	return nil
-}
-
-func (x *PlaceOrderRequest) GetEmail() string {
-	if x != nil {
-		return x.Email
-	}
-	return ""
+// Deprecated: Use ReportAdEventsRequest.ProtoReflect.Descriptor instead.
+func (*ReportAdEventsRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *PlaceOrderRequest) GetCreditCard() *CreditCardInfo {
+func (x *ReportAdEventsRequest) GetEvents() []*AdEvent {
 	if x != nil {
-		return x.CreditCard
+		return x.Events
 	}
 	return nil
 }
 
-type PlaceOrderResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Order         *OrderResult           `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+type ReportAdEventsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Indexes in events of the events not counted, as they are about an
+	// unknown ad or have no type. The other events are counted.
+	Rejected      []int32 `protobuf:"varint,1,rep,packed,name=rejected,proto3" json:"rejected,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PlaceOrderResponse) Reset() {
-	*x = PlaceOrderResponse{}
-	mi := &file_demo_proto_msgTypes[28]
+func (x *ReportAdEventsResponse) Reset() {
+	*x = ReportAdEventsResponse{}
+	mi := &file_demo_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PlaceOrderResponse) String() string {
+func (x *ReportAdEventsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PlaceOrderResponse) ProtoMessage() {}
+func (*ReportAdEventsResponse) ProtoMessage() {}
 
-func (x *PlaceOrderResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[28]
+func (x *ReportAdEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1561,41 +3461,41 @@ func (x *PlaceOrderResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PlaceOrderResponse.ProtoReflect.Descriptor instead.
-func (*PlaceOrderResponse) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{28}
+// Deprecated: Use ReportAdEventsResponse.ProtoReflect.Descriptor instead.
+func (*ReportAdEventsResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *PlaceOrderResponse) GetOrder() *OrderResult {
+func (x *ReportAdEventsResponse) GetRejected() []int32 {
 	if x != nil {
-		return x.Order
+		return x.Rejected
 	}
 	return nil
 }
 
-type AdRequest struct {
+type GetAdStatsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// List of important key words from the current page describing the context.
-	ContextKeys   []string `protobuf:"bytes,1,rep,name=context_keys,json=contextKeys,proto3" json:"context_keys,omitempty"`
+	// Ads to get the stats of, or all ads if empty.
+	AdIds         []string `protobuf:"bytes,1,rep,name=ad_ids,json=adIds,proto3" json:"ad_ids,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AdRequest) Reset() {
-	*x = AdRequest{}
-	mi := &file_demo_proto_msgTypes[29]
+func (x *GetAdStatsRequest) Reset() {
+	*x = GetAdStatsRequest{}
+	mi := &file_demo_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AdRequest) String() string {
+func (x *GetAdStatsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AdRequest) ProtoMessage() {}
+func (*GetAdStatsRequest) ProtoMessage() {}
 
-func (x *AdRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[29]
+func (x *GetAdStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1606,40 +3506,44 @@ func (x *AdRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AdRequest.ProtoReflect.Descriptor instead.
-func (*AdRequest) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{29}
+// Deprecated: Use GetAdStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetAdStatsRequest) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{56}
 }
 
-func (x *AdRequest) GetContextKeys() []string {
+func (x *GetAdStatsRequest) GetAdIds() []string {
 	if x != nil {
-		return x.ContextKeys
+		return x.AdIds
 	}
 	return nil
 }
 
-type AdResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ads           []*Ad                  `protobuf:"bytes,1,rep,name=ads,proto3" json:"ads,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *AdResponse) Reset() {
-	*x = AdResponse{}
-	mi := &file_demo_proto_msgTypes[30]
+type AdStats struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	AdId        string                 `protobuf:"bytes,1,opt,name=ad_id,json=adId,proto3" json:"ad_id,omitempty"`
+	Impressions int64                  `protobuf:"varint,2,opt,name=impressions,proto3" json:"impressions,omitempty"`
+	Clicks      int64                  `protobuf:"varint,3,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	// Clicks per impression, or 0 without impressions.
+	ClickThroughRate float64 `protobuf:"fixed64,4,opt,name=click_through_rate,json=clickThroughRate,proto3" json:"click_through_rate,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *AdStats) Reset() {
+	*x = AdStats{}
+	mi := &file_demo_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AdResponse) String() string {
+func (x *AdStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AdResponse) ProtoMessage() {}
+func (*AdStats) ProtoMessage() {}
 
-func (x *AdResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[30]
+func (x *AdStats) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1650,43 +3554,62 @@ func (x *AdResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AdResponse.ProtoReflect.Descriptor instead.
-func (*AdResponse) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{30}
+// Deprecated: Use AdStats.ProtoReflect.Descriptor instead.
+func (*AdStats) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *AdResponse) GetAds() []*Ad {
+func (x *AdStats) GetAdId() string {
 	if x != nil {
-		return x.Ads
+		return x.AdId
 	}
-	return nil
+	return ""
 }
 
-type Ad struct {
+func (x *AdStats) GetImpressions() int64 {
+	if x != nil {
+		return x.Impressions
+	}
+	return 0
+}
+
+func (x *AdStats) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+func (x *AdStats) GetClickThroughRate() float64 {
+	if x != nil {
+		return x.ClickThroughRate
+	}
+	return 0
+}
+
+type GetAdStatsResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// url to redirect to when an ad is clicked.
-	RedirectUrl string `protobuf:"bytes,1,opt,name=redirect_url,json=redirectUrl,proto3" json:"redirect_url,omitempty"`
-	// short advertisement text to display.
-	Text          string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// Sorted by ad ID.
+	Stats         []*AdStats `protobuf:"bytes,1,rep,name=stats,proto3" json:"stats,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Ad) Reset() {
-	*x = Ad{}
-	mi := &file_demo_proto_msgTypes[31]
+func (x *GetAdStatsResponse) Reset() {
+	*x = GetAdStatsResponse{}
+	mi := &file_demo_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Ad) String() string {
+func (x *GetAdStatsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Ad) ProtoMessage() {}
+func (*GetAdStatsResponse) ProtoMessage() {}
 
-func (x *Ad) ProtoReflect() protoreflect.Message {
-	mi := &file_demo_proto_msgTypes[31]
+func (x *GetAdStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_demo_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1697,23 +3620,16 @@ func (x *Ad) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Ad.ProtoReflect.Descriptor instead.
-func (*Ad) Descriptor() ([]byte, []int) {
-	return file_demo_proto_rawDescGZIP(), []int{31}
-}
-
-func (x *Ad) GetRedirectUrl() string {
-	if x != nil {
-		return x.RedirectUrl
-	}
-	return ""
+// Deprecated: Use GetAdStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetAdStatsResponse) Descriptor() ([]byte, []int) {
+	return file_demo_proto_rawDescGZIP(), []int{58}
 }
 
-func (x *Ad) GetText() string {
+func (x *GetAdStatsResponse) GetStats() []*AdStats {
 	if x != nil {
-		return x.Text
+		return x.Stats
 	}
-	return ""
+	return nil
 }
 
 var File_demo_proto protoreflect.FileDescriptor
@@ -1721,11 +3637,13 @@ var File_demo_proto protoreflect.FileDescriptor
 const file_demo_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"demo.proto\x12\bgenproto\"E\n" +
+	"demo.proto\x12\bgenproto\"f\n" +
 	"\bCartItem\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1a\n" +
-	"\bquantity\x18\x02 \x01(\x05R\bquantity\"Q\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\x12\x1f\n" +
+	"\vvariant_sku\x18\x03 \x01(\tR\n" +
+	"variantSku\"Q\n" +
 	"\x0eAddItemRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12&\n" +
 	"\x04item\x18\x02 \x01(\v2\x12.genproto.CartItemR\x04item\"+\n" +
@@ -1740,10 +3658,25 @@ const file_demo_proto_rawDesc = "" +
 	"\x1aListRecommendationsRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1f\n" +
 	"\vproduct_ids\x18\x02 \x03(\tR\n" +
-	"productIds\">\n" +
+	"productIds\"\xb4\x01\n" +
 	"\x1bListRecommendationsResponse\x12\x1f\n" +
 	"\vproduct_ids\x18\x01 \x03(\tR\n" +
-	"productIds\"\xb7\x01\n" +
+	"productIds\x12\x1e\n" +
+	"\n" +
+	"experiment\x18\x02 \x01(\tR\n" +
+	"experiment\x12\x10\n" +
+	"\x03arm\x18\x03 \x01(\tR\x03arm\x12B\n" +
+	"\x0frecommendations\x18\x04 \x03(\v2\x18.genproto.RecommendationR\x0frecommendations\"G\n" +
+	"\x0eRecommendation\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"a\n" +
+	"\n" +
+	"OrderEvent\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vproduct_ids\x18\x03 \x03(\tR\n" +
+	"productIds\"\xda\x02\n" +
 	"\aProduct\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
@@ -1752,32 +3685,121 @@ const file_demo_proto_rawDesc = "" +
 	"\tprice_usd\x18\x05 \x01(\v2\x0f.genproto.MoneyR\bpriceUsd\x12\x1e\n" +
 	"\n" +
 	"categories\x18\x06 \x03(\tR\n" +
-	"categories\"E\n" +
+	"categories\x12!\n" +
+	"\fweight_grams\x18\a \x01(\x05R\vweightGrams\x124\n" +
+	"\n" +
+	"dimensions\x18\b \x01(\v2\x14.genproto.DimensionsR\n" +
+	"dimensions\x12\x12\n" +
+	"\x04etag\x18\t \x01(\tR\x04etag\x124\n" +
+	"\bvariants\x18\n" +
+	" \x03(\v2\x18.genproto.ProductVariantR\bvariants\"\xd9\x01\n" +
+	"\x0eProductVariant\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12H\n" +
+	"\n" +
+	"attributes\x18\x02 \x03(\v2(.genproto.ProductVariant.AttributesEntryR\n" +
+	"attributes\x12,\n" +
+	"\tprice_usd\x18\x03 \x01(\v2\x0f.genproto.MoneyR\bpriceUsd\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"a\n" +
+	"\n" +
+	"Dimensions\x12\x1b\n" +
+	"\tlength_mm\x18\x01 \x01(\x05R\blengthMm\x12\x19\n" +
+	"\bwidth_mm\x18\x02 \x01(\x05R\awidthMm\x12\x1b\n" +
+	"\theight_mm\x18\x03 \x01(\x05R\bheightMm\"\x84\x02\n" +
+	"\x13ListProductsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12,\n" +
+	"\tmin_price\x18\x04 \x01(\v2\x0f.genproto.MoneyR\bminPrice\x12,\n" +
+	"\tmax_price\x18\x05 \x01(\v2\x0f.genproto.MoneyR\bmaxPrice\x129\n" +
+	"\n" +
+	"sort_order\x18\x06 \x01(\x0e2\x1a.genproto.ProductSortOrderR\tsortOrder\"\x8c\x01\n" +
 	"\x14ListProductsResponse\x12-\n" +
-	"\bproducts\x18\x01 \x03(\v2\x11.genproto.ProductR\bproducts\"#\n" +
+	"\bproducts\x18\x01 \x03(\v2\x11.genproto.ProductR\bproducts\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x05R\ttotalSize\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"#\n" +
 	"\x11GetProductRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"-\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"C\n" +
+	"\x14CreateProductRequest\x12+\n" +
+	"\aproduct\x18\x01 \x01(\v2\x11.genproto.ProductR\aproduct\"C\n" +
+	"\x14UpdateProductRequest\x12+\n" +
+	"\aproduct\x18\x01 \x01(\v2\x11.genproto.ProductR\aproduct\":\n" +
+	"\x14DeleteProductRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04etag\x18\x02 \x01(\tR\x04etag\"i\n" +
 	"\x15SearchProductsRequest\x12\x14\n" +
-	"\x05query\x18\x01 \x01(\tR\x05query\"E\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x03 \x01(\tR\tpageToken\"\x8c\x01\n" +
 	"\x16SearchProductsResponse\x12+\n" +
-	"\aresults\x18\x01 \x03(\v2\x11.genproto.ProductR\aresults\"h\n" +
+	"\aresults\x18\x01 \x03(\v2\x11.genproto.ProductR\aresults\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x05R\ttotalSize\x12&\n" +
+	"\x0fnext_page_token\x18\x03 \x01(\tR\rnextPageToken\"h\n" +
 	"\x0fGetQuoteRequest\x12+\n" +
 	"\aaddress\x18\x01 \x01(\v2\x11.genproto.AddressR\aaddress\x12(\n" +
-	"\x05items\x18\x02 \x03(\v2\x12.genproto.CartItemR\x05items\">\n" +
+	"\x05items\x18\x02 \x03(\v2\x12.genproto.CartItemR\x05items\"r\n" +
 	"\x10GetQuoteResponse\x12*\n" +
-	"\bcost_usd\x18\x01 \x01(\v2\x0f.genproto.MoneyR\acostUsd\"i\n" +
+	"\bcost_usd\x18\x01 \x01(\v2\x0f.genproto.MoneyR\acostUsd\x122\n" +
+	"\aoptions\x18\x02 \x03(\v2\x18.genproto.ShippingOptionR\aoptions\"\xad\x01\n" +
+	"\x0eShippingOption\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12*\n" +
+	"\bcost_usd\x18\x03 \x01(\v2\x0f.genproto.MoneyR\acostUsd\x12\x19\n" +
+	"\bmin_days\x18\x04 \x01(\x05R\aminDays\x12\x19\n" +
+	"\bmax_days\x18\x05 \x01(\x05R\amaxDays\"\x92\x01\n" +
 	"\x10ShipOrderRequest\x12+\n" +
 	"\aaddress\x18\x01 \x01(\v2\x11.genproto.AddressR\aaddress\x12(\n" +
-	"\x05items\x18\x02 \x03(\v2\x12.genproto.CartItemR\x05items\"4\n" +
+	"\x05items\x18\x02 \x03(\v2\x12.genproto.CartItemR\x05items\x12'\n" +
+	"\x0fshipping_method\x18\x03 \x01(\tR\x0eshippingMethod\"f\n" +
 	"\x11ShipOrderResponse\x12\x1f\n" +
 	"\vtracking_id\x18\x01 \x01(\tR\n" +
-	"trackingId\"\x8f\x01\n" +
+	"trackingId\x120\n" +
+	"\tshipments\x18\x02 \x03(\v2\x12.genproto.ShipmentR\tshipments\"x\n" +
+	"\bShipment\x12\x1f\n" +
+	"\vtracking_id\x18\x01 \x01(\tR\n" +
+	"trackingId\x12(\n" +
+	"\x05items\x18\x02 \x03(\v2\x12.genproto.CartItemR\x05items\x12!\n" +
+	"\fweight_grams\x18\x03 \x01(\x05R\vweightGrams\"7\n" +
+	"\x14TrackShipmentRequest\x12\x1f\n" +
+	"\vtracking_id\x18\x01 \x01(\tR\n" +
+	"trackingId\"\x80\x01\n" +
+	"\rShipmentEvent\x120\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x18.genproto.ShipmentStatusR\x06status\x12\x1b\n" +
+	"\ttime_unix\x18\x02 \x01(\x03R\btimeUnix\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"\xd3\x02\n" +
+	"\x15TrackShipmentResponse\x12\x1f\n" +
+	"\vtracking_id\x18\x01 \x01(\tR\n" +
+	"trackingId\x120\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x18.genproto.ShipmentStatusR\x06status\x12'\n" +
+	"\x0fshipping_method\x18\x03 \x01(\tR\x0eshippingMethod\x12+\n" +
+	"\aaddress\x18\x04 \x01(\v2\x11.genproto.AddressR\aaddress\x12/\n" +
+	"\x06events\x18\x05 \x03(\v2\x17.genproto.ShipmentEventR\x06events\x126\n" +
+	"\x17estimated_delivery_unix\x18\x06 \x01(\x03R\x15estimatedDeliveryUnix\x12(\n" +
+	"\x05items\x18\a \x03(\v2\x12.genproto.CartItemR\x05items\"E\n" +
+	"\x16ValidateAddressRequest\x12+\n" +
+	"\aaddress\x18\x01 \x01(\v2\x11.genproto.AddressR\aaddress\"\x98\x01\n" +
+	"\x17ValidateAddressResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12+\n" +
+	"\aaddress\x18\x02 \x01(\v2\x11.genproto.AddressR\aaddress\x12:\n" +
+	"\n" +
+	"violations\x18\x03 \x03(\v2\x1a.genproto.AddressViolationR\n" +
+	"violations\"J\n" +
+	"\x10AddressViolation\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"\xb0\x01\n" +
 	"\aAddress\x12%\n" +
 	"\x0estreet_address\x18\x01 \x01(\tR\rstreetAddress\x12\x12\n" +
 	"\x04city\x18\x02 \x01(\tR\x04city\x12\x14\n" +
 	"\x05state\x18\x03 \x01(\tR\x05state\x12\x18\n" +
 	"\acountry\x18\x04 \x01(\tR\acountry\x12\x19\n" +
-	"\bzip_code\x18\x05 \x01(\x05R\azipCode\"X\n" +
+	"\bzip_code\x18\x05 \x01(\x05R\azipCode\x12\x1f\n" +
+	"\vpostal_code\x18\x06 \x01(\tR\n" +
+	"postalCode\"X\n" +
 	"\x05Money\x12#\n" +
 	"\rcurrency_code\x18\x01 \x01(\tR\fcurrencyCode\x12\x14\n" +
 	"\x05units\x18\x02 \x01(\x03R\x05units\x12\x14\n" +
@@ -1791,68 +3813,135 @@ const file_demo_proto_rawDesc = "" +
 	"\x12credit_card_number\x18\x01 \x01(\tR\x10creditCardNumber\x12&\n" +
 	"\x0fcredit_card_cvv\x18\x02 \x01(\x05R\rcreditCardCvv\x12=\n" +
 	"\x1bcredit_card_expiration_year\x18\x03 \x01(\x05R\x18creditCardExpirationYear\x12?\n" +
-	"\x1ccredit_card_expiration_month\x18\x04 \x01(\x05R\x19creditCardExpirationMonth\"s\n" +
+	"\x1ccredit_card_expiration_month\x18\x04 \x01(\x05R\x19creditCardExpirationMonth\"\x98\x01\n" +
 	"\rChargeRequest\x12'\n" +
 	"\x06amount\x18\x01 \x01(\v2\x0f.genproto.MoneyR\x06amount\x129\n" +
 	"\vcredit_card\x18\x02 \x01(\v2\x18.genproto.CreditCardInfoR\n" +
-	"creditCard\"7\n" +
+	"creditCard\x12#\n" +
+	"\rpayment_token\x18\x03 \x01(\tR\fpaymentToken\"7\n" +
 	"\x0eChargeResponse\x12%\n" +
-	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\"X\n" +
+	"\x0etransaction_id\x18\x01 \x01(\tR\rtransactionId\"P\n" +
+	"\x13TokenizeCardRequest\x129\n" +
+	"\vcredit_card\x18\x01 \x01(\v2\x18.genproto.CreditCardInfoR\n" +
+	"creditCard\"X\n" +
+	"\x14TokenizeCardResponse\x12#\n" +
+	"\rpayment_token\x18\x01 \x01(\tR\fpaymentToken\x12\x1b\n" +
+	"\tcard_type\x18\x02 \x01(\tR\bcardType\"<\n" +
+	"\x15GetCardDetailsRequest\x12#\n" +
+	"\rpayment_token\x18\x01 \x01(\tR\fpaymentToken\"G\n" +
+	"\x16GetCardDetailsResponse\x12\x1b\n" +
+	"\tcard_type\x18\x01 \x01(\tR\bcardType\x12\x10\n" +
+	"\x03bin\x18\x02 \x01(\tR\x03bin\"X\n" +
 	"\tOrderItem\x12&\n" +
 	"\x04item\x18\x01 \x01(\v2\x12.genproto.CartItemR\x04item\x12#\n" +
-	"\x04cost\x18\x02 \x01(\v2\x0f.genproto.MoneyR\x04cost\"\xf9\x01\n" +
+	"\x04cost\x18\x02 \x01(\v2\x0f.genproto.MoneyR\x04cost\"\xd4\x02\n" +
 	"\vOrderResult\x12\x19\n" +
 	"\border_id\x18\x01 \x01(\tR\aorderId\x120\n" +
 	"\x14shipping_tracking_id\x18\x02 \x01(\tR\x12shippingTrackingId\x124\n" +
 	"\rshipping_cost\x18\x03 \x01(\v2\x0f.genproto.MoneyR\fshippingCost\x12<\n" +
 	"\x10shipping_address\x18\x04 \x01(\v2\x11.genproto.AddressR\x0fshippingAddress\x12)\n" +
-	"\x05items\x18\x05 \x03(\v2\x13.genproto.OrderItemR\x05items\"a\n" +
+	"\x05items\x18\x05 \x03(\v2\x13.genproto.OrderItemR\x05items\x12'\n" +
+	"\x0fshipping_method\x18\x06 \x01(\tR\x0eshippingMethod\x120\n" +
+	"\tshipments\x18\a \x03(\v2\x12.genproto.ShipmentR\tshipments\"a\n" +
 	"\x1cSendOrderConfirmationRequest\x12\x14\n" +
 	"\x05email\x18\x01 \x01(\tR\x05email\x12+\n" +
-	"\x05order\x18\x02 \x01(\v2\x15.genproto.OrderResultR\x05order\"\xcf\x01\n" +
+	"\x05order\x18\x02 \x01(\v2\x15.genproto.OrderResultR\x05order\"\x9d\x02\n" +
 	"\x11PlaceOrderRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12#\n" +
 	"\ruser_currency\x18\x02 \x01(\tR\fuserCurrency\x12+\n" +
 	"\aaddress\x18\x03 \x01(\v2\x11.genproto.AddressR\aaddress\x12\x14\n" +
 	"\x05email\x18\x05 \x01(\tR\x05email\x129\n" +
 	"\vcredit_card\x18\x06 \x01(\v2\x18.genproto.CreditCardInfoR\n" +
-	"creditCard\"A\n" +
+	"creditCard\x12#\n" +
+	"\rpayment_token\x18\a \x01(\tR\fpaymentToken\x12'\n" +
+	"\x0fshipping_method\x18\b \x01(\tR\x0eshippingMethod\"A\n" +
 	"\x12PlaceOrderResponse\x12+\n" +
-	"\x05order\x18\x01 \x01(\v2\x15.genproto.OrderResultR\x05order\".\n" +
+	"\x05order\x18\x01 \x01(\v2\x15.genproto.OrderResultR\x05order\"M\n" +
 	"\tAdRequest\x12!\n" +
-	"\fcontext_keys\x18\x01 \x03(\tR\vcontextKeys\",\n" +
+	"\fcontext_keys\x18\x01 \x03(\tR\vcontextKeys\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\",\n" +
 	"\n" +
 	"AdResponse\x12\x1e\n" +
-	"\x03ads\x18\x01 \x03(\v2\f.genproto.AdR\x03ads\";\n" +
+	"\x03ads\x18\x01 \x03(\v2\f.genproto.AdR\x03ads\"\x1e\n" +
+	"\fGetAdRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"K\n" +
 	"\x02Ad\x12!\n" +
 	"\fredirect_url\x18\x01 \x01(\tR\vredirectUrl\x12\x12\n" +
-	"\x04text\x18\x02 \x01(\tR\x04text2\xb8\x01\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\"h\n" +
+	"\aAdEvent\x12\x13\n" +
+	"\x05ad_id\x18\x01 \x01(\tR\x04adId\x12)\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x15.genproto.AdEventTypeR\x04type\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\"B\n" +
+	"\x15ReportAdEventsRequest\x12)\n" +
+	"\x06events\x18\x01 \x03(\v2\x11.genproto.AdEventR\x06events\"4\n" +
+	"\x16ReportAdEventsResponse\x12\x1a\n" +
+	"\brejected\x18\x01 \x03(\x05R\brejected\"*\n" +
+	"\x11GetAdStatsRequest\x12\x15\n" +
+	"\x06ad_ids\x18\x01 \x03(\tR\x05adIds\"\x86\x01\n" +
+	"\aAdStats\x12\x13\n" +
+	"\x05ad_id\x18\x01 \x01(\tR\x04adId\x12 \n" +
+	"\vimpressions\x18\x02 \x01(\x03R\vimpressions\x12\x16\n" +
+	"\x06clicks\x18\x03 \x01(\x03R\x06clicks\x12,\n" +
+	"\x12click_through_rate\x18\x04 \x01(\x01R\x10clickThroughRate\"=\n" +
+	"\x12GetAdStatsResponse\x12'\n" +
+	"\x05stats\x18\x01 \x03(\v2\x11.genproto.AdStatsR\x05stats*\x83\x01\n" +
+	"\x10ProductSortOrder\x12\"\n" +
+	"\x1ePRODUCT_SORT_ORDER_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fSORT_BY_NAME\x10\x01\x12\x1b\n" +
+	"\x17SORT_BY_PRICE_ASCENDING\x10\x02\x12\x1c\n" +
+	"\x18SORT_BY_PRICE_DESCENDING\x10\x03*c\n" +
+	"\x0eShipmentStatus\x12\x1f\n" +
+	"\x1bSHIPMENT_STATUS_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rLABEL_CREATED\x10\x01\x12\x0e\n" +
+	"\n" +
+	"IN_TRANSIT\x10\x02\x12\r\n" +
+	"\tDELIVERED\x10\x03*M\n" +
+	"\vAdEventType\x12\x1d\n" +
+	"\x19AD_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rAD_IMPRESSION\x10\x01\x12\f\n" +
+	"\bAD_CLICK\x10\x022\xb8\x01\n" +
 	"\vCartService\x126\n" +
 	"\aAddItem\x12\x18.genproto.AddItemRequest\x1a\x0f.genproto.Empty\"\x00\x125\n" +
 	"\aGetCart\x12\x18.genproto.GetCartRequest\x1a\x0e.genproto.Cart\"\x00\x12:\n" +
-	"\tEmptyCart\x12\x1a.genproto.EmptyCartRequest\x1a\x0f.genproto.Empty\"\x002}\n" +
+	"\tEmptyCart\x12\x1a.genproto.EmptyCartRequest\x1a\x0f.genproto.Empty\"\x002\xb5\x01\n" +
 	"\x15RecommendationService\x12d\n" +
-	"\x13ListRecommendations\x12$.genproto.ListRecommendationsRequest\x1a%.genproto.ListRecommendationsResponse\"\x002\xf1\x01\n" +
+	"\x13ListRecommendations\x12$.genproto.ListRecommendationsRequest\x1a%.genproto.ListRecommendationsResponse\"\x00\x126\n" +
+	"\vRecordOrder\x12\x14.genproto.OrderEvent\x1a\x0f.genproto.Empty\"\x002\x96\x04\n" +
 	"\x15ProductCatalogService\x12A\n" +
-	"\fListProducts\x12\x0f.genproto.Empty\x1a\x1e.genproto.ListProductsResponse\"\x00\x12>\n" +
+	"\fListProducts\x12\x0f.genproto.Empty\x1a\x1e.genproto.ListProductsResponse\"\x00\x12S\n" +
+	"\x10ListProductsPage\x12\x1d.genproto.ListProductsRequest\x1a\x1e.genproto.ListProductsResponse\"\x00\x12>\n" +
 	"\n" +
 	"GetProduct\x12\x1b.genproto.GetProductRequest\x1a\x11.genproto.Product\"\x00\x12U\n" +
-	"\x0eSearchProducts\x12\x1f.genproto.SearchProductsRequest\x1a .genproto.SearchProductsResponse\"\x002\x9e\x01\n" +
+	"\x0eSearchProducts\x12\x1f.genproto.SearchProductsRequest\x1a .genproto.SearchProductsResponse\"\x00\x12D\n" +
+	"\rCreateProduct\x12\x1e.genproto.CreateProductRequest\x1a\x11.genproto.Product\"\x00\x12D\n" +
+	"\rUpdateProduct\x12\x1e.genproto.UpdateProductRequest\x1a\x11.genproto.Product\"\x00\x12B\n" +
+	"\rDeleteProduct\x12\x1e.genproto.DeleteProductRequest\x1a\x0f.genproto.Empty\"\x002\xcc\x02\n" +
 	"\x0fShippingService\x12C\n" +
 	"\bGetQuote\x12\x19.genproto.GetQuoteRequest\x1a\x1a.genproto.GetQuoteResponse\"\x00\x12F\n" +
-	"\tShipOrder\x12\x1a.genproto.ShipOrderRequest\x1a\x1b.genproto.ShipOrderResponse\"\x002\xab\x01\n" +
+	"\tShipOrder\x12\x1a.genproto.ShipOrderRequest\x1a\x1b.genproto.ShipOrderResponse\"\x00\x12R\n" +
+	"\rTrackShipment\x12\x1e.genproto.TrackShipmentRequest\x1a\x1f.genproto.TrackShipmentResponse\"\x00\x12X\n" +
+	"\x0fValidateAddress\x12 .genproto.ValidateAddressRequest\x1a!.genproto.ValidateAddressResponse\"\x002\xab\x01\n" +
 	"\x0fCurrencyService\x12U\n" +
 	"\x16GetSupportedCurrencies\x12\x0f.genproto.Empty\x1a(.genproto.GetSupportedCurrenciesResponse\"\x00\x12A\n" +
-	"\aConvert\x12#.genproto.CurrencyConversionRequest\x1a\x0f.genproto.Money\"\x002O\n" +
+	"\aConvert\x12#.genproto.CurrencyConversionRequest\x1a\x0f.genproto.Money\"\x002\xf7\x01\n" +
 	"\x0ePaymentService\x12=\n" +
-	"\x06Charge\x12\x17.genproto.ChargeRequest\x1a\x18.genproto.ChargeResponse\"\x002b\n" +
+	"\x06Charge\x12\x17.genproto.ChargeRequest\x1a\x18.genproto.ChargeResponse\"\x00\x12O\n" +
+	"\fTokenizeCard\x12\x1d.genproto.TokenizeCardRequest\x1a\x1e.genproto.TokenizeCardResponse\"\x00\x12U\n" +
+	"\x0eGetCardDetails\x12\x1f.genproto.GetCardDetailsRequest\x1a .genproto.GetCardDetailsResponse\"\x002b\n" +
 	"\fEmailService\x12R\n" +
 	"\x15SendOrderConfirmation\x12&.genproto.SendOrderConfirmationRequest\x1a\x0f.genproto.Empty\"\x002\\\n" +
 	"\x0fCheckoutService\x12I\n" +
 	"\n" +
-	"PlaceOrder\x12\x1b.genproto.PlaceOrderRequest\x1a\x1c.genproto.PlaceOrderResponse\"\x002B\n" +
+	"PlaceOrder\x12\x1b.genproto.PlaceOrderRequest\x1a\x1c.genproto.PlaceOrderResponse\"\x002\x95\x02\n" +
 	"\tAdService\x125\n" +
-	"\x06GetAds\x12\x13.genproto.AdRequest\x1a\x14.genproto.AdResponse\"\x00B7Z5github.com/norun9/microservices-demo-ambient/genprotob\x06proto3"
+	"\x06GetAds\x12\x13.genproto.AdRequest\x1a\x14.genproto.AdResponse\"\x00\x12/\n" +
+	"\x05GetAd\x12\x16.genproto.GetAdRequest\x1a\f.genproto.Ad\"\x00\x12U\n" +
+	"\x0eReportAdEvents\x12\x1f.genproto.ReportAdEventsRequest\x1a .genproto.ReportAdEventsResponse\"\x00\x12I\n" +
+	"\n" +
+	"GetAdStats\x12\x1b.genproto.GetAdStatsRequest\x1a\x1c.genproto.GetAdStatsResponse\"\x00B7Z5github.com/norun9/microservices-demo-ambient/genprotob\x06proto3"
 
 var (
 	file_demo_proto_rawDescOnce sync.Once
@@ -1866,100 +3955,183 @@ func file_demo_proto_rawDescGZIP() []byte {
 	return file_demo_proto_rawDescData
 }
 
-var file_demo_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
+var file_demo_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_demo_proto_msgTypes = make([]protoimpl.MessageInfo, 60)
 var file_demo_proto_goTypes = []any{
-	(*CartItem)(nil),                       // 0: genproto.CartItem
-	(*AddItemRequest)(nil),                 // 1: genproto.AddItemRequest
-	(*EmptyCartRequest)(nil),               // 2: genproto.EmptyCartRequest
-	(*GetCartRequest)(nil),                 // 3: genproto.GetCartRequest
-	(*Cart)(nil),                           // 4: genproto.Cart
-	(*Empty)(nil),                          // 5: genproto.Empty
-	(*ListRecommendationsRequest)(nil),     // 6: genproto.ListRecommendationsRequest
-	(*ListRecommendationsResponse)(nil),    // 7: genproto.ListRecommendationsResponse
-	(*Product)(nil),                        // 8: genproto.Product
-	(*ListProductsResponse)(nil),           // 9: genproto.ListProductsResponse
-	(*GetProductRequest)(nil),              // 10: genproto.GetProductRequest
-	(*SearchProductsRequest)(nil),          // 11: genproto.SearchProductsRequest
-	(*SearchProductsResponse)(nil),         // 12: genproto.SearchProductsResponse
-	(*GetQuoteRequest)(nil),                // 13: genproto.GetQuoteRequest
-	(*GetQuoteResponse)(nil),               // 14: genproto.GetQuoteResponse
-	(*ShipOrderRequest)(nil),               // 15: genproto.ShipOrderRequest
-	(*ShipOrderResponse)(nil),              // 16: genproto.ShipOrderResponse
-	(*Address)(nil),                        // 17: genproto.Address
-	(*Money)(nil),                          // 18: genproto.Money
-	(*GetSupportedCurrenciesResponse)(nil), // 19: genproto.GetSupportedCurrenciesResponse
-	(*CurrencyConversionRequest)(nil),      // 20: genproto.CurrencyConversionRequest
-	(*CreditCardInfo)(nil),                 // 21: genproto.CreditCardInfo
-	(*ChargeRequest)(nil),                  // 22: genproto.ChargeRequest
-	(*ChargeResponse)(nil),                 // 23: genproto.ChargeResponse
-	(*OrderItem)(nil),                      // 24: genproto.OrderItem
-	(*OrderResult)(nil),                    // 25: genproto.OrderResult
-	(*SendOrderConfirmationRequest)(nil),   // 26: genproto.SendOrderConfirmationRequest
-	(*PlaceOrderRequest)(nil),              // 27: genproto.PlaceOrderRequest
-	(*PlaceOrderResponse)(nil),             // 28: genproto.PlaceOrderResponse
-	(*AdRequest)(nil),                      // 29: genproto.AdRequest
-	(*AdResponse)(nil),                     // 30: genproto.AdResponse
-	(*Ad)(nil),                             // 31: genproto.Ad
+	(ProductSortOrder)(0),                  // 0: genproto.ProductSortOrder
+	(ShipmentStatus)(0),                    // 1: genproto.ShipmentStatus
+	(AdEventType)(0),                       // 2: genproto.AdEventType
+	(*CartItem)(nil),                       // 3: genproto.CartItem
+	(*AddItemRequest)(nil),                 // 4: genproto.AddItemRequest
+	(*EmptyCartRequest)(nil),               // 5: genproto.EmptyCartRequest
+	(*GetCartRequest)(nil),                 // 6: genproto.GetCartRequest
+	(*Cart)(nil),                           // 7: genproto.Cart
+	(*Empty)(nil),                          // 8: genproto.Empty
+	(*ListRecommendationsRequest)(nil),     // 9: genproto.ListRecommendationsRequest
+	(*ListRecommendationsResponse)(nil),    // 10: genproto.ListRecommendationsResponse
+	(*Recommendation)(nil),                 // 11: genproto.Recommendation
+	(*OrderEvent)(nil),                     // 12: genproto.OrderEvent
+	(*Product)(nil),                        // 13: genproto.Product
+	(*ProductVariant)(nil),                 // 14: genproto.ProductVariant
+	(*Dimensions)(nil),                     // 15: genproto.Dimensions
+	(*ListProductsRequest)(nil),            // 16: genproto.ListProductsRequest
+	(*ListProductsResponse)(nil),           // 17: genproto.ListProductsResponse
+	(*GetProductRequest)(nil),              // 18: genproto.GetProductRequest
+	(*CreateProductRequest)(nil),           // 19: genproto.CreateProductRequest
+	(*UpdateProductRequest)(nil),           // 20: genproto.UpdateProductRequest
+	(*DeleteProductRequest)(nil),           // 21: genproto.DeleteProductRequest
+	(*SearchProductsRequest)(nil),          // 22: genproto.SearchProductsRequest
+	(*SearchProductsResponse)(nil),         // 23: genproto.SearchProductsResponse
+	(*GetQuoteRequest)(nil),                // 24: genproto.GetQuoteRequest
+	(*GetQuoteResponse)(nil),               // 25: genproto.GetQuoteResponse
+	(*ShippingOption)(nil),                 // 26: genproto.ShippingOption
+	(*ShipOrderRequest)(nil),               // 27: genproto.ShipOrderRequest
+	(*ShipOrderResponse)(nil),              // 28: genproto.ShipOrderResponse
+	(*Shipment)(nil),                       // 29: genproto.Shipment
+	(*TrackShipmentRequest)(nil),           // 30: genproto.TrackShipmentRequest
+	(*ShipmentEvent)(nil),                  // 31: genproto.ShipmentEvent
+	(*TrackShipmentResponse)(nil),          // 32: genproto.TrackShipmentResponse
+	(*ValidateAddressRequest)(nil),         // 33: genproto.ValidateAddressRequest
+	(*ValidateAddressResponse)(nil),        // 34: genproto.ValidateAddressResponse
+	(*AddressViolation)(nil),               // 35: genproto.AddressViolation
+	(*Address)(nil),                        // 36: genproto.Address
+	(*Money)(nil),                          // 37: genproto.Money
+	(*GetSupportedCurrenciesResponse)(nil), // 38: genproto.GetSupportedCurrenciesResponse
+	(*CurrencyConversionRequest)(nil),      // 39: genproto.CurrencyConversionRequest
+	(*CreditCardInfo)(nil),                 // 40: genproto.CreditCardInfo
+	(*ChargeRequest)(nil),                  // 41: genproto.ChargeRequest
+	(*ChargeResponse)(nil),                 // 42: genproto.ChargeResponse
+	(*TokenizeCardRequest)(nil),            // 43: genproto.TokenizeCardRequest
+	(*TokenizeCardResponse)(nil),           // 44: genproto.TokenizeCardResponse
+	(*GetCardDetailsRequest)(nil),          // 45: genproto.GetCardDetailsRequest
+	(*GetCardDetailsResponse)(nil),         // 46: genproto.GetCardDetailsResponse
+	(*OrderItem)(nil),                      // 47: genproto.OrderItem
+	(*OrderResult)(nil),                    // 48: genproto.OrderResult
+	(*SendOrderConfirmationRequest)(nil),   // 49: genproto.SendOrderConfirmationRequest
+	(*PlaceOrderRequest)(nil),              // 50: genproto.PlaceOrderRequest
+	(*PlaceOrderResponse)(nil),             // 51: genproto.PlaceOrderResponse
+	(*AdRequest)(nil),                      // 52: genproto.AdRequest
+	(*AdResponse)(nil),                     // 53: genproto.AdResponse
+	(*GetAdRequest)(nil),                   // 54: genproto.GetAdRequest
+	(*Ad)(nil),                             // 55: genproto.Ad
+	(*AdEvent)(nil),                        // 56: genproto.AdEvent
+	(*ReportAdEventsRequest)(nil),          // 57: genproto.ReportAdEventsRequest
+	(*ReportAdEventsResponse)(nil),         // 58: genproto.ReportAdEventsResponse
+	(*GetAdStatsRequest)(nil),              // 59: genproto.GetAdStatsRequest
+	(*AdStats)(nil),                        // 60: genproto.AdStats
+	(*GetAdStatsResponse)(nil),             // 61: genproto.GetAdStatsResponse
+	nil,                                    // 62: genproto.ProductVariant.AttributesEntry
 }
 var file_demo_proto_depIdxs = []int32{
-	0,  // 0: genproto.AddItemRequest.item:type_name -> genproto.CartItem
-	0,  // 1: genproto.Cart.items:type_name -> genproto.CartItem
-	18, // 2: genproto.Product.price_usd:type_name -> genproto.Money
-	8,  // 3: genproto.ListProductsResponse.products:type_name -> genproto.Product
-	8,  // 4: genproto.SearchProductsResponse.results:type_name -> genproto.Product
-	17, // 5: genproto.GetQuoteRequest.address:type_name -> genproto.Address
-	0,  // 6: genproto.GetQuoteRequest.items:type_name -> genproto.CartItem
-	18, // 7: genproto.GetQuoteResponse.cost_usd:type_name -> genproto.Money
-	17, // 8: genproto.ShipOrderRequest.address:type_name -> genproto.Address
-	0,  // 9: genproto.ShipOrderRequest.items:type_name -> genproto.CartItem
-	18, // 10: genproto.CurrencyConversionRequest.from:type_name -> genproto.Money
-	18, // 11: genproto.ChargeRequest.amount:type_name -> genproto.Money
-	21, // 12: genproto.ChargeRequest.credit_card:type_name -> genproto.CreditCardInfo
-	0,  // 13: genproto.OrderItem.item:type_name -> genproto.CartItem
-	18, // 14: genproto.OrderItem.cost:type_name -> genproto.Money
-	18, // 15: genproto.OrderResult.shipping_cost:type_name -> genproto.Money
-	17, // 16: genproto.OrderResult.shipping_address:type_name -> genproto.Address
-	24, // 17: genproto.OrderResult.items:type_name -> genproto.OrderItem
-	25, // 18: genproto.SendOrderConfirmationRequest.order:type_name -> genproto.OrderResult
-	17, // 19: genproto.PlaceOrderRequest.address:type_name -> genproto.Address
-	21, // 20: genproto.PlaceOrderRequest.credit_card:type_name -> genproto.CreditCardInfo
-	25, // 21: genproto.PlaceOrderResponse.order:type_name -> genproto.OrderResult
-	31, // 22: genproto.AdResponse.ads:type_name -> genproto.Ad
-	1,  // 23: genproto.CartService.AddItem:input_type -> genproto.AddItemRequest
-	3,  // 24: genproto.CartService.GetCart:input_type -> genproto.GetCartRequest
-	2,  // 25: genproto.CartService.EmptyCart:input_type -> genproto.EmptyCartRequest
-	6,  // 26: genproto.RecommendationService.ListRecommendations:input_type -> genproto.ListRecommendationsRequest
-	5,  // 27: genproto.ProductCatalogService.ListProducts:input_type -> genproto.Empty
-	10, // 28: genproto.ProductCatalogService.GetProduct:input_type -> genproto.GetProductRequest
-	11, // 29: genproto.ProductCatalogService.SearchProducts:input_type -> genproto.SearchProductsRequest
-	13, // 30: genproto.ShippingService.GetQuote:input_type -> genproto.GetQuoteRequest
-	15, // 31: genproto.ShippingService.ShipOrder:input_type -> genproto.ShipOrderRequest
-	5,  // 32: genproto.CurrencyService.GetSupportedCurrencies:input_type -> genproto.Empty
-	20, // 33: genproto.CurrencyService.Convert:input_type -> genproto.CurrencyConversionRequest
-	22, // 34: genproto.PaymentService.Charge:input_type -> genproto.ChargeRequest
-	26, // 35: genproto.EmailService.SendOrderConfirmation:input_type -> genproto.SendOrderConfirmationRequest
-	27, // 36: genproto.CheckoutService.PlaceOrder:input_type -> genproto.PlaceOrderRequest
-	29, // 37: genproto.AdService.GetAds:input_type -> genproto.AdRequest
-	5,  // 38: genproto.CartService.AddItem:output_type -> genproto.Empty
-	4,  // 39: genproto.CartService.GetCart:output_type -> genproto.Cart
-	5,  // 40: genproto.CartService.EmptyCart:output_type -> genproto.Empty
-	7,  // 41: genproto.RecommendationService.ListRecommendations:output_type -> genproto.ListRecommendationsResponse
-	9,  // 42: genproto.ProductCatalogService.ListProducts:output_type -> genproto.ListProductsResponse
-	8,  // 43: genproto.ProductCatalogService.GetProduct:output_type -> genproto.Product
-	12, // 44: genproto.ProductCatalogService.SearchProducts:output_type -> genproto.SearchProductsResponse
-	14, // 45: genproto.ShippingService.GetQuote:output_type -> genproto.GetQuoteResponse
-	16, // 46: genproto.ShippingService.ShipOrder:output_type -> genproto.ShipOrderResponse
-	19, // 47: genproto.CurrencyService.GetSupportedCurrencies:output_type -> genproto.GetSupportedCurrenciesResponse
-	18, // 48: genproto.CurrencyService.Convert:output_type -> genproto.Money
-	23, // 49: genproto.PaymentService.Charge:output_type -> genproto.ChargeResponse
-	5,  // 50: genproto.EmailService.SendOrderConfirmation:output_type -> genproto.Empty
-	28, // 51: genproto.CheckoutService.PlaceOrder:output_type -> genproto.PlaceOrderResponse
-	30, // 52: genproto.AdService.GetAds:output_type -> genproto.AdResponse
-	38, // [38:53] is the sub-list for method output_type
-	23, // [23:38] is the sub-list for method input_type
-	23, // [23:23] is the sub-list for extension type_name
-	23, // [23:23] is the sub-list for extension extendee
-	0,  // [0:23] is the sub-list for field type_name
+	3,  // 0: genproto.AddItemRequest.item:type_name -> genproto.CartItem
+	3,  // 1: genproto.Cart.items:type_name -> genproto.CartItem
+	11, // 2: genproto.ListRecommendationsResponse.recommendations:type_name -> genproto.Recommendation
+	37, // 3: genproto.Product.price_usd:type_name -> genproto.Money
+	15, // 4: genproto.Product.dimensions:type_name -> genproto.Dimensions
+	14, // 5: genproto.Product.variants:type_name -> genproto.ProductVariant
+	62, // 6: genproto.ProductVariant.attributes:type_name -> genproto.ProductVariant.AttributesEntry
+	37, // 7: genproto.ProductVariant.price_usd:type_name -> genproto.Money
+	37, // 8: genproto.ListProductsRequest.min_price:type_name -> genproto.Money
+	37, // 9: genproto.ListProductsRequest.max_price:type_name -> genproto.Money
+	0,  // 10: genproto.ListProductsRequest.sort_order:type_name -> genproto.ProductSortOrder
+	13, // 11: genproto.ListProductsResponse.products:type_name -> genproto.Product
+	13, // 12: genproto.CreateProductRequest.product:type_name -> genproto.Product
+	13, // 13: genproto.UpdateProductRequest.product:type_name -> genproto.Product
+	13, // 14: genproto.SearchProductsResponse.results:type_name -> genproto.Product
+	36, // 15: genproto.GetQuoteRequest.address:type_name -> genproto.Address
+	3,  // 16: genproto.GetQuoteRequest.items:type_name -> genproto.CartItem
+	37, // 17: genproto.GetQuoteResponse.cost_usd:type_name -> genproto.Money
+	26, // 18: genproto.GetQuoteResponse.options:type_name -> genproto.ShippingOption
+	37, // 19: genproto.ShippingOption.cost_usd:type_name -> genproto.Money
+	36, // 20: genproto.ShipOrderRequest.address:type_name -> genproto.Address
+	3,  // 21: genproto.ShipOrderRequest.items:type_name -> genproto.CartItem
+	29, // 22: genproto.ShipOrderResponse.shipments:type_name -> genproto.Shipment
+	3,  // 23: genproto.Shipment.items:type_name -> genproto.CartItem
+	1,  // 24: genproto.ShipmentEvent.status:type_name -> genproto.ShipmentStatus
+	1,  // 25: genproto.TrackShipmentResponse.status:type_name -> genproto.ShipmentStatus
+	36, // 26: genproto.TrackShipmentResponse.address:type_name -> genproto.Address
+	31, // 27: genproto.TrackShipmentResponse.events:type_name -> genproto.ShipmentEvent
+	3,  // 28: genproto.TrackShipmentResponse.items:type_name -> genproto.CartItem
+	36, // 29: genproto.ValidateAddressRequest.address:type_name -> genproto.Address
+	36, // 30: genproto.ValidateAddressResponse.address:type_name -> genproto.Address
+	35, // 31: genproto.ValidateAddressResponse.violations:type_name -> genproto.AddressViolation
+	37, // 32: genproto.CurrencyConversionRequest.from:type_name -> genproto.Money
+	37, // 33: genproto.ChargeRequest.amount:type_name -> genproto.Money
+	40, // 34: genproto.ChargeRequest.credit_card:type_name -> genproto.CreditCardInfo
+	40, // 35: genproto.TokenizeCardRequest.credit_card:type_name -> genproto.CreditCardInfo
+	3,  // 36: genproto.OrderItem.item:type_name -> genproto.CartItem
+	37, // 37: genproto.OrderItem.cost:type_name -> genproto.Money
+	37, // 38: genproto.OrderResult.shipping_cost:type_name -> genproto.Money
+	36, // 39: genproto.OrderResult.shipping_address:type_name -> genproto.Address
+	47, // 40: genproto.OrderResult.items:type_name -> genproto.OrderItem
+	29, // 41: genproto.OrderResult.shipments:type_name -> genproto.Shipment
+	48, // 42: genproto.SendOrderConfirmationRequest.order:type_name -> genproto.OrderResult
+	36, // 43: genproto.PlaceOrderRequest.address:type_name -> genproto.Address
+	40, // 44: genproto.PlaceOrderRequest.credit_card:type_name -> genproto.CreditCardInfo
+	48, // 45: genproto.PlaceOrderResponse.order:type_name -> genproto.OrderResult
+	55, // 46: genproto.AdResponse.ads:type_name -> genproto.Ad
+	2,  // 47: genproto.AdEvent.type:type_name -> genproto.AdEventType
+	56, // 48: genproto.ReportAdEventsRequest.events:type_name -> genproto.AdEvent
+	60, // 49: genproto.GetAdStatsResponse.stats:type_name -> genproto.AdStats
+	4,  // 50: genproto.CartService.AddItem:input_type -> genproto.AddItemRequest
+	6,  // 51: genproto.CartService.GetCart:input_type -> genproto.GetCartRequest
+	5,  // 52: genproto.CartService.EmptyCart:input_type -> genproto.EmptyCartRequest
+	9,  // 53: genproto.RecommendationService.ListRecommendations:input_type -> genproto.ListRecommendationsRequest
+	12, // 54: genproto.RecommendationService.RecordOrder:input_type -> genproto.OrderEvent
+	8,  // 55: genproto.ProductCatalogService.ListProducts:input_type -> genproto.Empty
+	16, // 56: genproto.ProductCatalogService.ListProductsPage:input_type -> genproto.ListProductsRequest
+	18, // 57: genproto.ProductCatalogService.GetProduct:input_type -> genproto.GetProductRequest
+	22, // 58: genproto.ProductCatalogService.SearchProducts:input_type -> genproto.SearchProductsRequest
+	19, // 59: genproto.ProductCatalogService.CreateProduct:input_type -> genproto.CreateProductRequest
+	20, // 60: genproto.ProductCatalogService.UpdateProduct:input_type -> genproto.UpdateProductRequest
+	21, // 61: genproto.ProductCatalogService.DeleteProduct:input_type -> genproto.DeleteProductRequest
+	24, // 62: genproto.ShippingService.GetQuote:input_type -> genproto.GetQuoteRequest
+	27, // 63: genproto.ShippingService.ShipOrder:input_type -> genproto.ShipOrderRequest
+	30, // 64: genproto.ShippingService.TrackShipment:input_type -> genproto.TrackShipmentRequest
+	33, // 65: genproto.ShippingService.ValidateAddress:input_type -> genproto.ValidateAddressRequest
+	8,  // 66: genproto.CurrencyService.GetSupportedCurrencies:input_type -> genproto.Empty
+	39, // 67: genproto.CurrencyService.Convert:input_type -> genproto.CurrencyConversionRequest
+	41, // 68: genproto.PaymentService.Charge:input_type -> genproto.ChargeRequest
+	43, // 69: genproto.PaymentService.TokenizeCard:input_type -> genproto.TokenizeCardRequest
+	45, // 70: genproto.PaymentService.GetCardDetails:input_type -> genproto.GetCardDetailsRequest
+	49, // 71: genproto.EmailService.SendOrderConfirmation:input_type -> genproto.SendOrderConfirmationRequest
+	50, // 72: genproto.CheckoutService.PlaceOrder:input_type -> genproto.PlaceOrderRequest
+	52, // 73: genproto.AdService.GetAds:input_type -> genproto.AdRequest
+	54, // 74: genproto.AdService.GetAd:input_type -> genproto.GetAdRequest
+	57, // 75: genproto.AdService.ReportAdEvents:input_type -> genproto.ReportAdEventsRequest
+	59, // 76: genproto.AdService.GetAdStats:input_type -> genproto.GetAdStatsRequest
+	8,  // 77: genproto.CartService.AddItem:output_type -> genproto.Empty
+	7,  // 78: genproto.CartService.GetCart:output_type -> genproto.Cart
+	8,  // 79: genproto.CartService.EmptyCart:output_type -> genproto.Empty
+	10, // 80: genproto.RecommendationService.ListRecommendations:output_type -> genproto.ListRecommendationsResponse
+	8,  // 81: genproto.RecommendationService.RecordOrder:output_type -> genproto.Empty
+	17, // 82: genproto.ProductCatalogService.ListProducts:output_type -> genproto.ListProductsResponse
+	17, // 83: genproto.ProductCatalogService.ListProductsPage:output_type -> genproto.ListProductsResponse
+	13, // 84: genproto.ProductCatalogService.GetProduct:output_type -> genproto.Product
+	23, // 85: genproto.ProductCatalogService.SearchProducts:output_type -> genproto.SearchProductsResponse
+	13, // 86: genproto.ProductCatalogService.CreateProduct:output_type -> genproto.Product
+	13, // 87: genproto.ProductCatalogService.UpdateProduct:output_type -> genproto.Product
+	8,  // 88: genproto.ProductCatalogService.DeleteProduct:output_type -> genproto.Empty
+	25, // 89: genproto.ShippingService.GetQuote:output_type -> genproto.GetQuoteResponse
+	28, // 90: genproto.ShippingService.ShipOrder:output_type -> genproto.ShipOrderResponse
+	32, // 91: genproto.ShippingService.TrackShipment:output_type -> genproto.TrackShipmentResponse
+	34, // 92: genproto.ShippingService.ValidateAddress:output_type -> genproto.ValidateAddressResponse
+	38, // 93: genproto.CurrencyService.GetSupportedCurrencies:output_type -> genproto.GetSupportedCurrenciesResponse
+	37, // 94: genproto.CurrencyService.Convert:output_type -> genproto.Money
+	42, // 95: genproto.PaymentService.Charge:output_type -> genproto.ChargeResponse
+	44, // 96: genproto.PaymentService.TokenizeCard:output_type -> genproto.TokenizeCardResponse
+	46, // 97: genproto.PaymentService.GetCardDetails:output_type -> genproto.GetCardDetailsResponse
+	8,  // 98: genproto.EmailService.SendOrderConfirmation:output_type -> genproto.Empty
+	51, // 99: genproto.CheckoutService.PlaceOrder:output_type -> genproto.PlaceOrderResponse
+	53, // 100: genproto.AdService.GetAds:output_type -> genproto.AdResponse
+	55, // 101: genproto.AdService.GetAd:output_type -> genproto.Ad
+	58, // 102: genproto.AdService.ReportAdEvents:output_type -> genproto.ReportAdEventsResponse
+	61, // 103: genproto.AdService.GetAdStats:output_type -> genproto.GetAdStatsResponse
+	77, // [77:104] is the sub-list for method output_type
+	50, // [50:77] is the sub-list for method input_type
+	50, // [50:50] is the sub-list for extension type_name
+	50, // [50:50] is the sub-list for extension extendee
+	0,  // [0:50] is the sub-list for field type_name
 }
 
 func init() { file_demo_proto_init() }
@@ -1972,13 +4144,14 @@ func file_demo_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_demo_proto_rawDesc), len(file_demo_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   32,
+			NumEnums:      3,
+			NumMessages:   60,
 			NumExtensions: 0,
 			NumServices:   9,
 		},
 		GoTypes:           file_demo_proto_goTypes,
 		DependencyIndexes: file_demo_proto_depIdxs,
+		EnumInfos:         file_demo_proto_enumTypes,
 		MessageInfos:      file_demo_proto_msgTypes,
 	}.Build()
 	File_demo_proto = out.File
@@ -114,7 +114,8 @@ run "-p 5050 -e PORT=5050 \
      -e PAYMENT_SERVICE_ADDR=paymentservice:50051 \
      -e EMAIL_SERVICE_ADDR=emailservice:8080 \
      -e CURRENCY_SERVICE_ADDR=currencyservice:7000 \
-     -e CART_SERVICE_ADDR=cartservice:7070" "$containername"
+     -e CART_SERVICE_ADDR=cartservice:7070 \
+     -e RECOMMENDATION_SERVICE_ADDR=recommendationservice:8080" "$containername"
 
 containername=currencyservice
 run "-p 7000 -e PORT=7000 \
@@ -133,6 +134,7 @@ run "-p 8080:8080 -e PORT=8080 \
      -e CART_SERVICE_ADDR=cartservice:7070 \
      -e RECOMMENDATION_SERVICE_ADDR=recommendationservice:8080 \
      -e CHECKOUT_SERVICE_ADDR=checkoutservice:5050 \
+     -e PAYMENT_SERVICE_ADDR=paymentservice:50051 \
      -e AD_SERVICE_ADDR=adservice:9555" "$containername"
 
 containername=paymentservice
@@ -141,16 +143,19 @@ run "-p 50051 -e PORT=50051 \
 
 containername=productcatalogservice
 run "-p 3550 -e PORT=3550 \
+     -e CURRENCY_SERVICE_ADDR=currencyservice:7000 \
      " "$containername"
 
 containername=recommendationservice
 run "-p 8080 -e PORT=8080 \
      -e OTEL_PYTHON_LOG_CORRELATION=true \
      -e PRODUCT_CATALOG_SERVICE_ADDR=productcatalogservice:3550 \
+     -e RECOMMENDATION_STRATEGY=cooccurrence \
      " "$containername"
 
 containername=shippingservice
 run "-p 50051 -e PORT=50051 \
+     -e PRODUCT_CATALOG_SERVICE_ADDR=productcatalogservice:3550 \
      " "$containername"
 
 containername=loadgenerator
@@ -0,0 +1,228 @@
+// Package redact scrubs cardholder data (PAN and CVV) from log output and
+// span attributes before it leaves the process.
+//
+// It works on rendered text rather than on typed values, so it also catches
+// card data that reaches a logger or span through fmt verbs such as %v on a
+// ChargeRequest or PlaceOrderRequest.
+package redact
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// PANMask replaces anything that looks like a primary account number.
+	PANMask = "[REDACTED-PAN]"
+	// CVVMask replaces the value of a card verification code.
+	CVVMask = "[REDACTED-CVV]"
+)
+
+var (
+	// panPattern matches 13-19 digits, optionally grouped by single spaces or
+	// dashes, that are not part of a longer word (so hex trace IDs and UUIDs
+	// are left alone). In free-form text, only matches that pass the Luhn
+	// check are masked, so that IDs and timestamps of that length are mostly
+	// left alone too.
+	panPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+	// cardNumberPattern matches the value of a card number next to its key,
+	// which is masked whether it passes the Luhn check or not, so that
+	// mistyped card numbers are masked too.
+	cardNumberPattern = regexp.MustCompile(`(?i)((?:credit_?card_?|card_?)number\\?"?\s*[:=]\s*\\?"?)\d(?:[ -]?\d)*`)
+
+	// cvvPattern matches a CVV/CVC value next to its key, as rendered by
+	// fmt, protobuf text format, JSON and logrus text output.
+	cvvPattern = regexp.MustCompile(`(?i)((?:credit_?card_?)?(?:cvv|cvc|cvv2|cvc2|cid)\\?"?\s*[:=]\s*\\?"?)\d{3,4}`)
+
+	// sensitiveKeys are attribute keys whose values are dropped entirely.
+	sensitiveKeys = []string{"credit_card_number", "creditcardnumber", "card_number", "pan", "cvv", "cvc"}
+)
+
+// String returns s with every PAN and CVV value masked.
+func String(s string) string {
+	return string(Bytes([]byte(s)))
+}
+
+// Bytes is like String for byte slices.
+func Bytes(b []byte) []byte {
+	b = cvvPattern.ReplaceAll(b, []byte("${1}"+CVVMask))
+	b = cardNumberPattern.ReplaceAll(b, []byte("${1}"+PANMask))
+	return maskPANs(b)
+}
+
+// maskPANs masks the matches of panPattern in b that pass the Luhn check,
+// unless they are part of a longer ID joined by dashes, such as the
+// AB-1234567890123-7 tracking IDs of shipments.
+func maskPANs(b []byte) []byte {
+	var out []byte
+	last := 0
+	for _, m := range panPattern.FindAllIndex(b, -1) {
+		start, end := m[0], m[1]
+		if !luhn(b[start:end]) || joined(b, start, end) {
+			continue
+		}
+		out = append(out, b[last:start]...)
+		out = append(out, PANMask...)
+		last = end
+	}
+	if out == nil {
+		return b
+	}
+	return append(out, b[last:]...)
+}
+
+// joined reports whether b[start:end] is joined to a letter or digit by a
+// dash on either side.
+func joined(b []byte, start, end int) bool {
+	return start >= 2 && b[start-1] == '-' && isAlnum(b[start-2]) ||
+		end+1 < len(b) && b[end] == '-' && isAlnum(b[end+1])
+}
+
+func isAlnum(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// luhn reports whether the digits of b, ignoring separators, pass the Luhn
+// check that every card number passes.
+func luhn(b []byte) bool {
+	var sum int
+	double := false
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < '0' || b[i] > '9' {
+			continue
+		}
+		d := int(b[i] - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isSensitiveKey reports whether an attribute key names card data.
+func isSensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if k == s || strings.HasSuffix(k, "."+s) || strings.HasSuffix(k, "_"+s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Attributes returns a copy of attrs with card data masked. Values of
+// sensitive keys are replaced outright; other string values are scrubbed.
+func Attributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, len(attrs))
+	for i, kv := range attrs {
+		out[i] = attr(kv)
+	}
+	return out
+}
+
+func attr(kv attribute.KeyValue) attribute.KeyValue {
+	if isSensitiveKey(string(kv.Key)) {
+		return kv.Key.String(PANMask)
+	}
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		return kv.Key.String(String(kv.Value.AsString()))
+	case attribute.STRINGSLICE:
+		vs := kv.Value.AsStringSlice()
+		for i := range vs {
+			vs[i] = String(vs[i])
+		}
+		return kv.Key.StringSlice(vs)
+	}
+	return kv
+}
+
+// writer scrubs everything written through it.
+type writer struct {
+	mu   sync.Mutex
+	next io.Writer
+}
+
+// NewWriter wraps w so that card data never reaches it. Both the standard
+// library logger and logrus write whole entries per call, which is what the
+// patterns rely on.
+func NewWriter(w io.Writer) io.Writer {
+	return &writer{next: w}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.next.Write(Bytes(bytes.Clone(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// spanExporter masks card data in spans before handing them to the next
+// exporter.
+type spanExporter struct {
+	next sdktrace.SpanExporter
+}
+
+// NewSpanExporter wraps exp so that span names, attributes, events and
+// status descriptions are scrubbed before export. This also covers spans
+// recorded by instrumentation libraries such as otelgrpc and otelmux.
+func NewSpanExporter(exp sdktrace.SpanExporter) sdktrace.SpanExporter {
+	return &spanExporter{next: exp}
+}
+
+func (e *spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	out := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		out[i] = redactedSpan{s}
+	}
+	return e.next.ExportSpans(ctx, out)
+}
+
+func (e *spanExporter) Shutdown(ctx context.Context) error {
+	return e.next.Shutdown(ctx)
+}
+
+// redactedSpan overrides the fields of a ReadOnlySpan that can carry
+// free-form text.
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s redactedSpan) Name() string {
+	return String(s.ReadOnlySpan.Name())
+}
+
+func (s redactedSpan) Attributes() []attribute.KeyValue {
+	return Attributes(s.ReadOnlySpan.Attributes())
+}
+
+func (s redactedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	out := make([]sdktrace.Event, len(events))
+	for i, ev := range events {
+		ev.Name = String(ev.Name)
+		ev.Attributes = Attributes(ev.Attributes)
+		out[i] = ev
+	}
+	return out
+}
+
+func (s redactedSpan) Status() sdktrace.Status {
+	st := s.ReadOnlySpan.Status()
+	st.Description = String(st.Description)
+	return st
+}
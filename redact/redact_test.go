@@ -0,0 +1,106 @@
+package redact
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain pan", "card 4432801561520454 declined", "card " + PANMask + " declined"},
+		{"dashed pan", "card 4432-8015-6152-0454", "card " + PANMask},
+		{"spaced pan", "card 5555 5555 5555 4444.", "card " + PANMask + "."},
+		{"proto text cvv", "credit_card_cvv:672", "credit_card_cvv:" + CVVMask},
+		{"go struct cvv", "{CreditCardCvv:672 CreditCardExpirationYear:2030}", "{CreditCardCvv:" + CVVMask + " CreditCardExpirationYear:2030}"},
+		{"json cvv", `{"credit_card_cvv":"672"}`, `{"credit_card_cvv":"` + CVVMask + `"}`},
+		{"trace id untouched", "4bf92f3577b34da6a3ce929d0e0e4736", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"uuid untouched", "123e4567-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174000"},
+		{"short number untouched", "zip 94043 amount 109", "zip 94043 amount 109"},
+		{"timestamp untouched", "at 1718900000000000001", "at 1718900000000000001"},
+		{"order id untouched", "order 4432801561520455", "order 4432801561520455"},
+		{"amex pan", "card 3782-822463-10005", "card " + PANMask},
+		{"mistyped proto text pan", `credit_card_number:"4432801561520455"`, `credit_card_number:"` + PANMask + `"`},
+		{"mistyped go struct pan", "{CreditCardNumber:4432-8015-6152-045 CreditCardCvv:672}", "{CreditCardNumber:" + PANMask + " CreditCardCvv:" + CVVMask + "}"},
+		{"mistyped json pan", `{"card_number":"5555 5555 5555 4445"}`, `{"card_number":"` + PANMask + `"}`},
+		{"tracking id untouched", "shipment AB-1234567890123-7 shipped", "shipment AB-1234567890123-7 shipped"},
+		{"tracking id ending untouched", "tracking=AB-1234567890123-7", "tracking=AB-1234567890123-7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := String(tt.in); got != tt.want {
+				t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	in := []attribute.KeyValue{
+		attribute.String("app.credit_card_number", "4432"),
+		attribute.String("error", "bad card 4432801561520454"),
+		attribute.StringSlice("args", []string{"4432-8015-6152-0454"}),
+		attribute.Int("payment.units", 19),
+	}
+	want := []attribute.KeyValue{
+		attribute.String("app.credit_card_number", PANMask),
+		attribute.String("error", "bad card "+PANMask),
+		attribute.StringSlice("args", []string{PANMask}),
+		attribute.Int("payment.units", 19),
+	}
+	got := Attributes(in)
+	for i := range want {
+		if got[i].Key != want[i].Key || got[i].Value.Emit() != want[i].Value.Emit() {
+			t.Errorf("Attributes()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	in := []byte("charging 4432801561520454 cvv=672\n")
+	n, err := w.Write(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(in) {
+		t.Errorf("Write() = %d, want %d", n, len(in))
+	}
+	if want := "charging " + PANMask + " cvv=" + CVVMask + "\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSpanExporter(t *testing.T) {
+	mem := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(NewSpanExporter(mem)))
+	_, span := tp.Tracer("test").Start(context.Background(), "charge 4432801561520454")
+	span.SetAttributes(attribute.String("request", "credit_card_number:\"4432801561520454\" credit_card_cvv:672"))
+	span.AddEvent("retry", trace.WithAttributes(attribute.String("card_number", "4432801561520454")))
+	span.End()
+
+	spans := mem.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if want := "charge " + PANMask; s.Name != want {
+		t.Errorf("Name = %q, want %q", s.Name, want)
+	}
+	if got, want := s.Attributes[0].Value.AsString(), "credit_card_number:\""+PANMask+"\" credit_card_cvv:"+CVVMask; got != want {
+		t.Errorf("attribute = %q, want %q", got, want)
+	}
+	if got := s.Events[0].Attributes[0].Value.AsString(); got != PANMask {
+		t.Errorf("event attribute = %q, want %q", got, PANMask)
+	}
+}
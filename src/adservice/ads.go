@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// adConfig is an ad of the ads file.
+type adConfig struct {
+	ID string `json:"id"`
+	// Categories of the products the ad is shown along with, and Keywords of
+	// the pages it is relevant to, such as words of product names.
+	Categories  []string `json:"categories"`
+	Keywords    []string `json:"keywords"`
+	RedirectURL string   `json:"redirect_url"`
+	Text        string   `json:"text"`
+
+	// Weight is how often the ad is chosen relative to the others, 1 if
+	// not given.
+	Weight float64 `json:"weight"`
+	// The ad is only shown from Start and until End, if given.
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+	// The ad is shown at most DailyImpressionCap times a day, and
+	// SessionFrequencyCap times a day to the same session, unless they are
+	// zero.
+	DailyImpressionCap  int `json:"daily_impression_cap"`
+	SessionFrequencyCap int `json:"session_frequency_cap"`
+}
+
+// loadAds reads and validates the ads of a JSON file.
+func loadAds(path string) ([]adConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ads: %w", err)
+	}
+	var file struct {
+		Ads []adConfig `json:"ads"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse ads: %w", err)
+	}
+	if err := validateAds(file.Ads); err != nil {
+		return nil, fmt.Errorf("invalid ads in %s: %w", path, err)
+	}
+	return file.Ads, nil
+}
+
+func validateAds(ads []adConfig) error {
+	if len(ads) == 0 {
+		return errors.New("no ads")
+	}
+	ids := make(map[string]bool)
+	for i := range ads {
+		ad := &ads[i]
+		switch {
+		case ad.ID == "":
+			return fmt.Errorf("ad %d has no id", i)
+		case ids[ad.ID]:
+			return fmt.Errorf("ad %s is given twice", ad.ID)
+		case ad.Text == "" || ad.RedirectURL == "":
+			return fmt.Errorf("ad %s needs a text and a redirect_url", ad.ID)
+		case ad.Weight < 0:
+			return fmt.Errorf("ad %s has negative weight %v", ad.ID, ad.Weight)
+		case ad.DailyImpressionCap < 0 || ad.SessionFrequencyCap < 0:
+			return fmt.Errorf("ad %s has a negative cap", ad.ID)
+		case ad.Start != nil && ad.End != nil && !ad.End.After(*ad.Start):
+			return fmt.Errorf("ad %s ends before it starts", ad.ID)
+		}
+		ids[ad.ID] = true
+		if ad.Weight == 0 {
+			ad.Weight = 1
+		}
+	}
+	return nil
+}
+
+func (ad *adConfig) proto() *pb.Ad {
+	return &pb.Ad{Id: ad.ID, RedirectUrl: ad.RedirectURL, Text: ad.Text}
+}
+
+// adSelector chooses the ads to serve. Ads are capped by the impressions of
+// the current day reported to stats, rather than by the ads served, as not all
+// of them are shown. It is safe for concurrent use.
+type adSelector struct {
+	ads []adConfig
+	// targets are the targets of each ad of ads.
+	targets [][]target
+	stats   *adStats
+	// rand returns a random number in [0, 1). The top-level functions of
+	// math/rand/v2 are safe for concurrent use.
+	rand func() float64
+}
+
+func newAdSelector(ads []adConfig, stats *adStats) *adSelector {
+	targets := make([][]target, len(ads))
+	for i := range ads {
+		targets[i] = ads[i].targets()
+	}
+	return &adSelector{ads: ads, targets: targets, stats: stats, rand: rand.Float64}
+}
+
+// choose returns up to n different ads for session. Ads are sampled from those
+// relevant to the keywords of contextKeys, by their weight times their
+// relevance, or by weight from all ads if none of them can be shown.
+func (s *adSelector) choose(session string, contextKeys []string, n int) []*adConfig {
+	now := s.stats.now()
+	impressions, sessionImpressions := s.stats.today(session, now)
+
+	context := keywords(contextKeys...)
+	var eligible, relevant []*adConfig
+	var eligibleWeights, relevantWeights []float64
+	for i := range s.ads {
+		ad := &s.ads[i]
+		if !showable(ad, now, impressions[ad.ID], sessionImpressions[ad.ID]) {
+			continue
+		}
+		eligible = append(eligible, ad)
+		eligibleWeights = append(eligibleWeights, ad.Weight)
+		if score := relevance(s.targets[i], context); score > 0 {
+			relevant = append(relevant, ad)
+			relevantWeights = append(relevantWeights, ad.Weight*score)
+		}
+	}
+
+	if len(relevant) > 0 {
+		return s.sample(relevant, relevantWeights, n)
+	}
+	return s.sample(eligible, eligibleWeights, n)
+}
+
+// ad returns the ad of id, or nil if there is none.
+func (s *adSelector) ad(id string) *adConfig {
+	for i := range s.ads {
+		if s.ads[i].ID == id {
+			return &s.ads[i]
+		}
+	}
+	return nil
+}
+
+// showable reports whether ad can be shown at now, after its impressions of
+// the day, of which sessionImpressions to the session it is shown to.
+func showable(ad *adConfig, now time.Time, impressions, sessionImpressions int) bool {
+	switch {
+	case ad.Start != nil && now.Before(*ad.Start):
+		return false
+	case ad.End != nil && !now.Before(*ad.End):
+		return false
+	case ad.DailyImpressionCap > 0 && float64(impressions) >= pacedCap(ad.DailyImpressionCap, now):
+		return false
+	case ad.SessionFrequencyCap > 0 && sessionImpressions >= ad.SessionFrequencyCap:
+		return false
+	}
+	return true
+}
+
+// pacingInterval is the time over which impressions are paced: by the end of
+// each interval of a day, an ad is shown at most the share of its daily cap of
+// the intervals past, so that it is not used up by the first hours of traffic.
+const pacingInterval = time.Hour
+
+// pacedCap returns the impressions an ad with a daily cap can have had by the
+// end of the pacing interval of now.
+func pacedCap(cap int, now time.Time) float64 {
+	const day = 24 * time.Hour
+	now = now.UTC()
+	elapsed := now.Sub(now.Truncate(day)).Truncate(pacingInterval) + pacingInterval
+	return float64(cap) * float64(min(elapsed, day)) / float64(day)
+}
+
+// sample draws up to n ads without replacement, each with a probability
+// proportional to its weight of weights among the ads left.
+func (s *adSelector) sample(ads []*adConfig, weights []float64, n int) []*adConfig {
+	left := append([]*adConfig(nil), ads...)
+	weights = append([]float64(nil), weights...)
+	var chosen []*adConfig
+	for len(chosen) < n && len(left) > 0 {
+		var total float64
+		for _, w := range weights {
+			total += w
+		}
+		r := s.rand() * total
+		i := 0
+		for ; i < len(left)-1; i++ {
+			if r < weights[i] {
+				break
+			}
+			r -= weights[i]
+		}
+		chosen = append(chosen, left[i])
+		left = append(left[:i], left[i+1:]...)
+		weights = append(weights[:i], weights[i+1:]...)
+	}
+	return chosen
+}
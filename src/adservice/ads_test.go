@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoadAds(t *testing.T) {
+	ads, err := loadAds(defaultAdsFile)
+	if err != nil {
+		t.Fatalf("bundled ads: %v", err)
+	}
+	for _, ad := range ads {
+		if ad.Weight <= 0 {
+			t.Errorf("ad %s has weight %v after loading", ad.ID, ad.Weight)
+		}
+	}
+
+	for _, invalid := range []string{
+		`{"ads": []}`,
+		`{"ads": [{"text": "t", "redirect_url": "/"}]}`,
+		`{"ads": [{"id": "a", "text": "t", "redirect_url": "/"}, {"id": "a", "text": "t", "redirect_url": "/"}]}`,
+		`{"ads": [{"id": "a", "redirect_url": "/"}]}`,
+		`{"ads": [{"id": "a", "text": "t", "redirect_url": "/", "weight": -1}]}`,
+		`{"ads": [{"id": "a", "text": "t", "redirect_url": "/", "daily_impression_cap": -1}]}`,
+		`{"ads": [{"id": "a", "text": "t", "redirect_url": "/", "start": "2024-02-01T00:00:00Z", "end": "2024-01-01T00:00:00Z"}]}`,
+		`{"ads": [{"id": "a", "text": "t", "redirect_url": "/", "start": "yesterday"}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "ads.json")
+		if err := os.WriteFile(path, []byte(invalid), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadAds(path); err == nil {
+			t.Errorf("loadAds(%s) = nil error", invalid)
+		}
+	}
+}
+
+func date(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
+
+func ids(ads []*adConfig) []string {
+	var ids []string
+	for _, ad := range ads {
+		ids = append(ids, ad.ID)
+	}
+	return ids
+}
+
+func TestChoose(t *testing.T) {
+	ads := []adConfig{
+		{ID: "jar", Categories: []string{"kitchen"}, Weight: 1, SessionFrequencyCap: 2},
+		{ID: "mug", Categories: []string{"kitchen"}, Weight: 1, DailyImpressionCap: 1},
+		{ID: "watch", Categories: []string{"accessories"}, Weight: 1, Start: date("2024-06-01T00:00:00Z"), End: date("2024-07-01T00:00:00Z")},
+		{ID: "candle", Categories: []string{"decor"}, Weight: 1},
+	}
+	stats := newAdStats(ads)
+	s := newAdSelector(ads, stats)
+	now := *date("2024-05-31T12:00:00Z")
+	stats.now = func() time.Time { return now }
+	// Always draw the first ad left.
+	s.rand = func() float64 { return 0 }
+
+	// check chooses ads for session and reports them all shown.
+	check := func(session string, keys []string, want ...string) {
+		t.Helper()
+		got := ids(s.choose(session, keys, 2))
+		if !slices.Equal(got, want) {
+			t.Fatalf("choose(%s, %v) = %v, want %v", session, keys, got, want)
+		}
+		var events []*pb.AdEvent
+		for _, id := range got {
+			events = append(events, &pb.AdEvent{AdId: id, Type: pb.AdEventType_AD_IMPRESSION, SessionId: session})
+		}
+		if rejected := stats.record(events); len(rejected) > 0 {
+			t.Fatalf("record(%v) rejected %v", events, rejected)
+		}
+	}
+
+	// Ads served count against their caps only once reported shown.
+	for i := 0; i < 3; i++ {
+		if got := ids(s.choose("s1", []string{"kitchen"}, 2)); !slices.Equal(got, []string{"jar", "mug"}) {
+			t.Fatalf("choose(s1, kitchen) = %v before any impression", got)
+		}
+	}
+	check("s1", []string{"kitchen"}, "jar", "mug")
+	// The mug reached its daily cap.
+	check("s2", []string{"kitchen"}, "jar")
+	// The jar reached its cap for s1, so ads of other categories are shown.
+	check("s1", []string{"kitchen"}, "jar")
+	check("s1", []string{"kitchen"}, "candle")
+	// The watch is not shown before it starts, nor after it ends.
+	check("s3", []string{"accessories"}, "jar", "candle")
+	now = *date("2024-06-30T23:59:59Z")
+	// A new day resets the caps.
+	check("s1", []string{"accessories", "kitchen"}, "jar", "mug")
+	check("s1", []string{"accessories"}, "watch")
+	now = *date("2024-07-01T00:00:00Z")
+	check("s4", []string{"accessories"}, "jar", "mug")
+	// Sessions are not capped without a session.
+	for i := 0; i < 3; i++ {
+		check("", []string{"decor"}, "candle")
+	}
+}
+
+func TestGetAd(t *testing.T) {
+	ads := []adConfig{{ID: "mug", RedirectURL: "/product/mug", Text: "Mug", End: date("2024-01-01T00:00:00Z")}}
+	svc := &adServiceServer{tracer: otel.Tracer("test"), ads: newAdSelector(ads, newAdStats(ads))}
+	// Ads that ended are still found, for their last clicks.
+	ad, err := svc.GetAd(context.Background(), &pb.GetAdRequest{Id: "mug"})
+	if err != nil || ad.RedirectUrl != "/product/mug" {
+		t.Errorf("GetAd(mug) = %v, %v", ad, err)
+	}
+	if _, err := svc.GetAd(context.Background(), &pb.GetAdRequest{Id: "lamp"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetAd(lamp) = %v, want NotFound", err)
+	}
+}
+
+func TestPacing(t *testing.T) {
+	ads := []adConfig{{ID: "mug", Weight: 1, DailyImpressionCap: 48}}
+	stats := newAdStats(ads)
+	s := newAdSelector(ads, stats)
+	var now time.Time
+	stats.now = func() time.Time { return now }
+
+	// show reports the mug shown as long as it is served, and returns how
+	// many times it was.
+	show := func() int {
+		n := 0
+		for len(s.choose("", nil, 1)) == 1 {
+			stats.record([]*pb.AdEvent{{AdId: "mug", Type: pb.AdEventType_AD_IMPRESSION}})
+			n++
+		}
+		return n
+	}
+	for _, tc := range []struct {
+		now  string
+		want int
+	}{
+		// Two impressions an hour.
+		{"2024-05-31T00:00:00Z", 2},
+		{"2024-05-31T00:59:59Z", 0},
+		{"2024-05-31T01:00:00Z", 2},
+		// Hours without traffic are caught up with.
+		{"2024-05-31T05:30:00Z", 8},
+		{"2024-05-31T23:59:59Z", 36},
+		{"2024-06-01T00:00:00Z", 2},
+	} {
+		now = *date(tc.now)
+		if got := show(); got != tc.want {
+			t.Errorf("impressions at %s = %d, want %d", tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestSampleWeights(t *testing.T) {
+	ads := []adConfig{{ID: "a", Weight: 3}, {ID: "b", Weight: 1}}
+	s := newAdSelector(ads, newAdStats(ads))
+	const draws = 10000
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < draws/4; i++ {
+				ad := s.choose("", nil, 1)[0]
+				mu.Lock()
+				counts[ad.ID]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if share := float64(counts["a"]) / draws; math.Abs(share-0.75) > 0.03 {
+		t.Errorf("ad of weight 3 out of 4 drawn %.3f of the time", share)
+	}
+	// Ads are drawn without replacement.
+	if got := ids(s.choose("", nil, 5)); len(got) != 2 || got[0] == got[1] {
+		t.Errorf("choose(5) = %v, want both ads", got)
+	}
+}
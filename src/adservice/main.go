@@ -4,11 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math/rand"
 	"net"
 	"os"
-	"time"
 
+	"github.com/norun9/microservices-demo-ambient/chaos"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
@@ -20,27 +19,26 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// maxAdsServed is the number of ads served at most for a request, as
+	// the frontend shows a single ad a page.
+	maxAdsServed = 1
+
+	defaultAdsFile = "data/ads.json"
 )
 
 // adServiceServer implements the AdServiceServer interface.
 type adServiceServer struct {
 	pb.UnimplementedAdServiceServer
 	tracer trace.Tracer
-}
-
-// adsMap holds ads by category.
-var adsMap = map[string][]*pb.Ad{
-	"clothing":    {{RedirectUrl: "/product/66VCHSJNUP", Text: "Tank top for sale. 20% off."}},
-	"accessories": {{RedirectUrl: "/product/1YMWWN1N4O", Text: "Watch for sale. Buy one, get second one for free"}},
-	"footwear":    {{RedirectUrl: "/product/L9ECAV7KIM", Text: "Loafers for sale. Buy one, get second one for free"}},
-	"hair":        {{RedirectUrl: "/product/2ZYFJ3GM2N", Text: "Hairdryer for sale. 50% off."}},
-	"decor":       {{RedirectUrl: "/product/0PUK6V6EV0", Text: "Candle holder for sale. 30% off."}},
-	"kitchen": {
-		{RedirectUrl: "/product/9SIQT8TOJO", Text: "Bamboo glass jar for sale. 10% off."},
-		{RedirectUrl: "/product/6E92ZMYYFZ", Text: "Mug for sale. Buy two, get third one for free"},
-	},
+	ads    *adSelector
+	stats  *adStats
 }
 
 // GetAds implements AdService.GetAds.
@@ -52,36 +50,72 @@ func (s *adServiceServer) GetAds(ctx context.Context, req *pb.AdRequest) (*pb.Ad
 	span.SetAttributes(attribute.StringSlice("app.context_keys", req.ContextKeys))
 	span.SetAttributes(attribute.Int("app.ads_requested", len(req.ContextKeys)))
 
-	var allAds []*pb.Ad
-	for _, key := range req.ContextKeys {
-		if ads, ok := adsMap[key]; ok {
-			allAds = append(allAds, ads...)
-		}
-	}
-	if len(allAds) == 0 {
-		allAds = getRandomAds()
+	chosen := s.ads.choose(req.SessionId, req.ContextKeys, maxAdsServed)
+	ads := make([]*pb.Ad, len(chosen))
+	ids := make([]string, len(chosen))
+	for i, ad := range chosen {
+		ads[i], ids[i] = ad.proto(), ad.ID
 	}
-	span.SetAttributes(attribute.Int("app.ads_served", len(allAds)))
+	span.SetAttributes(attribute.Int("app.ads_served", len(ads)))
+	span.SetAttributes(attribute.StringSlice("app.ad_ids", ids))
 
 	resp := &pb.AdResponse{
-		Ads: allAds,
+		Ads: ads,
 	}
 	return resp, nil
 }
 
-// getRandomAds returns 2 random ads.
-func getRandomAds() []*pb.Ad {
-	var all []*pb.Ad
-	for _, ads := range adsMap {
-		all = append(all, ads...)
+// GetAd implements AdService.GetAd.
+func (s *adServiceServer) GetAd(ctx context.Context, req *pb.GetAdRequest) (*pb.Ad, error) {
+	_, span := s.tracer.Start(ctx, "GetAd")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("app.ad_id", req.Id))
+	ad := s.ads.ad(req.Id)
+	if ad == nil {
+		return nil, status.Errorf(codes.NotFound, "unknown ad %q", req.Id)
+	}
+	return ad.proto(), nil
+}
+
+// ReportAdEvents implements AdService.ReportAdEvents.
+func (s *adServiceServer) ReportAdEvents(ctx context.Context, req *pb.ReportAdEventsRequest) (*pb.ReportAdEventsResponse, error) {
+	_, span := s.tracer.Start(ctx, "ReportAdEvents")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("app.ad_events", len(req.Events)))
+	rejected := s.stats.record(req.Events)
+	span.SetAttributes(attribute.Int("app.ad_events_rejected", len(rejected)))
+	if len(rejected) > 0 {
+		log.Printf("rejected %d of %d ad events, the first about ad %q", len(rejected), len(req.Events), req.Events[rejected[0]].AdId)
+	}
+	return &pb.ReportAdEventsResponse{Rejected: rejected}, nil
+}
+
+// GetAdStats implements AdService.GetAdStats.
+func (s *adServiceServer) GetAdStats(ctx context.Context, req *pb.GetAdStatsRequest) (*pb.GetAdStatsResponse, error) {
+	_, span := s.tracer.Start(ctx, "GetAdStats")
+	defer span.End()
+
+	stats, err := s.stats.stats(req.AdIds)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &pb.GetAdStatsResponse{Stats: stats}, nil
+}
+
+// mustLoadAds reads the ads file named by ADS_FILE, or the bundled one.
+func mustLoadAds() []adConfig {
+	path := os.Getenv("ADS_FILE")
+	if path == "" {
+		path = defaultAdsFile
 	}
-	rand.Seed(time.Now().UnixNano())
-	res := []*pb.Ad{}
-	for i := 0; i < 2; i++ {
-		idx := rand.Intn(len(all))
-		res = append(res, all[idx])
+	ads, err := loadAds(path)
+	if err != nil {
+		log.Fatal(err)
 	}
-	return res
+	log.Printf("loaded %d ads from %q", len(ads), path)
+	return ads
 }
 
 func main() {
@@ -108,14 +142,25 @@ func main() {
 		log.Fatalf("failed to listen on %s: %v", addr, err)
 	}
 
+	// Inject the faults of CHAOS_RULES_FILE, if set.
+	chaosInjector, err := chaos.FromEnv(nil)
+	if err != nil {
+		log.Fatalf("failed to load chaos rules: %v", err)
+	}
+	defer chaosInjector.Close()
+
 	// Add OpenTelemetry StatsHandler to the gRPC server.
-	grpcServer := grpc.NewServer(
+	grpcServer := grpc.NewServer(append([]grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+	}, chaosInjector.ServerOptions()...)...)
 
 	// 3) Register AdService server.
+	ads := mustLoadAds()
+	stats := newAdStats(ads)
 	pb.RegisterAdServiceServer(grpcServer, &adServiceServer{
 		tracer: otel.Tracer("adservice"),
+		ads:    newAdSelector(ads, stats),
+		stats:  stats,
 	})
 
 	// 4) Register health check service.
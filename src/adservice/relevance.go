@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Targeting keywords matching a context keyword only fuzzily count for less.
+const (
+	exactMatch = 1
+	fuzzyMatch = 0.5
+	// minPrefixLen and minTypoLen are the lengths of the shortest words
+	// matched by prefix and with a typo, as shorter words are too often a
+	// letter away from an unrelated one.
+	minPrefixLen = 4
+	minTypoLen   = 5
+)
+
+// stopWords are left out of keywords.
+var stopWords = map[string]bool{
+	"and": true, "are": true, "but": true, "can": true, "for": true, "from": true,
+	"has": true, "have": true, "its": true, "made": true, "not": true, "our": true,
+	"that": true, "the": true, "this": true, "with": true, "you": true, "your": true,
+	"will": true, "was": true, "any": true, "all": true, "into": true, "more": true,
+}
+
+// keywords extracts the keywords of texts, such as the name and description
+// of a product or a search query: their lowercase words, without stop words
+// nor words shorter than three letters, and reduced to their singular form.
+// Each keyword is only returned once.
+func keywords(texts ...string) []string {
+	var words []string
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len(w) < 3 || stopWords[w] {
+				continue
+			}
+			w = singular(w)
+			if !seen[w] {
+				seen[w] = true
+				words = append(words, w)
+			}
+		}
+	}
+	return words
+}
+
+// singular returns the singular form of a regular English plural.
+func singular(w string) string {
+	switch {
+	case len(w) <= 3:
+		return w
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"), strings.HasSuffix(w, "shes"),
+		strings.HasSuffix(w, "ches"), strings.HasSuffix(w, "xes"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"), strings.HasSuffix(w, "us"):
+		return w
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// match returns how well keywords a and b match: exactMatch if they are the
+// same, fuzzyMatch if one starts with the other or they are a typo apart past
+// their first letter, and 0 otherwise.
+func match(a, b string) float64 {
+	if a == b {
+		return exactMatch
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	switch {
+	case len(a) >= minPrefixLen && strings.HasPrefix(b, a):
+		return fuzzyMatch
+	case len(a) >= minTypoLen && a[0] == b[0] && typo(a, b):
+		return fuzzyMatch
+	}
+	return 0
+}
+
+// typo reports whether b differs from a by one inserted, deleted or replaced
+// letter, or by two adjacent letters swapped. a is not longer than b.
+func typo(a, b string) bool {
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) < len(b) {
+		return a[i:] == b[i+1:]
+	}
+	if i+1 < len(a) && a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:] {
+		return true
+	}
+	return a[i+1:] == b[i+1:]
+}
+
+// target is a keyword an ad is targeted at.
+type target struct {
+	keyword string
+	weight  float64
+}
+
+// Categories weigh more than keywords, as they are picked for the ad.
+const (
+	categoryWeight = 2
+	keywordWeight  = 1
+)
+
+// targets returns the keywords of the categories and keywords of ad.
+func (ad *adConfig) targets() []target {
+	var targets []target
+	seen := make(map[string]bool)
+	add := func(weight float64, texts []string) {
+		for _, k := range keywords(texts...) {
+			if !seen[k] {
+				seen[k] = true
+				targets = append(targets, target{k, weight})
+			}
+		}
+	}
+	add(categoryWeight, ad.Categories)
+	add(keywordWeight, ad.Keywords)
+	return targets
+}
+
+// relevance scores how relevant an ad targeted at targets is to the keywords
+// of the context: the weights of its targets, each as much as it matches its
+// best context keyword. It is 0 if none of them matches.
+func relevance(targets []target, context []string) float64 {
+	var score float64
+	for _, t := range targets {
+		var best float64
+		for _, k := range context {
+			if m := match(t.keyword, k); m > best {
+				best = m
+			}
+		}
+		score += t.weight * best
+	}
+	return score
+}
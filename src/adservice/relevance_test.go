@@ -0,0 +1,99 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestKeywords(t *testing.T) {
+	got := keywords("Salt & Pepper Shakers", "Add some flavor to your kitchen.", "glasses, accessories and dress", "kitchen")
+	want := []string{"salt", "pepper", "shaker", "add", "some", "flavor", "kitchen", "glass", "accessory", "dress"}
+	if !slices.Equal(got, want) {
+		t.Errorf("keywords() = %v, want %v", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want float64
+	}{
+		{"mug", "mug", exactMatch},
+		{"hair", "hairdryer", fuzzyMatch},
+		{"hairdryer", "hair", fuzzyMatch},
+		{"watch", "wacth", fuzzyMatch},
+		{"loafer", "lofer", fuzzyMatch},
+		{"candle", "candel", fuzzyMatch},
+		{"bamboo", "bambou", fuzzyMatch},
+		{"mug", "mud", 0},
+		{"top", "tops", 0},
+		{"watch", "match", 0},
+		{"tank", "talk", 0},
+		{"kitchen", "chicken", 0},
+	} {
+		if got := match(tc.a, tc.b); got != tc.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestRelevance(t *testing.T) {
+	ad := adConfig{Categories: []string{"kitchen"}, Keywords: []string{"glass jar", "bamboo"}}
+	targets := ad.targets()
+	for _, tc := range []struct {
+		context []string
+		want    float64
+	}{
+		{nil, 0},
+		{[]string{"footwear"}, 0},
+		{[]string{"kitchen"}, categoryWeight},
+		{[]string{"Bamboo Glass Jar", "This bamboo glass jar is perfect for any kitchen."}, categoryWeight + 3*keywordWeight},
+		{[]string{"glasses"}, keywordWeight},
+		{[]string{"kitchenware", "jars"}, categoryWeight*fuzzyMatch + keywordWeight},
+	} {
+		if got := relevance(targets, keywords(tc.context...)); got != tc.want {
+			t.Errorf("relevance(%v) = %v, want %v", tc.context, got, tc.want)
+		}
+	}
+}
+
+func TestChooseRelevant(t *testing.T) {
+	ads, err := loadAds(defaultAdsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := newAdStats(ads)
+	stats.now = func() time.Time { return *date("2024-05-31T12:00:00Z") }
+	s := newAdSelector(ads, stats)
+	// Always draw the ad at the middle of the weights.
+	s.rand = func() float64 { return 0.5 }
+
+	for _, tc := range []struct {
+		context []string
+		want    string
+	}{
+		// The description of the product page.
+		{[]string{"This gold-tone stainless steel watch will work with most of your outfits."}, "watch-bogo"},
+		// A misspelt search query.
+		{[]string{"hairdyer"}, "hairdryer-50-off"},
+		{[]string{"candel holders"}, "candle-holder-30-off"},
+		// The categories of the products in the cart.
+		{[]string{"footwear"}, "loafers-bogo"},
+	} {
+		if got := ids(s.choose("", tc.context, 1)); len(got) != 1 || got[0] != tc.want {
+			t.Errorf("choose(%v) = %v, want %s", tc.context, got, tc.want)
+		}
+	}
+
+	// Both kitchen ads are relevant to kitchen products, but the jar is
+	// more relevant to a jar.
+	counts := make(map[string]int)
+	s.rand = newAdSelector(nil, nil).rand
+	for i := 0; i < 1000; i++ {
+		counts[s.choose("", []string{"kitchen", "Bamboo Glass Jar"}, 1)[0].ID]++
+	}
+	if counts["bamboo-jar-10-off"] <= counts["mug-buy-two"] || counts["bamboo-jar-10-off"]+counts["mug-buy-two"] != 1000 {
+		t.Errorf("ads chosen for a jar: %v", counts)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// adStats counts the impressions and clicks of each ad reported since the
+// service started, and the impressions of the current day of each ad and of
+// each session, which the caps of the ads count. It is safe for concurrent
+// use.
+type adStats struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	counts map[string]*adCounts
+	// day is the UTC date the impressions of the day are counted for.
+	day         string
+	impressions map[string]int
+	sessions    map[string]map[string]int
+}
+
+type adCounts struct {
+	impressions, clicks int64
+}
+
+func newAdStats(ads []adConfig) *adStats {
+	s := &adStats{counts: make(map[string]*adCounts, len(ads)), now: time.Now}
+	for _, ad := range ads {
+		s.counts[ad.ID] = &adCounts{}
+	}
+	return s
+}
+
+// record counts the valid events, and returns the indexes of the others: those
+// about an unknown ad or without a type.
+func (s *adStats) record(events []*pb.AdEvent) (rejected []int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startDay(s.now())
+	for i, e := range events {
+		c, ok := s.counts[e.AdId]
+		if !ok || e.Type != pb.AdEventType_AD_IMPRESSION && e.Type != pb.AdEventType_AD_CLICK {
+			rejected = append(rejected, int32(i))
+			continue
+		}
+		if e.Type != pb.AdEventType_AD_IMPRESSION {
+			c.clicks++
+			continue
+		}
+		c.impressions++
+		s.impressions[e.AdId]++
+		if e.SessionId != "" {
+			if s.sessions[e.SessionId] == nil {
+				s.sessions[e.SessionId] = make(map[string]int)
+			}
+			s.sessions[e.SessionId][e.AdId]++
+		}
+	}
+	return rejected
+}
+
+// today returns the impressions of each ad on the day of now, and those shown
+// to session.
+func (s *adStats) today(session string, now time.Time) (impressions, sessionImpressions map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startDay(now)
+	return maps.Clone(s.impressions), maps.Clone(s.sessions[session])
+}
+
+// startDay starts counting the impressions of the day of now over, if it is
+// not the day they are counted for. s.mu must be held.
+func (s *adStats) startDay(now time.Time) {
+	if day := now.UTC().Format(time.DateOnly); day != s.day {
+		s.day = day
+		s.impressions = make(map[string]int)
+		s.sessions = make(map[string]map[string]int)
+	}
+}
+
+// stats returns the stats of the ads of ids, or of all ads if ids is empty,
+// sorted by ad ID.
+func (s *adStats) stats(ids []string) ([]*pb.AdStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(ids) == 0 {
+		for id := range s.counts {
+			ids = append(ids, id)
+		}
+	}
+	stats := make([]*pb.AdStats, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		c, ok := s.counts[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown ad %q", id)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		st := &pb.AdStats{AdId: id, Impressions: c.impressions, Clicks: c.clicks}
+		if c.impressions > 0 {
+			st.ClickThroughRate = float64(c.clicks) / float64(c.impressions)
+		}
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].AdId < stats[j].AdId })
+	return stats, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func event(id string, t pb.AdEventType) *pb.AdEvent {
+	return &pb.AdEvent{AdId: id, Type: t}
+}
+
+func TestAdStats(t *testing.T) {
+	ads := []adConfig{{ID: "mug"}, {ID: "jar"}, {ID: "watch"}}
+	stats := newAdStats(ads)
+	svc := &adServiceServer{tracer: otel.Tracer("test"), ads: newAdSelector(ads, stats), stats: stats}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			events := []*pb.AdEvent{
+				event("mug", pb.AdEventType_AD_IMPRESSION),
+				event("mug", pb.AdEventType_AD_IMPRESSION),
+				event("jar", pb.AdEventType_AD_IMPRESSION),
+			}
+			if _, err := svc.ReportAdEvents(ctx, &pb.ReportAdEventsRequest{Events: events}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	events := []*pb.AdEvent{event("mug", pb.AdEventType_AD_CLICK), event("jar", pb.AdEventType_AD_CLICK)}
+	if _, err := svc.ReportAdEvents(ctx, &pb.ReportAdEventsRequest{Events: events}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid events are rejected, and the others of their batch counted.
+	events = []*pb.AdEvent{
+		event("lamp", pb.AdEventType_AD_IMPRESSION),
+		event("jar", pb.AdEventType_AD_IMPRESSION),
+		event("mug", pb.AdEventType_AD_EVENT_TYPE_UNSPECIFIED),
+		event("jar", pb.AdEventType_AD_IMPRESSION),
+	}
+	reported, err := svc.ReportAdEvents(ctx, &pb.ReportAdEventsRequest{Events: events})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(reported.Rejected, []int32{0, 2}) {
+		t.Errorf("ReportAdEvents() rejected %v, want [0 2]", reported.Rejected)
+	}
+
+	resp, err := svc.GetAdStats(ctx, &pb.GetAdStatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		id                  string
+		impressions, clicks int64
+		ctr                 float64
+	}{
+		{"jar", 6, 1, 1.0 / 6},
+		{"mug", 8, 1, 0.125},
+		{"watch", 0, 0, 0},
+	}
+	if len(resp.Stats) != len(want) {
+		t.Fatalf("GetAdStats() = %v, want %d ads", resp.Stats, len(want))
+	}
+	for i, w := range want {
+		s := resp.Stats[i]
+		if s.AdId != w.id || s.Impressions != w.impressions || s.Clicks != w.clicks || s.ClickThroughRate != w.ctr {
+			t.Errorf("stats %d = %v, want %+v", i, s, w)
+		}
+	}
+
+	resp, err = svc.GetAdStats(ctx, &pb.GetAdStatsRequest{AdIds: []string{"mug", "jar", "mug"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Stats) != 2 || resp.Stats[0].AdId != "jar" || resp.Stats[1].AdId != "mug" {
+		t.Errorf("GetAdStats(mug, jar, mug) = %v", resp.Stats)
+	}
+	if _, err := svc.GetAdStats(ctx, &pb.GetAdStatsRequest{AdIds: []string{"lamp"}}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetAdStats(lamp) = %v, want NotFound", err)
+	}
+}
@@ -10,7 +10,7 @@ import (
 type ICartStore interface {
 	Initialize(ctx context.Context) error
 
-	AddItem(ctx context.Context, userID, productID string, quantity int32) error
+	AddItem(ctx context.Context, userID, productID, variantSKU string, quantity int32) error
 	EmptyCart(ctx context.Context, userID string) error
 	GetCart(ctx context.Context, userID string) (*pb.Cart, error)
 
@@ -32,8 +32,8 @@ func (l *LocalCartStore) Initialize(ctx context.Context) error {
 }
 
 // AddItem adds a product to the user's cart.
-func (l *LocalCartStore) AddItem(ctx context.Context, userID, productID string, quantity int32) error {
-	fmt.Printf("LocalCartStore: AddItem called (userID=%s, productID=%s, quantity=%d)\n", userID, productID, quantity)
+func (l *LocalCartStore) AddItem(ctx context.Context, userID, productID, variantSKU string, quantity int32) error {
+	fmt.Printf("LocalCartStore: AddItem called (userID=%s, productID=%s, variantSKU=%s, quantity=%d)\n", userID, productID, variantSKU, quantity)
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -49,7 +49,7 @@ func (l *LocalCartStore) AddItem(ctx context.Context, userID, productID string,
 	// Look for an existing item.
 	found := false
 	for _, item := range cart.Items {
-		if item.ProductId == productID {
+		if item.ProductId == productID && item.VariantSku == variantSKU {
 			item.Quantity += quantity
 			found = true
 			break
@@ -57,8 +57,9 @@ func (l *LocalCartStore) AddItem(ctx context.Context, userID, productID string,
 	}
 	if !found {
 		cart.Items = append(cart.Items, &pb.CartItem{
-			ProductId: productID,
-			Quantity:  quantity,
+			ProductId:  productID,
+			Quantity:   quantity,
+			VariantSku: variantSKU,
 		})
 	}
 
@@ -93,8 +93,8 @@ func (r *RedisCartStore) Initialize(ctx context.Context) error {
 }
 
 // AddItem stores cart information in a Redis Hash for each user.
-func (r *RedisCartStore) AddItem(ctx context.Context, userID, productID string, quantity int32) error {
-	log.Printf("RedisCartStore: AddItem called (userID=%s, productID=%s, quantity=%d)\n", userID, productID, quantity)
+func (r *RedisCartStore) AddItem(ctx context.Context, userID, productID, variantSKU string, quantity int32) error {
+	log.Printf("RedisCartStore: AddItem called (userID=%s, productID=%s, variantSKU=%s, quantity=%d)\n", userID, productID, variantSKU, quantity)
 
 	// Use the user ID as the Hash key and store the Cart binary in the "cart" field.
 	key := userID
@@ -112,8 +112,9 @@ func (r *RedisCartStore) AddItem(ctx context.Context, userID, productID string,
 		cart.UserId = userID
 		cart.Items = []*pb.CartItem{
 			{
-				ProductId: productID,
-				Quantity:  quantity,
+				ProductId:  productID,
+				Quantity:   quantity,
+				VariantSku: variantSKU,
 			},
 		}
 	} else {
@@ -121,10 +122,10 @@ func (r *RedisCartStore) AddItem(ctx context.Context, userID, productID string,
 		if parseErr := proto.Unmarshal([]byte(val), &cart); parseErr != nil {
 			return status.Errorf(codes.FailedPrecondition, "failed to parse cart data: %v", parseErr)
 		}
-		// If the same productID exists, add to the quantity; otherwise, append.
+		// If the same product and variant exist, add to the quantity; otherwise, append.
 		found := false
 		for _, item := range cart.Items {
-			if item.ProductId == productID {
+			if item.ProductId == productID && item.VariantSku == variantSKU {
 				item.Quantity += quantity
 				found = true
 				break
@@ -132,8 +133,9 @@ func (r *RedisCartStore) AddItem(ctx context.Context, userID, productID string,
 		}
 		if !found {
 			cart.Items = append(cart.Items, &pb.CartItem{
-				ProductId: productID,
-				Quantity:  quantity,
+				ProductId:  productID,
+				Quantity:   quantity,
+				VariantSku: variantSKU,
 			})
 		}
 	}
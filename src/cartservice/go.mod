@@ -5,6 +5,7 @@ go 1.24.1
 require (
 	github.com/go-redis/redis/extra/redisotel/v8 v8.11.5
 	github.com/go-redis/redis/v8 v8.11.5
+	github.com/norun9/microservices-demo-ambient/chaos v0.0.0-00010101000000-000000000000
 	github.com/norun9/microservices-demo-ambient/genproto v0.0.0-00010101000000-000000000000
 	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.46.1
 	go.opentelemetry.io/otel v1.35.0
@@ -33,6 +34,9 @@ require (
 	golang.org/x/text v0.26.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20250324211829-b45e905df463 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250603155806-513f23925822 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/norun9/microservices-demo-ambient/genproto => ../../genproto
+
+replace github.com/norun9/microservices-demo-ambient/chaos => ../../chaos
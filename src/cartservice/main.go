@@ -10,6 +10,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/norun9/microservices-demo-ambient/chaos"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 	"github.com/norun9/microservices-demo-ambient/src/cartservice/cartstore"
 	"github.com/norun9/microservices-demo-ambient/src/cartservice/services"
@@ -87,10 +88,17 @@ func main() {
 	}
 	log.Println("Successfully created TCP listener")
 
+	// Inject the faults of CHAOS_RULES_FILE, if set.
+	chaosInjector, err := chaos.FromEnv(nil)
+	if err != nil {
+		log.Fatalf("failed to load chaos rules: %v", err)
+	}
+	defer chaosInjector.Close()
+
 	// Add OTel interceptor to gRPC server.
-	grpcServer := grpc.NewServer(
+	grpcServer := grpc.NewServer(append([]grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+	}, chaosInjector.ServerOptions()...)...)
 	log.Println("Created gRPC server with OpenTelemetry interceptors")
 
 	// Register CartService and HealthCheckService.
@@ -36,10 +36,11 @@ func (s *CartServiceServer) AddItem(ctx context.Context, req *pb.AddItemRequest)
 	span.SetAttributes(
 		attribute.String("app.user_id", req.UserId),
 		attribute.String("app.product_id", req.Item.ProductId),
+		attribute.String("app.variant_sku", req.Item.VariantSku),
 		attribute.Int64("app.quantity", int64(req.Item.Quantity)),
 	)
 
-	if err := s.store.AddItem(ctx, req.UserId, req.Item.ProductId, req.Item.Quantity); err != nil {
+	if err := s.store.AddItem(ctx, req.UserId, req.Item.ProductId, req.Item.VariantSku, req.Item.Quantity); err != nil {
 		return nil, status.Errorf(codes.Internal, "AddItem failed: %v", err)
 	}
 	return &pb.Empty{}, nil
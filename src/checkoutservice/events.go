@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+const (
+	// orderEventQueueSize is the number of order events waiting to be
+	// published at most. Events are dropped when the queue is full.
+	orderEventQueueSize = 100
+	// orderEventTimeout bounds the time spent publishing an event.
+	orderEventTimeout = 2 * time.Second
+)
+
+// orderPublisher publishes the orders placed to the recommendation service in
+// the background, so that a slow or unavailable subscriber never holds back
+// checkout. A nil orderPublisher publishes nothing.
+type orderPublisher struct {
+	client pb.RecommendationServiceClient
+	events chan orderEvent
+}
+
+type orderEvent struct {
+	// ctx carries the trace of the order, without its deadline.
+	ctx   context.Context
+	event *pb.OrderEvent
+}
+
+// newOrderPublisher returns a publisher to the recommendation service at
+// addr, or nil if addr is empty.
+func newOrderPublisher(addr string) (*orderPublisher, error) {
+	if addr == "" {
+		return nil, nil
+	}
+	conn, err := createClient(addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect recommendation service: %+v", err)
+	}
+	p := &orderPublisher{
+		client: pb.NewRecommendationServiceClient(conn),
+		events: make(chan orderEvent, orderEventQueueSize),
+	}
+	go p.run()
+	return p, nil
+}
+
+// publish queues an event for the order of items by userID.
+func (p *orderPublisher) publish(ctx context.Context, orderID, userID string, items []*pb.OrderItem) {
+	if p == nil {
+		return
+	}
+	event := &pb.OrderEvent{OrderId: orderID, UserId: userID}
+	seen := make(map[string]bool)
+	for _, it := range items {
+		if id := it.GetItem().GetProductId(); !seen[id] {
+			seen[id] = true
+			event.ProductIds = append(event.ProductIds, id)
+		}
+	}
+	select {
+	case p.events <- orderEvent{context.WithoutCancel(ctx), event}:
+	default:
+		log.Warnf("dropped the event of order %s: the queue is full", orderID)
+	}
+}
+
+func (p *orderPublisher) run() {
+	for e := range p.events {
+		ctx, cancel := context.WithTimeout(e.ctx, orderEventTimeout)
+		if _, err := p.client.RecordOrder(ctx, e.event); err != nil {
+			log.Warnf("failed to publish the event of order %s: %+v", e.event.OrderId, err)
+		}
+		cancel()
+	}
+}
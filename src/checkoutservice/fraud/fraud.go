@@ -0,0 +1,352 @@
+// Package fraud scores orders for fraud risk before the card is charged.
+//
+// A Scorer keeps a short in-memory history of recent orders and adds up
+// points for each rule an order trips: too many orders placed by the same user
+// or with the same card within a window, an amount that is an outlier, and a shipping country
+// that does not match the card issuer or the chosen currency. The total is
+// compared against the review and reject thresholds from the rules file.
+package fraud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome is the decision for a scored order.
+type Outcome int
+
+const (
+	Allow Outcome = iota
+	Review
+	Reject
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Review:
+		return "REVIEW"
+	case Reject:
+		return "REJECT"
+	default:
+		return "ALLOW"
+	}
+}
+
+// Duration is a time.Duration that reads from JSON strings like "10m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Rules configures the scorer. Scores are additive points; an order whose
+// total reaches ReviewScore is held for review and one that reaches
+// RejectScore is rejected.
+type Rules struct {
+	ReviewScore int `json:"review_score"`
+	RejectScore int `json:"reject_score"`
+
+	// Velocity limits the orders placed, as recorded by Placed, within
+	// Window, not counting the order scored.
+	Velocity struct {
+		Window           Duration `json:"window"`
+		MaxOrdersPerUser int      `json:"max_orders_per_user"`
+		MaxOrdersPerCard int      `json:"max_orders_per_card"`
+		Score            int      `json:"score"`
+	} `json:"velocity"`
+
+	Amount struct {
+		// MaxUSD is a hard ceiling; orders above it always score.
+		MaxUSD float64 `json:"max_usd"`
+		Score  int     `json:"score"`
+		// Orders more than ZScore standard deviations above the mean of
+		// previously seen orders score ZScoreScore, once MinSamples orders
+		// have been seen.
+		ZScore      float64 `json:"z_score"`
+		MinSamples  int     `json:"min_samples"`
+		ZScoreScore int     `json:"z_score_score"`
+	} `json:"amount"`
+
+	Country struct {
+		// BINCountries maps card number prefixes to the issuing country.
+		BINCountries map[string]string `json:"bin_countries"`
+		// CurrencyCountries lists the countries expected for a currency.
+		CurrencyCountries map[string][]string `json:"currency_countries"`
+		Score             int                 `json:"score"`
+	} `json:"country"`
+}
+
+// DefaultRules returns the rules used when no rules file is configured.
+func DefaultRules() Rules {
+	var r Rules
+	r.ReviewScore = 50
+	r.RejectScore = 80
+	r.Velocity.Window = Duration(10 * time.Minute)
+	r.Velocity.MaxOrdersPerUser = 3
+	r.Velocity.MaxOrdersPerCard = 3
+	r.Velocity.Score = 40
+	r.Amount.MaxUSD = 5000
+	r.Amount.Score = 30
+	r.Amount.ZScore = 3
+	r.Amount.MinSamples = 20
+	r.Amount.ZScoreScore = 20
+	r.Country.Score = 30
+	return r
+}
+
+// LoadRules reads rules from a JSON file. Fields missing from the file keep
+// their DefaultRules values.
+func LoadRules(path string) (Rules, error) {
+	r := DefaultRules()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return r, fmt.Errorf("failed to read fraud rules: %w", err)
+	}
+	if err := json.Unmarshal(b, &r); err != nil {
+		return r, fmt.Errorf("failed to parse fraud rules: %w", err)
+	}
+	if r.ReviewScore <= 0 || r.RejectScore < r.ReviewScore {
+		return r, fmt.Errorf("invalid fraud thresholds: review_score=%d reject_score=%d", r.ReviewScore, r.RejectScore)
+	}
+	return r, nil
+}
+
+// Order is the information about an order that the rules look at.
+type Order struct {
+	UserID string
+	// CardKey identifies the card across orders without being the card
+	// number itself; see CardKey.
+	CardKey string
+	// CardBIN is the leading digits of the card number, if known.
+	CardBIN   string
+	AmountUSD float64
+	Country   string
+	Currency  string
+}
+
+// Result is the outcome of scoring an order.
+type Result struct {
+	Outcome Outcome
+	Score   int
+	Reasons []string
+}
+
+// CardKey returns a stable, non-reversible key for a card number, or "" if
+// the number is empty.
+func CardKey(cardNumber string) string {
+	if cardNumber == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(digits(cardNumber)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// CardBIN returns the first six digits of a card number.
+func CardBIN(cardNumber string) string {
+	d := digits(cardNumber)
+	if len(d) < 6 {
+		return d
+	}
+	return d[:6]
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Scorer scores orders against a set of rules. It is safe for concurrent
+// use.
+type Scorer struct {
+	rules Rules
+	now   func() time.Time
+
+	mu    sync.Mutex
+	users map[string][]time.Time
+	cards map[string][]time.Time
+	// sweptAt is when idle keys were last dropped from users and cards.
+	sweptAt time.Time
+
+	// Running amount statistics.
+	n    int
+	mean float64
+	m2   float64
+}
+
+// NewScorer returns a scorer for the given rules.
+func NewScorer(rules Rules) *Scorer {
+	return &Scorer{
+		rules: rules,
+		now:   time.Now,
+		users: make(map[string][]time.Time),
+		cards: make(map[string][]time.Time),
+	}
+}
+
+// Score returns the risk assessment of the order. Its velocity counts the
+// order along with the orders recorded by Placed within the window, so that
+// orders rejected or failing later do not count against the next attempts.
+func (s *Scorer) Score(o Order) Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res Result
+	add := func(points int, reason string, args ...interface{}) {
+		res.Score += points
+		res.Reasons = append(res.Reasons, fmt.Sprintf(reason, args...))
+	}
+
+	now := s.now()
+	if o.UserID != "" {
+		n := s.count(s.users, o.UserID, now) + 1
+		if limit := s.rules.Velocity.MaxOrdersPerUser; limit > 0 && n > limit {
+			add(s.rules.Velocity.Score, "%d orders from user within %s", n, time.Duration(s.rules.Velocity.Window))
+		}
+	}
+	if o.CardKey != "" {
+		n := s.count(s.cards, o.CardKey, now) + 1
+		if limit := s.rules.Velocity.MaxOrdersPerCard; limit > 0 && n > limit {
+			add(s.rules.Velocity.Score, "%d orders with card within %s", n, time.Duration(s.rules.Velocity.Window))
+		}
+	}
+
+	if limit := s.rules.Amount.MaxUSD; limit > 0 && o.AmountUSD > limit {
+		add(s.rules.Amount.Score, "amount %.2f USD above limit %.2f USD", o.AmountUSD, limit)
+	}
+	if s.rules.Amount.ZScore > 0 && s.rules.Amount.MinSamples > 0 && s.n >= s.rules.Amount.MinSamples {
+		if sd := math.Sqrt(s.m2 / float64(s.n-1)); sd > 0 {
+			if z := (o.AmountUSD - s.mean) / sd; z > s.rules.Amount.ZScore {
+				add(s.rules.Amount.ZScoreScore, "amount %.2f USD is %.1f standard deviations above mean", o.AmountUSD, z)
+			}
+		}
+	}
+	s.observe(o.AmountUSD)
+
+	if country := normalizeCountry(o.Country); country != "" {
+		if issuer, ok := s.issuerCountry(o.CardBIN); ok && issuer != country {
+			add(s.rules.Country.Score, "card issued in %q but shipping to %q", issuer, o.Country)
+		}
+		if expected, ok := s.rules.Country.CurrencyCountries[o.Currency]; ok && !containsCountry(expected, country) {
+			add(s.rules.Country.Score, "currency %s unexpected for shipping country %q", o.Currency, o.Country)
+		}
+	}
+
+	switch {
+	case res.Score >= s.rules.RejectScore:
+		res.Outcome = Reject
+	case res.Score >= s.rules.ReviewScore:
+		res.Outcome = Review
+	}
+	return res
+}
+
+// Placed records that the order, scored before, was placed, so that it counts
+// towards the velocity of the next orders of its user and card.
+func (s *Scorer) Placed(o Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	// Orders only count within the velocity window, so dropping idle keys
+	// once per window keeps at most two windows of history.
+	if window := time.Duration(s.rules.Velocity.Window); !now.Before(s.sweptAt.Add(window)) {
+		s.sweep(now)
+		s.sweptAt = now
+	}
+	if o.UserID != "" {
+		s.count(s.users, o.UserID, now)
+		s.users[o.UserID] = append(s.users[o.UserID], now)
+	}
+	if o.CardKey != "" {
+		s.count(s.cards, o.CardKey, now)
+		s.cards[o.CardKey] = append(s.cards[o.CardKey], now)
+	}
+}
+
+// count drops the entries of the history for key that fell out of the
+// velocity window at t, and returns the number of entries left.
+func (s *Scorer) count(history map[string][]time.Time, key string, t time.Time) int {
+	ts, ok := history[key]
+	if !ok {
+		return 0
+	}
+	cutoff := t.Add(-time.Duration(s.rules.Velocity.Window))
+	kept := ts[:0]
+	for _, x := range ts {
+		if x.After(cutoff) {
+			kept = append(kept, x)
+		}
+	}
+	if len(kept) == 0 {
+		delete(history, key)
+		return 0
+	}
+	history[key] = kept
+	return len(kept)
+}
+
+// sweep drops keys whose most recent order fell out of the velocity window.
+func (s *Scorer) sweep(now time.Time) {
+	cutoff := now.Add(-time.Duration(s.rules.Velocity.Window))
+	for _, history := range []map[string][]time.Time{s.users, s.cards} {
+		for key, ts := range history {
+			if len(ts) == 0 || !ts[len(ts)-1].After(cutoff) {
+				delete(history, key)
+			}
+		}
+	}
+}
+
+// observe folds an amount into the running mean and variance (Welford).
+func (s *Scorer) observe(amount float64) {
+	s.n++
+	delta := amount - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (amount - s.mean)
+}
+
+// issuerCountry looks up the issuing country for the longest matching BIN
+// prefix.
+func (s *Scorer) issuerCountry(bin string) (string, bool) {
+	var best, country string
+	for prefix, c := range s.rules.Country.BINCountries {
+		if strings.HasPrefix(bin, prefix) && len(prefix) > len(best) {
+			best, country = prefix, c
+		}
+	}
+	return normalizeCountry(country), best != ""
+}
+
+func normalizeCountry(c string) string {
+	return strings.ToLower(strings.TrimSpace(c))
+}
+
+func containsCountry(list []string, country string) bool {
+	for _, c := range list {
+		if normalizeCountry(c) == country {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package fraud
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testRules() Rules {
+	r := DefaultRules()
+	r.Country.BINCountries = map[string]string{"4432": "United States", "4": "Canada"}
+	r.Country.CurrencyCountries = map[string][]string{"JPY": {"Japan"}}
+	return r
+}
+
+func newTestScorer(now *time.Time) *Scorer {
+	s := NewScorer(testRules())
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestScoreRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		want  Outcome
+		score int
+	}{
+		{"clean order", Order{UserID: "u1", CardBIN: "443280", AmountUSD: 50, Country: "United States", Currency: "USD"}, Allow, 0},
+		{"issuer mismatch", Order{UserID: "u1", CardBIN: "443280", AmountUSD: 50, Country: "France", Currency: "USD"}, Allow, 30},
+		{"longest bin prefix wins", Order{UserID: "u1", CardBIN: "411111", AmountUSD: 50, Country: " canada ", Currency: "USD"}, Allow, 0},
+		{"currency mismatch", Order{UserID: "u1", CardBIN: "443280", AmountUSD: 50, Country: "United States", Currency: "JPY"}, Allow, 30},
+		{"amount over limit", Order{UserID: "u1", AmountUSD: 6000}, Allow, 30},
+		{"amount and countries", Order{UserID: "u1", CardBIN: "443280", AmountUSD: 6000, Country: "France", Currency: "JPY"}, Reject, 90},
+		{"amount and issuer", Order{UserID: "u1", CardBIN: "443280", AmountUSD: 6000, Country: "France"}, Review, 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			got := newTestScorer(&now).Score(tt.order)
+			if got.Outcome != tt.want || got.Score != tt.score {
+				t.Errorf("Score() = %v/%d (%v), want %v/%d", got.Outcome, got.Score, got.Reasons, tt.want, tt.score)
+			}
+		})
+	}
+}
+
+func TestScoreVelocity(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newTestScorer(&now)
+	card := CardKey("4432-8015-6152-0454")
+
+	// Attempts that are not placed, such as declined cards, do not count.
+	for i := 0; i < 5; i++ {
+		if got := s.Score(Order{UserID: "u1", CardKey: card, AmountUSD: 10}); got.Score != 0 {
+			t.Fatalf("attempt %d: Score() = %d, want 0", i+1, got.Score)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		o := Order{UserID: "u1", CardKey: card, AmountUSD: 10}
+		if got := s.Score(o); got.Outcome != Allow {
+			t.Fatalf("order %d: Score() = %v, want ALLOW", i+1, got.Outcome)
+		}
+		s.Placed(o)
+		now = now.Add(time.Minute)
+	}
+	// Fourth order from the same user with the same card trips both limits.
+	// It is not placed, so retrying it scores the same.
+	for i := 0; i < 2; i++ {
+		if got := s.Score(Order{UserID: "u1", CardKey: card, AmountUSD: 10}); got.Outcome != Reject || got.Score != 80 {
+			t.Errorf("Score() = %v/%d, want REJECT/80", got.Outcome, got.Score)
+		}
+	}
+	// Same card from another session only trips the card limit.
+	if got := s.Score(Order{UserID: "u2", CardKey: card, AmountUSD: 10}); got.Outcome != Allow || got.Score != 40 {
+		t.Errorf("Score() = %v/%d, want ALLOW/40", got.Outcome, got.Score)
+	}
+	// Once the window has passed the history no longer counts.
+	now = now.Add(time.Hour)
+	if got := s.Score(Order{UserID: "u1", CardKey: card, AmountUSD: 10}); got.Score != 0 {
+		t.Errorf("Score() after window = %d, want 0", got.Score)
+	}
+}
+
+func TestScoreSweepsIdleHistory(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newTestScorer(&now)
+	s.Placed(Order{UserID: "u1", CardKey: "c1"})
+	now = now.Add(5 * time.Minute)
+	s.Placed(Order{UserID: "u2", CardKey: "c2"})
+	// Orders scored but not placed leave no history.
+	s.Score(Order{UserID: "u9", CardKey: "c9"})
+	if len(s.users) != 2 || len(s.cards) != 2 {
+		t.Fatalf("history of %d users and %d cards, want 2 of each", len(s.users), len(s.cards))
+	}
+	// u1 is idle for a whole window by the next sweep, but not u2.
+	now = now.Add(6 * time.Minute)
+	s.Placed(Order{UserID: "u3"})
+	if _, ok := s.users["u1"]; ok || len(s.users) != 2 || len(s.cards) != 1 {
+		t.Errorf("history after a window: users %v, cards %v, want u2, u3 and c2", s.users, s.cards)
+	}
+}
+
+func TestScoreAmountOutlier(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newTestScorer(&now)
+	for i := 0; i < 20; i++ {
+		s.Score(Order{AmountUSD: float64(90 + i)})
+	}
+	if got := s.Score(Order{AmountUSD: 105}); got.Score != 0 {
+		t.Errorf("Score(105) = %d (%v), want 0", got.Score, got.Reasons)
+	}
+	if got := s.Score(Order{AmountUSD: 1000}); got.Score != 20 {
+		t.Errorf("Score(1000) = %d (%v), want 20", got.Score, got.Reasons)
+	}
+}
+
+func TestCardKey(t *testing.T) {
+	if CardKey("4432-8015-6152-0454") != CardKey("4432801561520454") {
+		t.Error("CardKey() differs for the same number with and without dashes")
+	}
+	if CardKey("4432801561520454") == CardKey("5555555555554444") {
+		t.Error("CardKey() collides for different numbers")
+	}
+	if got := CardKey(""); got != "" {
+		t.Errorf("CardKey(\"\") = %q, want \"\"", got)
+	}
+	if got := CardBIN("4432-8015-6152-0454"); got != "443280" {
+		t.Errorf("CardBIN() = %q, want %q", got, "443280")
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	r, err := LoadRules(filepath.Join("..", "data", "fraud_rules.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(r.Velocity.Window) != 10*time.Minute {
+		t.Errorf("Velocity.Window = %v, want 10m", time.Duration(r.Velocity.Window))
+	}
+
+	bad := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(bad, []byte(`{"review_score": 90, "reject_score": 10}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRules(bad); err == nil {
+		t.Error("LoadRules() accepted reject_score below review_score")
+	}
+}
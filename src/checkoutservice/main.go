@@ -19,10 +19,13 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -32,6 +35,7 @@ import (
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -39,13 +43,18 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/norun9/microservices-demo-ambient/chaos"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"github.com/norun9/microservices-demo-ambient/redact"
+	"github.com/norun9/microservices-demo-ambient/src/checkoutservice/fraud"
 	money "github.com/norun9/microservices-demo-ambient/src/checkoutservice/money"
 )
 
 const (
 	listenPort  = "5050"
 	usdCurrency = "USD"
+
+	defaultFraudRulesFile = "data/fraud_rules.json"
 )
 
 var log *logrus.Logger
@@ -61,7 +70,7 @@ func init() {
 		},
 		TimestampFormat: time.RFC3339Nano,
 	}
-	log.Out = os.Stdout
+	log.Out = redact.NewWriter(os.Stdout)
 }
 
 func InitTracerProvider() *sdktrace.TracerProvider {
@@ -91,8 +100,8 @@ func InitTracerProvider() *sdktrace.TracerProvider {
 		log.Fatal(err)
 	}
 
-	// 3) Build TracerProvider.
-	bsp := sdktrace.NewBatchSpanProcessor(exporter)
+	// 3) Build TracerProvider. Spans are scrubbed of card data before export.
+	bsp := sdktrace.NewBatchSpanProcessor(redact.NewSpanExporter(exporter))
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Consider TraceIDRatioBased for production.
 		sdktrace.WithResource(res),
@@ -112,6 +121,8 @@ type checkoutService struct {
 	shippingSvcAddr       string
 	emailSvcAddr          string
 	paymentSvcAddr        string
+	fraud                 *fraud.Scorer
+	orders                *orderPublisher
 	tracer                trace.Tracer
 	pb.UnimplementedCheckoutServiceServer
 }
@@ -136,6 +147,13 @@ func main() {
 	mustMapEnv(&svc.currencySvcAddr, "CURRENCY_SERVICE_ADDR")
 	mustMapEnv(&svc.emailSvcAddr, "EMAIL_SERVICE_ADDR")
 	mustMapEnv(&svc.paymentSvcAddr, "PAYMENT_SERVICE_ADDR")
+	svc.fraud = fraud.NewScorer(mustLoadFraudRules())
+	// Orders are published to the recommendation service, if any.
+	orders, err := newOrderPublisher(os.Getenv("RECOMMENDATION_SERVICE_ADDR"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	svc.orders = orders
 	svc.tracer = otel.Tracer("checkoutservice")
 
 	log.Infof("service config: %+v", svc)
@@ -145,9 +163,16 @@ func main() {
 		log.Fatal(err)
 	}
 
-	var srv *grpc.Server = grpc.NewServer(
+	// Inject the faults of CHAOS_RULES_FILE, if set.
+	chaosInjector, err := chaos.FromEnv(log.Infof)
+	if err != nil {
+		log.Fatalf("failed to load chaos rules: %v", err)
+	}
+	defer chaosInjector.Close()
+
+	var srv *grpc.Server = grpc.NewServer(append([]grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+	}, chaosInjector.ServerOptions()...)...)
 	pb.RegisterCheckoutServiceServer(srv, svc)
 	healthSvc := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(srv, healthSvc)
@@ -165,6 +190,27 @@ func mustMapEnv(target *string, envKey string) {
 	*target = v
 }
 
+// mustLoadFraudRules reads the rules file named by FRAUD_RULES_FILE. Without
+// the variable the bundled rules are used, falling back to the defaults if
+// they cannot be read.
+func mustLoadFraudRules() fraud.Rules {
+	path := os.Getenv("FRAUD_RULES_FILE")
+	if path == "" {
+		rules, err := fraud.LoadRules(defaultFraudRulesFile)
+		if err != nil {
+			log.Warnf("using default fraud rules: %v", err)
+			return fraud.DefaultRules()
+		}
+		return rules
+	}
+	rules, err := fraud.LoadRules(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Infof("loaded fraud rules from %q", path)
+	return rules
+}
+
 func (cs *checkoutService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
 	ctx, span := cs.tracer.Start(ctx, "PlaceOrder")
 	defer span.End()
@@ -176,8 +222,15 @@ func (cs *checkoutService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderReq
 		return nil, status.Errorf(codes.Internal, "failed to generate order uuid")
 	}
 
-	prep, err := cs.prepareOrderItemsAndShippingQuoteFromCart(ctx, req.UserId, req.UserCurrency, req.Address)
+	address, err := cs.validateAddress(ctx, req.Address)
 	if err != nil {
+		return nil, err
+	}
+
+	prep, err := cs.prepareOrderItemsAndShippingQuoteFromCart(ctx, req.UserId, req.UserCurrency, address, req.ShippingMethod)
+	if status.Code(err) == codes.InvalidArgument {
+		return nil, err
+	} else if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
@@ -190,25 +243,33 @@ func (cs *checkoutService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderReq
 		total = money.Must(money.Sum(total, multPrice))
 	}
 
-	txID, err := cs.chargeCard(ctx, total, req.CreditCard)
+	screened, err := cs.screenOrder(ctx, req, address, total)
+	if err != nil {
+		return nil, err
+	}
+
+	txID, err := cs.chargeCard(ctx, total, req.GetPaymentToken(), req.GetCreditCard())
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to charge card: %+v", err)
 	}
 	log.Infof("payment went through (transaction_id: %s)", txID)
 
-	shippingTrackingID, err := cs.shipOrder(ctx, req.Address, prep.cartItems)
+	shipments, err := cs.shipOrder(ctx, address, prep.cartItems, prep.shippingMethod)
 	if err != nil {
 		return nil, status.Errorf(codes.Unavailable, "shipping error: %+v", err)
 	}
+	cs.fraud.Placed(screened)
 
 	_ = cs.emptyUserCart(ctx, req.UserId)
 
 	orderResult := &pb.OrderResult{
 		OrderId:            orderID.String(),
-		ShippingTrackingId: shippingTrackingID,
+		ShippingTrackingId: shipments[0].GetTrackingId(),
 		ShippingCost:       prep.shippingCostLocalized,
-		ShippingAddress:    req.Address,
+		ShippingAddress:    address,
 		Items:              prep.orderItems,
+		ShippingMethod:     prep.shippingMethod,
+		Shipments:          shipments,
 	}
 
 	if err := cs.sendOrderConfirmation(ctx, req.Email, orderResult); err != nil {
@@ -216,17 +277,137 @@ func (cs *checkoutService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderReq
 	} else {
 		log.Infof("order confirmation email sent to %q", req.Email)
 	}
+	cs.orders.publish(ctx, orderResult.OrderId, req.UserId, orderResult.Items)
 	resp := &pb.PlaceOrderResponse{Order: orderResult}
 	return resp, nil
 }
 
+// validateAddress asks the shipping service to check the address and returns
+// it in normalized form. An invalid address is an InvalidArgument error with
+// a BadRequest detail listing the problems.
+func (cs *checkoutService) validateAddress(ctx context.Context, address *pb.Address) (*pb.Address, error) {
+	conn, err := createClient(cs.shippingSvcAddr)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not connect shipping service: %+v", err)
+	}
+	defer conn.Close()
+
+	resp, err := pb.NewShippingServiceClient(conn).
+		ValidateAddress(ctx, &pb.ValidateAddressRequest{Address: address})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to validate address: %+v", err)
+	}
+	if resp.GetValid() {
+		return resp.GetAddress(), nil
+	}
+
+	br := &errdetails.BadRequest{}
+	descriptions := make([]string, len(resp.GetViolations()))
+	for i, v := range resp.GetViolations() {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "address." + v.GetField(),
+			Description: v.GetDescription(),
+		})
+		descriptions[i] = v.GetDescription()
+	}
+	msg := "invalid shipping address: " + strings.Join(descriptions, "; ")
+	st, err := status.New(codes.InvalidArgument, msg).WithDetails(br)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, msg)
+	}
+	return nil, st.Err()
+}
+
+// screenOrder scores the order for fraud and returns a gRPC status error if
+// it has to be held for review (FailedPrecondition) or is rejected
+// (PermissionDenied). Both carry an ErrorInfo detail with the outcome. It
+// returns the order scored, to record with Placed once it is placed.
+func (cs *checkoutService) screenOrder(ctx context.Context, req *pb.PlaceOrderRequest, address *pb.Address, total *pb.Money) (fraud.Order, error) {
+	totalUSD, err := cs.convertCurrency(ctx, total, usdCurrency)
+	if err != nil {
+		return fraud.Order{}, status.Errorf(codes.Internal, "failed to convert order total for fraud screening: %+v", err)
+	}
+	cardKey, cardBIN, err := cs.screenedCard(ctx, req)
+	if err != nil {
+		return fraud.Order{}, err
+	}
+	order := fraud.Order{
+		UserID:    req.GetUserId(),
+		CardKey:   cardKey,
+		CardBIN:   cardBIN,
+		AmountUSD: float64(totalUSD.GetUnits()) + float64(totalUSD.GetNanos())/1e9,
+		Country:   address.GetCountry(),
+		Currency:  req.GetUserCurrency(),
+	}
+	res := cs.fraud.Score(order)
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("fraud.score", res.Score),
+		attribute.String("fraud.outcome", res.Outcome.String()),
+		attribute.StringSlice("fraud.reasons", res.Reasons),
+	)
+	log.WithField("fraud.score", res.Score).WithField("fraud.outcome", res.Outcome.String()).
+		WithField("fraud.reasons", res.Reasons).Infof("[PlaceOrder] fraud screening for user_id=%q", req.GetUserId())
+
+	var (
+		code codes.Code
+		msg  string
+	)
+	switch res.Outcome {
+	case fraud.Reject:
+		code, msg = codes.PermissionDenied, "order rejected by fraud screening"
+	case fraud.Review:
+		code, msg = codes.FailedPrecondition, "order held for manual review by fraud screening"
+	default:
+		return order, nil
+	}
+	st, err := status.New(code, fmt.Sprintf("%s (score %d)", msg, res.Score)).
+		WithDetails(&errdetails.ErrorInfo{
+			Reason: "FRAUD_" + res.Outcome.String(),
+			Domain: "checkoutservice",
+			Metadata: map[string]string{
+				"score":   strconv.Itoa(res.Score),
+				"reasons": strings.Join(res.Reasons, "; "),
+			},
+		})
+	if err != nil {
+		return order, status.Error(code, msg)
+	}
+	return order, st.Err()
+}
+
+// screenedCard returns the velocity key and BIN of the card of the order.
+// Tokens are stable per card, so they work as the velocity key, and the
+// payment service tells the BIN of the card behind them.
+func (cs *checkoutService) screenedCard(ctx context.Context, req *pb.PlaceOrderRequest) (string, string, error) {
+	token := req.GetPaymentToken()
+	if token == "" {
+		cardNumber := req.GetCreditCard().GetCreditCardNumber()
+		return fraud.CardKey(cardNumber), fraud.CardBIN(cardNumber), nil
+	}
+	conn, err := createClient(cs.paymentSvcAddr)
+	if err != nil {
+		return "", "", status.Errorf(codes.Internal, "could not connect payment service: %+v", err)
+	}
+	defer conn.Close()
+	details, err := pb.NewPaymentServiceClient(conn).
+		GetCardDetails(ctx, &pb.GetCardDetailsRequest{PaymentToken: token})
+	if status.Code(err) == codes.NotFound {
+		return "", "", status.Error(codes.InvalidArgument, "unknown payment token")
+	} else if err != nil {
+		return "", "", status.Errorf(codes.Unavailable, "failed to get card details for fraud screening: %+v", err)
+	}
+	return token, details.GetBin(), nil
+}
+
 type orderPrep struct {
 	orderItems            []*pb.OrderItem
 	cartItems             []*pb.CartItem
 	shippingCostLocalized *pb.Money
+	shippingMethod        string
 }
 
-func (cs *checkoutService) prepareOrderItemsAndShippingQuoteFromCart(ctx context.Context, userID, userCurrency string, address *pb.Address) (orderPrep, error) {
+func (cs *checkoutService) prepareOrderItemsAndShippingQuoteFromCart(ctx context.Context, userID, userCurrency string, address *pb.Address, shippingMethod string) (orderPrep, error) {
 	var out orderPrep
 	cartItems, err := cs.getUserCart(ctx, userID)
 	if err != nil {
@@ -234,11 +415,11 @@ func (cs *checkoutService) prepareOrderItemsAndShippingQuoteFromCart(ctx context
 	}
 	orderItems, err := cs.prepOrderItems(ctx, cartItems, userCurrency)
 	if err != nil {
-		return out, fmt.Errorf("failed to prepare order: %+v", err)
+		return out, fmt.Errorf("failed to prepare order: %w", err)
 	}
-	shippingUSD, err := cs.quoteShipping(ctx, address, cartItems)
+	shippingUSD, shippingMethod, err := cs.quoteShipping(ctx, address, cartItems, shippingMethod)
 	if err != nil {
-		return out, fmt.Errorf("shipping quote failure: %+v", err)
+		return out, fmt.Errorf("shipping quote failure: %w", err)
 	}
 	shippingPrice, err := cs.convertCurrency(ctx, shippingUSD, userCurrency)
 	if err != nil {
@@ -246,6 +427,7 @@ func (cs *checkoutService) prepareOrderItemsAndShippingQuoteFromCart(ctx context
 	}
 
 	out.shippingCostLocalized = shippingPrice
+	out.shippingMethod = shippingMethod
 	out.cartItems = cartItems
 	out.orderItems = orderItems
 	return out, nil
@@ -258,10 +440,12 @@ func createClient(svcAddr string) (*grpc.ClientConn, error) {
 	)
 }
 
-func (cs *checkoutService) quoteShipping(ctx context.Context, address *pb.Address, items []*pb.CartItem) (*pb.Money, error) {
+// quoteShipping returns the cost of shipping items with method, or with the
+// cheapest method if none was chosen, along with the method used.
+func (cs *checkoutService) quoteShipping(ctx context.Context, address *pb.Address, items []*pb.CartItem, method string) (*pb.Money, string, error) {
 	conn, err := createClient(cs.shippingSvcAddr)
 	if err != nil {
-		return nil, fmt.Errorf("could not connect shipping service: %+v", err)
+		return nil, "", fmt.Errorf("could not connect shipping service: %+v", err)
 	}
 	defer conn.Close()
 
@@ -270,9 +454,21 @@ func (cs *checkoutService) quoteShipping(ctx context.Context, address *pb.Addres
 			Address: address,
 			Items:   items})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get shipping quote: %+v", err)
+		return nil, "", fmt.Errorf("failed to get shipping quote: %+v", err)
+	}
+	options := shippingQuote.GetOptions()
+	if method == "" {
+		if len(options) > 0 {
+			return options[0].GetCostUsd(), options[0].GetMethod(), nil
+		}
+		return shippingQuote.GetCostUsd(), "", nil
+	}
+	for _, o := range options {
+		if o.GetMethod() == method {
+			return o.GetCostUsd(), method, nil
+		}
 	}
-	return shippingQuote.GetCostUsd(), nil
+	return nil, "", status.Errorf(codes.InvalidArgument, "shipping method %q is not available for this address", method)
 }
 
 func (cs *checkoutService) getUserCart(ctx context.Context, userID string) ([]*pb.CartItem, error) {
@@ -317,7 +513,11 @@ func (cs *checkoutService) prepOrderItems(ctx context.Context, items []*pb.CartI
 		if err != nil {
 			return nil, fmt.Errorf("failed to get product #%q", item.GetProductId())
 		}
-		price, err := cs.convertCurrency(ctx, product.GetPriceUsd(), userCurrency)
+		priceUSD, err := variantPrice(product, item.GetVariantSku())
+		if err != nil {
+			return nil, err
+		}
+		price, err := cs.convertCurrency(ctx, priceUSD, userCurrency)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert price of %q to %s", item.GetProductId(), userCurrency)
 		}
@@ -328,6 +528,27 @@ func (cs *checkoutService) prepOrderItems(ctx context.Context, items []*pb.CartI
 	return out, nil
 }
 
+// variantPrice returns the price in USD of the variant of product with the
+// SKU. Products with variants must be ordered by SKU, and those without by
+// product ID alone.
+func variantPrice(product *pb.Product, sku string) (*pb.Money, error) {
+	if sku == "" {
+		if len(product.GetVariants()) > 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "product #%q must be ordered in one of its variants", product.GetId())
+		}
+		return product.GetPriceUsd(), nil
+	}
+	for _, v := range product.GetVariants() {
+		if v.GetSku() == sku {
+			if v.GetPriceUsd() != nil {
+				return v.GetPriceUsd(), nil
+			}
+			return product.GetPriceUsd(), nil
+		}
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "product #%q has no variant %q", product.GetId(), sku)
+}
+
 func (cs *checkoutService) convertCurrency(ctx context.Context, from *pb.Money, toCurrency string) (*pb.Money, error) {
 	conn, err := createClient(cs.currencySvcAddr)
 	if err != nil {
@@ -343,7 +564,9 @@ func (cs *checkoutService) convertCurrency(ctx context.Context, from *pb.Money,
 	return result, err
 }
 
-func (cs *checkoutService) chargeCard(ctx context.Context, amount *pb.Money, paymentInfo *pb.CreditCardInfo) (string, error) {
+// chargeCard charges the card behind paymentToken, or paymentInfo if no
+// token was given.
+func (cs *checkoutService) chargeCard(ctx context.Context, amount *pb.Money, paymentToken string, paymentInfo *pb.CreditCardInfo) (string, error) {
 	conn, err := createClient(cs.paymentSvcAddr)
 	if err != nil {
 		return "", fmt.Errorf("failed to connect payment service: %+v", err)
@@ -351,8 +574,9 @@ func (cs *checkoutService) chargeCard(ctx context.Context, amount *pb.Money, pay
 	defer conn.Close()
 
 	paymentResp, err := pb.NewPaymentServiceClient(conn).Charge(ctx, &pb.ChargeRequest{
-		Amount:     amount,
-		CreditCard: paymentInfo})
+		Amount:       amount,
+		PaymentToken: paymentToken,
+		CreditCard:   paymentInfo})
 	if err != nil {
 		return "", fmt.Errorf("could not charge the card: %+v", err)
 	}
@@ -371,17 +595,23 @@ func (cs *checkoutService) sendOrderConfirmation(ctx context.Context, email stri
 	return err
 }
 
-func (cs *checkoutService) shipOrder(ctx context.Context, address *pb.Address, items []*pb.CartItem) (string, error) {
+// shipOrder ships the items and returns one shipment per package.
+func (cs *checkoutService) shipOrder(ctx context.Context, address *pb.Address, items []*pb.CartItem, method string) ([]*pb.Shipment, error) {
 	conn, err := createClient(cs.shippingSvcAddr)
 	if err != nil {
-		return "", fmt.Errorf("failed to connect email service: %+v", err)
+		return nil, fmt.Errorf("failed to connect shipping service: %+v", err)
 	}
 	defer conn.Close()
 	resp, err := pb.NewShippingServiceClient(conn).ShipOrder(ctx, &pb.ShipOrderRequest{
-		Address: address,
-		Items:   items})
+		Address:        address,
+		Items:          items,
+		ShippingMethod: method})
 	if err != nil {
-		return "", fmt.Errorf("shipment failed: %+v", err)
+		return nil, fmt.Errorf("shipment failed: %+v", err)
+	}
+	if len(resp.GetShipments()) == 0 {
+		// Older shipping services only return a single tracking ID.
+		return []*pb.Shipment{{TrackingId: resp.GetTrackingId(), Items: items}}, nil
 	}
-	return resp.GetTrackingId(), nil
+	return resp.GetShipments(), nil
 }
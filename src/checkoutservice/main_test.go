@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"github.com/norun9/microservices-demo-ambient/src/checkoutservice/fraud"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakePayment knows the BINs of the cards behind its tokens.
+type fakePayment struct {
+	pb.UnimplementedPaymentServiceServer
+	bins map[string]string
+}
+
+func (f *fakePayment) GetCardDetails(_ context.Context, req *pb.GetCardDetailsRequest) (*pb.GetCardDetailsResponse, error) {
+	bin, ok := f.bins[req.GetPaymentToken()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown payment token")
+	}
+	return &pb.GetCardDetailsResponse{CardType: "visa", Bin: bin}, nil
+}
+
+// fakeCurrency converts at par.
+type fakeCurrency struct {
+	pb.UnimplementedCurrencyServiceServer
+}
+
+func (fakeCurrency) Convert(_ context.Context, req *pb.CurrencyConversionRequest) (*pb.Money, error) {
+	return &pb.Money{CurrencyCode: req.GetToCode(), Units: req.GetFrom().GetUnits(), Nanos: req.GetFrom().GetNanos()}, nil
+}
+
+func TestScreenOrder(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterPaymentServiceServer(srv, &fakePayment{bins: map[string]string{"tok_us": "443280"}})
+	pb.RegisterCurrencyServiceServer(srv, fakeCurrency{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	rules := fraud.DefaultRules()
+	rules.Country.BINCountries = map[string]string{"4432": "United States"}
+	rules.Country.Score = rules.ReviewScore
+	cs := &checkoutService{
+		paymentSvcAddr:  lis.Addr().String(),
+		currencySvcAddr: lis.Addr().String(),
+		fraud:           fraud.NewScorer(rules),
+	}
+	total := &pb.Money{CurrencyCode: usdCurrency, Units: 20}
+
+	tests := []struct {
+		name    string
+		req     *pb.PlaceOrderRequest
+		country string
+		want    codes.Code
+	}{
+		{"token from the issuer country", &pb.PlaceOrderRequest{UserId: "u1", PaymentToken: "tok_us"}, "United States", codes.OK},
+		{"token from another country", &pb.PlaceOrderRequest{UserId: "u2", PaymentToken: "tok_us"}, "France", codes.FailedPrecondition},
+		{"unknown token", &pb.PlaceOrderRequest{UserId: "u3", PaymentToken: "tok_forged"}, "United States", codes.InvalidArgument},
+		{"raw card from another country", &pb.PlaceOrderRequest{UserId: "u4", CreditCard: &pb.CreditCardInfo{CreditCardNumber: "4432801561520454"}}, "France", codes.FailedPrecondition},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cs.screenOrder(context.Background(), tt.req, &pb.Address{Country: tt.country}, total)
+			if status.Code(err) != tt.want {
+				t.Errorf("screenOrder() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
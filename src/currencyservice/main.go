@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/norun9/microservices-demo-ambient/chaos"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 	"github.com/norun9/microservices-demo-ambient/src/currencyservice/services"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -73,10 +74,17 @@ func main() {
 	}
 	log.Println("Successfully created TCP listener")
 
+	// Inject the faults of CHAOS_RULES_FILE, if set.
+	chaosInjector, err := chaos.FromEnv(nil)
+	if err != nil {
+		log.Fatalf("failed to load chaos rules: %v", err)
+	}
+	defer chaosInjector.Close()
+
 	// Add OTel interceptor to gRPC server.
-	grpcServer := grpc.NewServer(
+	grpcServer := grpc.NewServer(append([]grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+	}, chaosInjector.ServerOptions()...)...)
 	log.Println("Created gRPC server with OpenTelemetry interceptors")
 
 	// Register CurrencyService and HealthCheckService.
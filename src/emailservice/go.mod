@@ -3,6 +3,7 @@ module github.com/norun9/microservices-demo-ambient/src/emailservice
 go 1.24.1
 
 require (
+	github.com/norun9/microservices-demo-ambient/chaos v0.0.0-00010101000000-000000000000
 	github.com/norun9/microservices-demo-ambient/genproto v0.0.0-00010101000000-000000000000
 	go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.46.1
 	go.opentelemetry.io/otel v1.37.0
@@ -28,6 +29,9 @@ require (
 	google.golang.org/genproto/googleapis/api v0.0.0-20250603155806-513f23925822 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250603155806-513f23925822 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
 
 replace github.com/norun9/microservices-demo-ambient/genproto => ../../genproto
+
+replace github.com/norun9/microservices-demo-ambient/chaos => ../../chaos
@@ -122,9 +122,12 @@ func loadEmailTemplate() (*template.Template, error) {
 
 	// Define custom functions.
 	funcMap := template.FuncMap{
-		"div": func(a, b int64) int64 {
+		"div": func(a, b int32) int32 {
 			return a / b
 		},
+		"inc": func(i int) int {
+			return i + 1
+		},
 	}
 
 	// Load and parse the template.
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/grpc"
+)
+
+const (
+	// adEventQueueSize is the number of ad impressions waiting to be reported
+	// at most. Impressions are dropped when the queue is full.
+	adEventQueueSize = 1000
+	// adEventBatchSize is the number of impressions reported at most at once.
+	adEventBatchSize = 100
+	// adEventFlushInterval is how long an impression waits at most to be
+	// reported.
+	adEventFlushInterval = time.Second
+	// adEventTimeout bounds the time spent reporting a batch.
+	adEventTimeout = 2 * time.Second
+)
+
+// impressionReporter reports the ads shown to the ad service in batches, in
+// the background, so as not to delay pages nor start a request per page.
+type impressionReporter struct {
+	client pb.AdServiceClient
+	events chan *pb.AdEvent
+}
+
+func newImpressionReporter(conn *grpc.ClientConn) *impressionReporter {
+	r := &impressionReporter{
+		client: pb.NewAdServiceClient(conn),
+		events: make(chan *pb.AdEvent, adEventQueueSize),
+	}
+	go r.run()
+	return r
+}
+
+// report queues an impression of adID shown to sessionID.
+func (r *impressionReporter) report(adID, sessionID string) {
+	select {
+	case r.events <- &pb.AdEvent{AdId: adID, Type: pb.AdEventType_AD_IMPRESSION, SessionId: sessionID}:
+	default:
+		log.Warnf("dropped the impression of ad %s: the queue is full", adID)
+	}
+}
+
+func (r *impressionReporter) run() {
+	ticker := time.NewTicker(adEventFlushInterval)
+	defer ticker.Stop()
+	var batch []*pb.AdEvent
+	for {
+		select {
+		case e := <-r.events:
+			if batch = append(batch, e); len(batch) < adEventBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		r.send(batch)
+		batch = nil
+	}
+}
+
+func (r *impressionReporter) send(events []*pb.AdEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), adEventTimeout)
+	defer cancel()
+	resp, err := r.client.ReportAdEvents(ctx, &pb.ReportAdEventsRequest{Events: events})
+	if err != nil {
+		log.WithField("error", err).Warnf("failed to report %d ad impressions", len(events))
+		return
+	}
+	// Impressions of ads no longer served are rejected, and the others
+	// counted.
+	if rejected := resp.GetRejected(); len(rejected) > 0 {
+		log.Warnf("%d of %d ad impressions rejected, the first of ad %s", len(rejected), len(events), events[rejected[0]].GetAdId())
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// categoryCacheTTL is how long the categories of a product are cached.
+	categoryCacheTTL = 5 * time.Minute
+	// categoryCacheSize is the number of products cached at most.
+	categoryCacheSize = 1000
+)
+
+// categoryCache keeps the categories of the products seen lately, so that the
+// products in carts are not fetched on every page for the ads. It is safe for
+// concurrent use.
+type categoryCache struct {
+	mu      sync.Mutex
+	entries map[string]categoryEntry
+}
+
+type categoryEntry struct {
+	categories []string
+	expires    time.Time
+}
+
+func newCategoryCache() *categoryCache {
+	return &categoryCache{entries: make(map[string]categoryEntry)}
+}
+
+// get returns the categories of product id, if they are cached at now.
+func (c *categoryCache) get(id string, now time.Time) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+	return e.categories, true
+}
+
+// put caches the categories of product id from now. When the cache is full,
+// expired products are dropped first, then any product.
+func (c *categoryCache) put(id string, categories []string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[id]; !ok && len(c.entries) >= categoryCacheSize {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < categoryCacheSize {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[id] = categoryEntry{categories, now.Add(categoryCacheTTL)}
+}
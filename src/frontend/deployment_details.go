@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/norun9/microservices-demo-ambient/redact"
 )
 
 var deploymentDetailsMap map[string]string
@@ -28,7 +30,7 @@ func initializeLogger() {
 		},
 		TimestampFormat: time.RFC3339Nano,
 	}
-	log.Out = os.Stdout
+	log.Out = redact.NewWriter(os.Stdout)
 }
 
 // func loadDeploymentDetails() {
@@ -34,6 +34,11 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250603155806-513f23925822 // indirect
 )
 
-require go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux v0.29.0
+require (
+	github.com/norun9/microservices-demo-ambient/redact v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux v0.29.0
+)
 
 replace github.com/norun9/microservices-demo-ambient/genproto => ../../genproto
+
+replace github.com/norun9/microservices-demo-ambient/redact => ../../redact
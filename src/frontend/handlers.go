@@ -18,17 +18,21 @@ import (
 	"context"
 	"fmt"
 	"html/template"
-	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 	"github.com/norun9/microservices-demo-ambient/src/frontend/money"
@@ -45,6 +49,8 @@ var (
 			Funcs(template.FuncMap{
 			"renderMoney":        renderMoney,
 			"renderCurrencyLogo": renderCurrencyLogo,
+			"renderTime":         renderTime,
+			"inc":                func(i int) int { return i + 1 },
 		}).ParseGlob("templates/*.html"))
 	plat platformDetails
 )
@@ -59,11 +65,36 @@ func (fe *frontendServer) homeHandler(w http.ResponseWriter, r *http.Request) {
 		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve currencies"), http.StatusInternalServerError)
 		return
 	}
-	products, err := fe.getProducts(r.Context())
+	filter := parseProductFilter(r)
+	req, err := filter.request(currentCurrency(r))
 	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve products"), http.StatusInternalServerError)
+		renderHTTPError(log, r, w, err, http.StatusBadRequest)
 		return
 	}
+	req.PageSize = homePageSize
+	req.PageToken = r.FormValue("page")
+	var productsPage *pb.ListProductsResponse
+	if filter.Query != "" {
+		productsPage, err = fe.searchProducts(r.Context(), filter.Query, req.PageSize, req.PageToken)
+	} else {
+		productsPage, err = fe.listProducts(r.Context(), req)
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		if status.Code(err) == codes.InvalidArgument {
+			code = http.StatusBadRequest
+		}
+		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve products"), code)
+		return
+	}
+	products := productsPage.GetProducts()
+	var firstPageURL, nextPageURL string
+	if filter.Paged {
+		firstPageURL = filter.url("")
+	}
+	if token := productsPage.GetNextPageToken(); token != "" {
+		nextPageURL = filter.url(token)
+	}
 	cart, err := fe.getCart(r.Context(), sessionID(r))
 	if err != nil {
 		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve cart"), http.StatusInternalServerError)
@@ -109,9 +140,15 @@ func (fe *frontendServer) homeHandler(w http.ResponseWriter, r *http.Request) {
 		"show_currency":     true,
 		"currencies":        currencies,
 		"products":          ps,
+		"total_products":    productsPage.GetTotalSize(),
+		"filter":            filter,
+		"categories":        productCategories,
+		"sort_orders":       productSortOrders,
+		"first_page_url":    firstPageURL,
+		"next_page_url":     nextPageURL,
 		"cart_size":         cartSize(cart),
 		"banner_color":      os.Getenv("BANNER_COLOR"), // illustrates canary deployments
-		"ad":                fe.chooseAd(r.Context(), []string{}, log),
+		"ad":                fe.chooseAd(r.Context(), sessionID(r), fe.adContext(r.Context(), nil, filter, products, cart, log), log),
 		"platform_css":      plat.css,
 		"platform_name":     plat.provider,
 		"is_cymbal_brand":   isCymbalBrand,
@@ -121,6 +158,83 @@ func (fe *frontendServer) homeHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// productFilter is the category, price range and sort order picked on the
+// home page, or the search query, as found in its query string. Products
+// searched for are listed most relevant first, whatever the other filters.
+type productFilter struct {
+	Query    string
+	Category string
+	MinPrice string
+	MaxPrice string
+	Sort     string
+	// Paged is set when a page other than the first is shown.
+	Paged bool
+}
+
+// productSortOrder is a sort order offered on the home page.
+type productSortOrder struct {
+	Value string
+	Label string
+	Order pb.ProductSortOrder
+}
+
+var productSortOrders = []productSortOrder{
+	{"", "Featured", pb.ProductSortOrder_PRODUCT_SORT_ORDER_UNSPECIFIED},
+	{"name", "Name", pb.ProductSortOrder_SORT_BY_NAME},
+	{"price_asc", "Price: low to high", pb.ProductSortOrder_SORT_BY_PRICE_ASCENDING},
+	{"price_desc", "Price: high to low", pb.ProductSortOrder_SORT_BY_PRICE_DESCENDING},
+}
+
+func parseProductFilter(r *http.Request) productFilter {
+	return productFilter{
+		Query:    strings.TrimSpace(r.FormValue("q")),
+		Category: r.FormValue("category"),
+		MinPrice: strings.TrimSpace(r.FormValue("min_price")),
+		MaxPrice: strings.TrimSpace(r.FormValue("max_price")),
+		Sort:     r.FormValue("sort"),
+		Paged:    r.FormValue("page") != "",
+	}
+}
+
+// request returns a catalog request for the filter, with the price range in
+// the user's currency.
+func (f productFilter) request(currency string) (*pb.ListProductsRequest, error) {
+	req := &pb.ListProductsRequest{Category: f.Category}
+	var err error
+	if f.MinPrice != "" {
+		if req.MinPrice, err = money.Parse(f.MinPrice, currency); err != nil {
+			return nil, errors.Errorf("invalid minimum price %q", f.MinPrice)
+		}
+	}
+	if f.MaxPrice != "" {
+		if req.MaxPrice, err = money.Parse(f.MaxPrice, currency); err != nil {
+			return nil, errors.Errorf("invalid maximum price %q", f.MaxPrice)
+		}
+	}
+	for _, o := range productSortOrders {
+		if o.Value == f.Sort {
+			req.SortOrder = o.Order
+			return req, nil
+		}
+	}
+	return nil, errors.Errorf("invalid sort order %q", f.Sort)
+}
+
+// url returns the home page URL showing the filtered products from the page
+// token on, or from the first product if the token is empty.
+func (f productFilter) url(pageToken string) string {
+	q := url.Values{}
+	for k, v := range map[string]string{"q": f.Query, "category": f.Category, "min_price": f.MinPrice, "max_price": f.MaxPrice, "sort": f.Sort, "page": pageToken} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if len(q) == 0 {
+		return "/"
+	}
+	return "/?" + q.Encode()
+}
+
 func (plat *platformDetails) setPlatformDetails(env string) {
 	if env == "aws" {
 		plat.provider = "AWS"
@@ -176,21 +290,35 @@ func (fe *frontendServer) productHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	recommendations, err := fe.getRecommendations(r.Context(), sessionID(r), []string{id})
-	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "failed to get product recommendations"), http.StatusInternalServerError)
-		return
+	recommendations := fe.chooseRecommendations(r.Context(), sessionID(r), []string{id}, log)
+
+	type variantView struct {
+		Sku   string
+		Label string
+		Price *pb.Money
+	}
+	variants := make([]variantView, len(p.GetVariants()))
+	for i, v := range p.GetVariants() {
+		variantPrice := price
+		if v.GetPriceUsd() != nil {
+			if variantPrice, err = fe.convertCurrency(r.Context(), v.GetPriceUsd(), currentCurrency(r)); err != nil {
+				renderHTTPError(log, r, w, errors.Wrap(err, "failed to convert currency"), http.StatusInternalServerError)
+				return
+			}
+		}
+		variants[i] = variantView{Sku: v.GetSku(), Label: variantLabel(v), Price: variantPrice}
 	}
 
 	product := struct {
-		Item  *pb.Product
-		Price *pb.Money
-	}{p, price}
+		Item     *pb.Product
+		Price    *pb.Money
+		Variants []variantView
+	}{p, price, variants}
 
 	if err := templates.ExecuteTemplate(w, "product", map[string]interface{}{
 		"session_id":        sessionID(r),
 		"request_id":        r.Context().Value(ctxKeyRequestID{}),
-		"ad":                fe.chooseAd(r.Context(), p.Categories, log),
+		"ad":                fe.chooseAd(r.Context(), sessionID(r), fe.adContext(r.Context(), p, productFilter{}, []*pb.Product{p}, cart, log), log),
 		"user_currency":     currentCurrency(r),
 		"show_currency":     true,
 		"currencies":        currencies,
@@ -210,19 +338,27 @@ func (fe *frontendServer) addToCartHandler(w http.ResponseWriter, r *http.Reques
 	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
 	quantity, _ := strconv.ParseUint(r.FormValue("quantity"), 10, 32)
 	productID := r.FormValue("product_id")
+	variantSKU := r.FormValue("variant_sku")
 	if productID == "" || quantity == 0 {
 		renderHTTPError(log, r, w, errors.New("invalid form input"), http.StatusBadRequest)
 		return
 	}
-	log.WithField("product", productID).WithField("quantity", quantity).Debug("adding to cart")
+	log.WithField("product", productID).WithField("variant", variantSKU).WithField("quantity", quantity).Debug("adding to cart")
 
 	p, err := fe.getProduct(r.Context(), productID)
 	if err != nil {
 		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve product"), http.StatusInternalServerError)
 		return
 	}
+	if len(p.GetVariants()) > 0 && findVariant(p, variantSKU) == nil {
+		renderHTTPError(log, r, w, errors.Errorf("product %s has no variant %q", productID, variantSKU), http.StatusBadRequest)
+		return
+	} else if len(p.GetVariants()) == 0 && variantSKU != "" {
+		renderHTTPError(log, r, w, errors.Errorf("product %s has no variants", productID), http.StatusBadRequest)
+		return
+	}
 
-	if err := fe.insertCart(r.Context(), sessionID(r), p.GetId(), int32(quantity)); err != nil {
+	if err := fe.insertCart(r.Context(), sessionID(r), p.GetId(), variantSKU, int32(quantity)); err != nil {
 		renderHTTPError(log, r, w, errors.Wrap(err, "failed to add to cart"), http.StatusInternalServerError)
 		return
 	}
@@ -256,20 +392,34 @@ func (fe *frontendServer) viewCartHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	recommendations, err := fe.getRecommendations(r.Context(), sessionID(r), cartIDs(cart))
-	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "failed to get product recommendations"), http.StatusInternalServerError)
-		return
-	}
+	recommendations := fe.chooseRecommendations(r.Context(), sessionID(r), cartIDs(cart), log)
 
-	shippingCost, err := fe.getShippingQuote(r.Context(), cart, currentCurrency(r))
+	// Quotes depend on the destination; the cart page lets the user pick the
+	// country and method, defaulting to the cheapest method.
+	country := r.FormValue("country")
+	if country == "" {
+		country = defaultShippingCountry
+	}
+	shippingOptions, err := fe.getShippingQuote(r.Context(), cart, country, currentCurrency(r))
 	if err != nil {
 		renderHTTPError(log, r, w, errors.Wrap(err, "failed to get shipping quote"), http.StatusInternalServerError)
 		return
 	}
+	if len(shippingOptions) == 0 {
+		renderHTTPError(log, r, w, errors.Errorf("no shipping method available to %q", country), http.StatusInternalServerError)
+		return
+	}
+	shipping := shippingOptions[0]
+	for _, o := range shippingOptions {
+		if o.Method == r.FormValue("shipping_method") {
+			shipping = o
+		}
+	}
 
 	type cartItemView struct {
 		Item     *pb.Product
+		Variant  *pb.ProductVariant
+		Label    string
 		Quantity int32
 		Price    *pb.Money
 	}
@@ -281,7 +431,12 @@ func (fe *frontendServer) viewCartHandler(w http.ResponseWriter, r *http.Request
 			renderHTTPError(log, r, w, errors.Wrapf(err, "could not retrieve product #%s", item.GetProductId()), http.StatusInternalServerError)
 			return
 		}
-		price, err := fe.convertCurrency(r.Context(), p.GetPriceUsd(), currentCurrency(r))
+		priceUSD := p.GetPriceUsd()
+		variant := findVariant(p, item.GetVariantSku())
+		if variant.GetPriceUsd() != nil {
+			priceUSD = variant.GetPriceUsd()
+		}
+		price, err := fe.convertCurrency(r.Context(), priceUSD, currentCurrency(r))
 		if err != nil {
 			renderHTTPError(log, r, w, errors.Wrapf(err, "could not convert currency for product #%s", item.GetProductId()), http.StatusInternalServerError)
 			return
@@ -290,11 +445,13 @@ func (fe *frontendServer) viewCartHandler(w http.ResponseWriter, r *http.Request
 		multPrice := money.MultiplySlow(price, uint32(item.GetQuantity()))
 		items[i] = cartItemView{
 			Item:     p,
+			Variant:  variant,
+			Label:    variantLabel(variant),
 			Quantity: item.GetQuantity(),
 			Price:    multPrice}
 		totalPrice = money.Must(money.Sum(totalPrice, multPrice))
 	}
-	totalPrice = money.Must(money.Sum(totalPrice, shippingCost))
+	totalPrice = money.Must(money.Sum(totalPrice, shipping.Cost))
 	year := time.Now().Year()
 
 	if err := templates.ExecuteTemplate(w, "cart", map[string]interface{}{
@@ -304,7 +461,10 @@ func (fe *frontendServer) viewCartHandler(w http.ResponseWriter, r *http.Request
 		"currencies":        currencies,
 		"recommendations":   recommendations,
 		"cart_size":         cartSize(cart),
-		"shipping_cost":     shippingCost,
+		"shipping_cost":     shipping.Cost,
+		"shipping_method":   shipping.Method,
+		"shipping_options":  shippingOptions,
+		"shipping_country":  country,
 		"show_currency":     true,
 		"total_cost":        totalPrice,
 		"items":             items,
@@ -325,41 +485,57 @@ func (fe *frontendServer) placeOrderHandler(w http.ResponseWriter, r *http.Reque
 	var (
 		email         = r.FormValue("email")
 		streetAddress = r.FormValue("street_address")
-		zipCode, _    = strconv.ParseInt(r.FormValue("zip_code"), 10, 32)
+		postalCode    = r.FormValue("zip_code")
 		city          = r.FormValue("city")
 		state         = r.FormValue("state")
 		country       = r.FormValue("country")
+		method        = r.FormValue("shipping_method")
 		ccNumber      = r.FormValue("credit_card_number")
 		ccMonth, _    = strconv.ParseInt(r.FormValue("credit_card_expiration_month"), 10, 32)
 		ccYear, _     = strconv.ParseInt(r.FormValue("credit_card_expiration_year"), 10, 32)
 		ccCVV, _      = strconv.ParseInt(r.FormValue("credit_card_cvv"), 10, 32)
 	)
 
+	paymentToken, err := fe.tokenizeCard(r.Context(), &pb.CreditCardInfo{
+		CreditCardNumber:          ccNumber,
+		CreditCardExpirationMonth: int32(ccMonth),
+		CreditCardExpirationYear:  int32(ccYear),
+		CreditCardCvv:             int32(ccCVV)})
+	if err != nil {
+		code := http.StatusInternalServerError
+		if status.Code(err) == codes.InvalidArgument {
+			code = http.StatusBadRequest
+		}
+		renderHTTPError(log, r, w, errors.Wrap(err, "failed to verify the card"), code)
+		return
+	}
+
 	order, err := pb.NewCheckoutServiceClient(fe.checkoutSvcConn).
 		PlaceOrder(r.Context(), &pb.PlaceOrderRequest{
-			Email: email,
-			CreditCard: &pb.CreditCardInfo{
-				CreditCardNumber:          ccNumber,
-				CreditCardExpirationMonth: int32(ccMonth),
-				CreditCardExpirationYear:  int32(ccYear),
-				CreditCardCvv:             int32(ccCVV)},
-			UserId:       sessionID(r),
-			UserCurrency: currentCurrency(r),
+			Email:          email,
+			PaymentToken:   paymentToken,
+			UserId:         sessionID(r),
+			UserCurrency:   currentCurrency(r),
+			ShippingMethod: method,
 			Address: &pb.Address{
 				StreetAddress: streetAddress,
 				City:          city,
 				State:         state,
-				ZipCode:       int32(zipCode),
+				PostalCode:    postalCode,
 				Country:       country},
 		})
 	if err != nil {
-		renderHTTPError(log, r, w, errors.Wrap(err, "failed to complete the order"), http.StatusInternalServerError)
+		code := http.StatusInternalServerError
+		if status.Code(err) == codes.InvalidArgument {
+			code = http.StatusBadRequest
+		}
+		renderHTTPError(log, r, w, errors.Wrap(err, "failed to complete the order"), code)
 		return
 	}
 	log.WithField("order", order.GetOrder().GetOrderId()).Info("order placed")
 
 	order.GetOrder().GetItems()
-	recommendations, _ := fe.getRecommendations(r.Context(), sessionID(r), nil)
+	recommendations := fe.chooseRecommendations(r.Context(), sessionID(r), nil, log)
 
 	totalPaid := order.GetOrder().GetShippingCost()
 	for _, v := range order.GetOrder().GetItems() {
@@ -391,6 +567,56 @@ func (fe *frontendServer) placeOrderHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+var shipmentStatusText = map[pb.ShipmentStatus]string{
+	pb.ShipmentStatus_LABEL_CREATED: "Label created",
+	pb.ShipmentStatus_IN_TRANSIT:    "In transit",
+	pb.ShipmentStatus_DELIVERED:     "Delivered",
+}
+
+func (fe *frontendServer) trackShipmentHandler(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		renderHTTPError(log, r, w, errors.New("tracking id not specified"), http.StatusBadRequest)
+		return
+	}
+	log.WithField("tracking_id", id).Debug("tracking shipment")
+
+	shipment, err := fe.trackShipment(r.Context(), id)
+	if status.Code(err) == codes.NotFound {
+		renderHTTPError(log, r, w, errors.Wrap(err, "unknown tracking id"), http.StatusNotFound)
+		return
+	} else if status.Code(err) == codes.InvalidArgument {
+		renderHTTPError(log, r, w, errors.Wrap(err, "invalid tracking id"), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve shipment"), http.StatusInternalServerError)
+		return
+	}
+
+	currencies, err := fe.getCurrencies(r.Context())
+	if err != nil {
+		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve currencies"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := templates.ExecuteTemplate(w, "track", map[string]interface{}{
+		"session_id":        sessionID(r),
+		"request_id":        r.Context().Value(ctxKeyRequestID{}),
+		"user_currency":     currentCurrency(r),
+		"show_currency":     false,
+		"currencies":        currencies,
+		"shipment":          shipment,
+		"status":            shipmentStatusText[shipment.GetStatus()],
+		"platform_css":      plat.css,
+		"platform_name":     plat.provider,
+		"is_cymbal_brand":   isCymbalBrand,
+		"deploymentDetails": deploymentDetailsMap,
+	}); err != nil {
+		log.Println(err)
+	}
+}
+
 func (fe *frontendServer) logoutHandler(w http.ResponseWriter, r *http.Request) {
 	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
 	log.Debug("logging out")
@@ -424,16 +650,139 @@ func (fe *frontendServer) setCurrencyHandler(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusFound)
 }
 
-// chooseAd queries for advertisements available and randomly chooses one, if
-// available. It ignores the error retrieving the ad since it is not critical.
-func (fe *frontendServer) chooseAd(ctx context.Context, ctxKeys []string, log logrus.FieldLogger) *pb.Ad {
-	ads, err := fe.getAd(ctx, ctxKeys)
+// chooseAd queries for the advertisement to show, if any is available. It
+// ignores the error retrieving the ad since it is not critical.
+func (fe *frontendServer) chooseAd(ctx context.Context, sessionID string, ctxKeys []string, log logrus.FieldLogger) *pb.Ad {
+	ads, err := fe.getAd(ctx, sessionID, ctxKeys)
 	if err != nil {
 		// This error occurs here.
 		log.WithField("error", err).Warn("failed to retrieve ads")
 		return nil
 	}
-	return ads[rand.Intn(len(ads))]
+	// No ad is left when all of them reached their caps.
+	if len(ads) == 0 {
+		return nil
+	}
+	ad := ads[0]
+	fe.impressions.report(ad.GetId(), sessionID)
+	return ad
+}
+
+// adContext returns the context keys ads are chosen for: the categories, name
+// and description of the product shown, if any, the category and search query
+// the products shown are filtered by, and the categories of the products in the
+// cart. The ad service extracts keywords from them.
+func (fe *frontendServer) adContext(ctx context.Context, product *pb.Product, filter productFilter, shown []*pb.Product, cart []*pb.CartItem, log logrus.FieldLogger) []string {
+	var keys []string
+	if product != nil {
+		keys = append(keys, product.GetCategories()...)
+		keys = append(keys, product.GetName(), product.GetDescription())
+	}
+	if filter.Category != "" {
+		keys = append(keys, filter.Category)
+	}
+	if filter.Query != "" {
+		keys = append(keys, filter.Query)
+	}
+	return append(keys, fe.cartCategories(ctx, shown, cart, log)...)
+}
+
+// cartCategories returns the categories of the products in cart. Products are
+// taken from those shown, or from the categories cached, and only the others
+// are fetched, concurrently.
+func (fe *frontendServer) cartCategories(ctx context.Context, shown []*pb.Product, cart []*pb.CartItem, log logrus.FieldLogger) []string {
+	now := time.Now()
+	for _, p := range shown {
+		fe.categories.put(p.GetId(), p.GetCategories(), now)
+	}
+	var categories []string
+	var missing []string
+	seen := make(map[string]bool)
+	for _, item := range cart {
+		id := item.GetProductId()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if c, ok := fe.categories.get(id, now); ok {
+			categories = append(categories, c...)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	fetched := make([][]string, len(missing))
+	var wg sync.WaitGroup
+	for i, id := range missing {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := fe.getProduct(ctx, id)
+			if err != nil {
+				// Ads are chosen without the product rather than failing.
+				log.WithField("error", err).Warn("failed to retrieve cart product for ads")
+				return
+			}
+			fe.categories.put(id, p.GetCategories(), now)
+			fetched[i] = p.GetCategories()
+		}()
+	}
+	wg.Wait()
+	for _, c := range fetched {
+		categories = append(categories, c...)
+	}
+	return categories
+}
+
+// adClickHandler records a click on an ad, and redirects to the page the ad
+// links to. Unknown ads are not found.
+func (fe *frontendServer) adClickHandler(w http.ResponseWriter, r *http.Request) {
+	log := r.Context().Value(ctxKeyLog{}).(logrus.FieldLogger)
+	id := mux.Vars(r)["id"]
+	ad, err := fe.getAdByID(r.Context(), id)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if status.Code(err) == codes.NotFound {
+			code = http.StatusNotFound
+		}
+		renderHTTPError(log, r, w, errors.Wrap(err, "could not retrieve ad"), code)
+		return
+	}
+	to := ad.GetRedirectUrl()
+	if !isLocalPath(to) {
+		renderHTTPError(log, r, w, errors.Errorf("ad %s redirects off the site to %q", id, to), http.StatusInternalServerError)
+		return
+	}
+	log.WithField("ad_id", id).Debug("ad clicked")
+
+	// The click is lost rather than the redirect if it cannot be reported.
+	if err := fe.reportAdClick(r.Context(), id, sessionID(r)); err != nil {
+		log.WithField("error", err).Warn("failed to report ad click")
+	}
+	w.Header().Set("location", to)
+	w.WriteHeader(http.StatusFound)
+}
+
+// isLocalPath reports whether s is a path of this site, so that a misconfigured
+// ad cannot redirect elsewhere.
+func isLocalPath(s string) bool {
+	if !strings.HasPrefix(s, "/") || strings.HasPrefix(s, "//") || strings.HasPrefix(s, "/\\") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme == "" && u.Host == ""
+}
+
+// chooseRecommendations returns the products recommended to userID along with
+// those of productIDs. Pages are rendered without recommendations, rather than
+// failing, if they cannot be retrieved.
+func (fe *frontendServer) chooseRecommendations(ctx context.Context, userID string, productIDs []string, log logrus.FieldLogger) []*pb.Product {
+	recommendations, err := fe.getRecommendations(ctx, userID, productIDs)
+	if err != nil {
+		log.WithField("error", err).Warn("failed to retrieve recommendations")
+		return nil
+	}
+	return recommendations
 }
 
 func renderHTTPError(log logrus.FieldLogger, r *http.Request, w http.ResponseWriter, err error, code int) {
@@ -478,6 +827,34 @@ func cartIDs(c []*pb.CartItem) []string {
 	return out
 }
 
+// findVariant returns the variant of p with the SKU, or nil.
+func findVariant(p *pb.Product, sku string) *pb.ProductVariant {
+	if sku == "" {
+		return nil
+	}
+	for _, v := range p.GetVariants() {
+		if v.GetSku() == sku {
+			return v
+		}
+	}
+	return nil
+}
+
+// variantLabel describes a variant by its attribute values, such as
+// "white / L", in the order of their names.
+func variantLabel(v *pb.ProductVariant) string {
+	names := make([]string, 0, len(v.GetAttributes()))
+	for name := range v.GetAttributes() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = v.GetAttributes()[name]
+	}
+	return strings.Join(values, " / ")
+}
+
 // get total # of items in cart
 func cartSize(c []*pb.CartItem) int {
 	cartSize := 0
@@ -492,6 +869,11 @@ func renderMoney(money *pb.Money) string {
 	return fmt.Sprintf("%s%d.%02d", currencyLogo, money.GetUnits(), money.GetNanos()/10000000)
 }
 
+// renderTime formats seconds since the Unix epoch for display.
+func renderTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format("Jan 2, 2006 15:04 MST")
+}
+
 func renderCurrencyLogo(currencyCode string) string {
 	logos := map[string]string{
 		"USD": "$",
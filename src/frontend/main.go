@@ -33,6 +33,8 @@ import (
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+
+	"github.com/norun9/microservices-demo-ambient/redact"
 )
 
 const (
@@ -40,6 +42,12 @@ const (
 	defaultCurrency = "USD"
 	cookieMaxAge    = 60 * 60 * 48
 
+	defaultShippingCountry = "United States"
+
+	// homePageSize is the number of products per home page, three rows of
+	// three.
+	homePageSize = 9
+
 	cookiePrefix    = "shop_"
 	cookieSessionID = cookiePrefix + "session-id"
 	cookieCurrency  = cookiePrefix + "currency"
@@ -53,6 +61,9 @@ var (
 		"JPY": true,
 		"GBP": true,
 		"TRY": true}
+
+	// productCategories are the categories that the home page can filter by.
+	productCategories = []string{"accessories", "beauty", "clothing", "decor", "footwear", "hair", "home", "kitchen"}
 )
 
 type ctxKeySessionID struct{}
@@ -76,8 +87,14 @@ type frontendServer struct {
 	shippingSvcAddr string
 	shippingSvcConn *grpc.ClientConn
 
+	paymentSvcAddr string
+	paymentSvcConn *grpc.ClientConn
+
 	adSvcAddr string
 	adSvcConn *grpc.ClientConn
+
+	impressions *impressionReporter
+	categories  *categoryCache
 }
 
 func InitTracerProvider() *sdktrace.TracerProvider {
@@ -107,8 +124,8 @@ func InitTracerProvider() *sdktrace.TracerProvider {
 		log.Fatal(err)
 	}
 
-	// 3) Build TracerProvider.
-	bsp := sdktrace.NewBatchSpanProcessor(exporter)
+	// 3) Build TracerProvider. Spans are scrubbed of card data before export.
+	bsp := sdktrace.NewBatchSpanProcessor(redact.NewSpanExporter(exporter))
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Consider TraceIDRatioBased for production.
 		sdktrace.WithResource(res),
@@ -133,13 +150,14 @@ func main() {
 		srvPort = os.Getenv("PORT")
 	}
 	addr := os.Getenv("LISTEN_ADDR")
-	svc := new(frontendServer)
+	svc := &frontendServer{categories: newCategoryCache()}
 	mustMapEnv(&svc.productCatalogSvcAddr, "PRODUCT_CATALOG_SERVICE_ADDR")
 	mustMapEnv(&svc.currencySvcAddr, "CURRENCY_SERVICE_ADDR")
 	mustMapEnv(&svc.cartSvcAddr, "CART_SERVICE_ADDR")
 	mustMapEnv(&svc.recommendationSvcAddr, "RECOMMENDATION_SERVICE_ADDR")
 	mustMapEnv(&svc.checkoutSvcAddr, "CHECKOUT_SERVICE_ADDR")
 	mustMapEnv(&svc.shippingSvcAddr, "SHIPPING_SERVICE_ADDR")
+	mustMapEnv(&svc.paymentSvcAddr, "PAYMENT_SERVICE_ADDR")
 	mustMapEnv(&svc.adSvcAddr, "AD_SERVICE_ADDR")
 
 	mustConnGRPC(&svc.currencySvcConn, svc.currencySvcAddr)
@@ -147,8 +165,10 @@ func main() {
 	mustConnGRPC(&svc.cartSvcConn, svc.cartSvcAddr)
 	mustConnGRPC(&svc.recommendationSvcConn, svc.recommendationSvcAddr)
 	mustConnGRPC(&svc.shippingSvcConn, svc.shippingSvcAddr)
+	mustConnGRPC(&svc.paymentSvcConn, svc.paymentSvcAddr)
 	mustConnGRPC(&svc.checkoutSvcConn, svc.checkoutSvcAddr)
 	mustConnGRPC(&svc.adSvcConn, svc.adSvcAddr)
+	svc.impressions = newImpressionReporter(svc.adSvcConn)
 
 	r := mux.NewRouter()
 	r.Use(otelmux.Middleware("server"))
@@ -160,6 +180,8 @@ func main() {
 	r.HandleFunc("/setCurrency", svc.setCurrencyHandler).Methods(http.MethodPost)
 	r.HandleFunc("/logout", svc.logoutHandler).Methods(http.MethodGet)
 	r.HandleFunc("/cart/checkout", svc.placeOrderHandler).Methods(http.MethodPost)
+	r.HandleFunc("/track/{id}", svc.trackShipmentHandler).Methods(http.MethodGet, http.MethodHead)
+	r.HandleFunc("/ad/click/{id}", svc.adClickHandler).Methods(http.MethodGet)
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
 	r.HandleFunc("/robots.txt", func(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, "User-agent: *\nDisallow: /") })
 	r.HandleFunc("/_healthz", func(w http.ResponseWriter, _ *http.Request) { fmt.Fprint(w, "ok") })
@@ -16,6 +16,9 @@ package money
 
 import (
 	"errors"
+	"regexp"
+	"strconv"
+	"strings"
 
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 )
@@ -130,3 +133,19 @@ func MultiplySlow(m *pb.Money, n uint32) *pb.Money {
 	}
 	return out
 }
+
+var decimal = regexp.MustCompile(`^(\d+)(?:\.(\d{1,9}))?$`)
+
+// Parse parses a non-negative decimal amount such as "12.5" in the currency.
+func Parse(s, currencyCode string) (*pb.Money, error) {
+	m := decimal.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, ErrInvalidValue
+	}
+	units, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidValue
+	}
+	nanos, _ := strconv.Atoi(m[2] + strings.Repeat("0", 9-len(m[2])))
+	return &pb.Money{CurrencyCode: currencyCode, Units: units, Nanos: int32(nanos)}, nil
+}
@@ -243,3 +243,34 @@ func TestSum(t *testing.T) {
 		})
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *pb.Money
+		wantErr error
+	}{
+		{"12", mmc(12, 0, "EUR"), nil},
+		{" 12.5 ", mmc(12, 500000000, "EUR"), nil},
+		{"0.05", mmc(0, 50000000, "EUR"), nil},
+		{"1.123456789", mmc(1, 123456789, "EUR"), nil},
+		{"", nil, ErrInvalidValue},
+		{".5", nil, ErrInvalidValue},
+		{"12.", nil, ErrInvalidValue},
+		{"-1", nil, ErrInvalidValue},
+		{"1.1234567890", nil, ErrInvalidValue},
+		{"1e3", nil, ErrInvalidValue},
+		{"99999999999999999999", nil, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, "EUR")
+			if err != tt.wantErr {
+				t.Errorf("Parse(%q): expected err=\"%v\" got=\"%v\"", tt.in, tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
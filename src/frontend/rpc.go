@@ -21,6 +21,8 @@ import (
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -42,10 +44,24 @@ func (fe *frontendServer) getCurrencies(ctx context.Context) ([]string, error) {
 	return out, nil
 }
 
-func (fe *frontendServer) getProducts(ctx context.Context) ([]*pb.Product, error) {
+func (fe *frontendServer) listProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	return pb.NewProductCatalogServiceClient(fe.productCatalogSvcConn).
+		ListProductsPage(ctx, req)
+}
+
+// searchProducts returns a page of the products matching query, most relevant
+// first.
+func (fe *frontendServer) searchProducts(ctx context.Context, query string, pageSize int32, pageToken string) (*pb.ListProductsResponse, error) {
 	resp, err := pb.NewProductCatalogServiceClient(fe.productCatalogSvcConn).
-		ListProducts(ctx, &pb.Empty{})
-	return resp.GetProducts(), err
+		SearchProducts(ctx, &pb.SearchProductsRequest{Query: query, PageSize: pageSize, PageToken: pageToken})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListProductsResponse{
+		Products:      resp.GetResults(),
+		TotalSize:     resp.GetTotalSize(),
+		NextPageToken: resp.GetNextPageToken(),
+	}, nil
 }
 
 func (fe *frontendServer) getProduct(ctx context.Context, id string) (*pb.Product, error) {
@@ -64,12 +80,13 @@ func (fe *frontendServer) emptyCart(ctx context.Context, userID string) error {
 	return err
 }
 
-func (fe *frontendServer) insertCart(ctx context.Context, userID, productID string, quantity int32) error {
+func (fe *frontendServer) insertCart(ctx context.Context, userID, productID, variantSKU string, quantity int32) error {
 	_, err := pb.NewCartServiceClient(fe.cartSvcConn).AddItem(ctx, &pb.AddItemRequest{
 		UserId: userID,
 		Item: &pb.CartItem{
-			ProductId: productID,
-			Quantity:  quantity},
+			ProductId:  productID,
+			VariantSku: variantSKU,
+			Quantity:   quantity},
 	})
 	return err
 }
@@ -84,16 +101,55 @@ func (fe *frontendServer) convertCurrency(ctx context.Context, money *pb.Money,
 			ToCode: currency})
 }
 
-func (fe *frontendServer) getShippingQuote(ctx context.Context, items []*pb.CartItem, currency string) (*pb.Money, error) {
+// shippingOption is a shipping method with its cost in the user's currency.
+type shippingOption struct {
+	Method      string
+	DisplayName string
+	Cost        *pb.Money
+	MinDays     int32
+	MaxDays     int32
+}
+
+// getShippingQuote returns the shipping methods available to country,
+// cheapest first, with costs converted to currency.
+func (fe *frontendServer) getShippingQuote(ctx context.Context, items []*pb.CartItem, country, currency string) ([]shippingOption, error) {
 	quote, err := pb.NewShippingServiceClient(fe.shippingSvcConn).GetQuote(ctx,
 		&pb.GetQuoteRequest{
-			Address: nil,
+			Address: &pb.Address{Country: country},
 			Items:   items})
 	if err != nil {
 		return nil, err
 	}
-	localized, err := fe.convertCurrency(ctx, quote.GetCostUsd(), currency)
-	return localized, errors.Wrap(err, "failed to convert currency for shipping cost")
+	out := make([]shippingOption, len(quote.GetOptions()))
+	for i, o := range quote.GetOptions() {
+		localized, err := fe.convertCurrency(ctx, o.GetCostUsd(), currency)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert currency for shipping cost")
+		}
+		out[i] = shippingOption{
+			Method:      o.GetMethod(),
+			DisplayName: o.GetDisplayName(),
+			Cost:        localized,
+			MinDays:     o.GetMinDays(),
+			MaxDays:     o.GetMaxDays()}
+	}
+	return out, nil
+}
+
+func (fe *frontendServer) trackShipment(ctx context.Context, trackingID string) (*pb.TrackShipmentResponse, error) {
+	return pb.NewShippingServiceClient(fe.shippingSvcConn).
+		TrackShipment(ctx, &pb.TrackShipmentRequest{TrackingId: trackingID})
+}
+
+// tokenizeCard exchanges card details for a payment token so that the card
+// number and CVV are not sent any further than the payment service.
+func (fe *frontendServer) tokenizeCard(ctx context.Context, card *pb.CreditCardInfo) (string, error) {
+	resp, err := pb.NewPaymentServiceClient(fe.paymentSvcConn).
+		TokenizeCard(ctx, &pb.TokenizeCardRequest{CreditCard: card})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetPaymentToken(), nil
 }
 
 func (fe *frontendServer) getRecommendations(ctx context.Context, userID string, productIDs []string) ([]*pb.Product, error) {
@@ -102,13 +158,17 @@ func (fe *frontendServer) getRecommendations(ctx context.Context, userID string,
 	if err != nil {
 		return nil, err
 	}
-	out := make([]*pb.Product, len(resp.GetProductIds()))
-	for i, v := range resp.GetProductIds() {
+	out := make([]*pb.Product, 0, len(resp.GetProductIds()))
+	for _, v := range resp.GetProductIds() {
 		p, err := fe.getProduct(ctx, v)
+		if status.Code(err) == codes.NotFound {
+			// Recommendations may lag behind products removed from the catalog.
+			continue
+		}
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get recommended product info (#%s)", v)
 		}
-		out[i] = p
+		out = append(out, p)
 	}
 	if len(out) > 4 {
 		out = out[:4] // take only first four to fit the UI
@@ -116,12 +176,30 @@ func (fe *frontendServer) getRecommendations(ctx context.Context, userID string,
 	return out, err
 }
 
-func (fe *frontendServer) getAd(ctx context.Context, ctxKeys []string) ([]*pb.Ad, error) {
+func (fe *frontendServer) getAd(ctx context.Context, sessionID string, ctxKeys []string) ([]*pb.Ad, error) {
 	ctx, cancel := context.WithTimeout(ctx, time.Millisecond*500)
 	defer cancel()
 
 	resp, err := pb.NewAdServiceClient(fe.adSvcConn).GetAds(ctx, &pb.AdRequest{
 		ContextKeys: ctxKeys,
+		SessionId:   sessionID,
 	})
 	return resp.GetAds(), errors.Wrap(err, "failed to get ads")
 }
+
+func (fe *frontendServer) getAdByID(ctx context.Context, id string) (*pb.Ad, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond*500)
+	defer cancel()
+
+	return pb.NewAdServiceClient(fe.adSvcConn).GetAd(ctx, &pb.GetAdRequest{Id: id})
+}
+
+func (fe *frontendServer) reportAdClick(ctx context.Context, adID, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond*500)
+	defer cancel()
+
+	_, err := pb.NewAdServiceClient(fe.adSvcConn).ReportAdEvents(ctx, &pb.ReportAdEventsRequest{
+		Events: []*pb.AdEvent{{AdId: adID, Type: pb.AdEventType_AD_CLICK, SessionId: sessionID}},
+	})
+	return errors.Wrap(err, "failed to report ad click")
+}
@@ -16,6 +16,12 @@ const products = [
     '6E92ZMYYFZ', '9SIQT8TOJO', 'L9ECAV7KIM', 'LS4PSXUNUM', 'OLJCESPC7Z'
 ];
 
+// SKUs of the products that must be bought in one of their variants
+const variants = {
+    '66VCHSJNUP': ['66VCHSJNUP-XS', '66VCHSJNUP-S', '66VCHSJNUP-M', '66VCHSJNUP-L', '66VCHSJNUP-XL'],
+    'L9ECAV7KIM': ['L9ECAV7KIM-8', 'L9ECAV7KIM-9', 'L9ECAV7KIM-10', 'L9ECAV7KIM-11', 'L9ECAV7KIM-12', 'L9ECAV7KIM-13'],
+};
+
 // Supported currencies
 const currencies = ['EUR', 'USD', 'JPY', 'CAD'];
 
@@ -83,6 +89,9 @@ function addToCart() {
         product_id: id,
         quantity: [1,2,3,4,5,10][Math.floor(Math.random() * 6)],
     };
+    if (variants[id]) {
+        data.variant_sku = variants[id][Math.floor(Math.random() * variants[id].length)];
+    }
     const payload = formEncode(data);
     http.post(
         `${BASE_URL}/cart`,
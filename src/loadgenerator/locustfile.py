@@ -43,6 +43,11 @@ products = [
     'LS4PSXUNUM',
     'OLJCESPC7Z']
 
+# SKUs of the products that must be bought in one of their variants.
+variants = {
+    '66VCHSJNUP': ['66VCHSJNUP-XS', '66VCHSJNUP-S', '66VCHSJNUP-M', '66VCHSJNUP-L', '66VCHSJNUP-XL'],
+    'L9ECAV7KIM': ['L9ECAV7KIM-8', 'L9ECAV7KIM-9', 'L9ECAV7KIM-10', 'L9ECAV7KIM-11', 'L9ECAV7KIM-12', 'L9ECAV7KIM-13']}
+
 def index(l):
     l.client.get("/")
 
@@ -60,9 +65,12 @@ def viewCart(l):
 def addToCart(l):
     product = random.choice(products)
     l.client.get("/product/" + product)
-    l.client.post("/cart", {
+    item = {
         'product_id': product,
-        'quantity': random.choice([1,2,3,4,5,10])})
+        'quantity': random.choice([1,2,3,4,5,10])}
+    if product in variants:
+        item['variant_sku'] = random.choice(variants[product])
+    l.client.post("/cart", item)
 
 def checkout(l):
     addToCart(l)
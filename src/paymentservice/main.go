@@ -11,7 +11,9 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/norun9/microservices-demo-ambient/chaos"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"github.com/norun9/microservices-demo-ambient/redact"
 	"github.com/norun9/microservices-demo-ambient/src/paymentservice/services"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
@@ -32,7 +34,7 @@ func main() {
 
 	// Configure logging.
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds | log.Lshortfile)
-	log.SetOutput(os.Stderr)
+	log.SetOutput(redact.NewWriter(os.Stderr))
 
 	// ----------------------------------------------------------------
 	// 1) Initialize OpenTelemetry TracerProvider.
@@ -73,10 +75,17 @@ func main() {
 	}
 	log.Println("Successfully created TCP listener")
 
+	// Inject the faults of CHAOS_RULES_FILE, if set.
+	chaosInjector, err := chaos.FromEnv(nil)
+	if err != nil {
+		log.Fatalf("failed to load chaos rules: %v", err)
+	}
+	defer chaosInjector.Close()
+
 	// Add OTel interceptor to gRPC server.
-	grpcServer := grpc.NewServer(
+	grpcServer := grpc.NewServer(append([]grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
+	}, chaosInjector.ServerOptions()...)...)
 	log.Println("Created gRPC server with OpenTelemetry interceptors")
 
 	// Register PaymentService and HealthCheckService.
@@ -136,8 +145,8 @@ func initTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	// 3) Build TracerProvider.
-	bsp := sdktrace.NewBatchSpanProcessor(exporter)
+	// 3) Build TracerProvider. Spans are scrubbed of card data before export.
+	bsp := sdktrace.NewBatchSpanProcessor(redact.NewSpanExporter(exporter))
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Consider TraceIDRatioBased for production.
 		sdktrace.WithResource(res),
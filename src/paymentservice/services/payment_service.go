@@ -2,9 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,51 +15,139 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // PaymentService implements the gRPC PaymentService.
 type PaymentService struct {
 	pb.UnimplementedPaymentServiceServer
+	vault  *Vault
 	tracer trace.Tracer
 }
 
-// NewPaymentService constructor.
+// NewPaymentService constructor. The card vault is keyed with the base64
+// PAYMENT_VAULT_KEY environment variable, or a random key if it is unset, and
+// its tokens expire after PAYMENT_TOKEN_TTL, or DefaultTokenTTL if unset.
 func NewPaymentService() (*PaymentService, error) {
+	keyEnv := os.Getenv("PAYMENT_VAULT_KEY")
+	if keyEnv == "" {
+		log.Println("PAYMENT_VAULT_KEY not set, using a random card vault key")
+	}
+	var ttl time.Duration
+	if s := os.Getenv("PAYMENT_TOKEN_TTL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid PAYMENT_TOKEN_TTL %q", s)
+		}
+		ttl = d
+	}
+	log.Println("payment tokens are kept in memory: they are lost on restart and unknown to other replicas")
+	vault, err := NewVaultFromEnv(keyEnv, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create card vault: %w", err)
+	}
 	return &PaymentService{
+		vault:  vault,
 		tracer: otel.Tracer("paymentservice"),
 	}, nil
 }
 
+// TokenizeCard RPC: validates a card and exchanges it for a payment token.
+func (p *PaymentService) TokenizeCard(ctx context.Context, req *pb.TokenizeCardRequest) (*pb.TokenizeCardResponse, error) {
+	_, span := p.tracer.Start(ctx, "TokenizeCard")
+	defer span.End()
+
+	if req.GetCreditCard() == nil {
+		return nil, status.Error(codes.InvalidArgument, "credit card is required")
+	}
+	card := normalizeCard(req.GetCreditCard())
+	cardType := getCardType(card.CreditCardNumber)
+	span.SetAttributes(attribute.String("credit_card.type", cardType))
+
+	if err := p.validateCreditCard(card); err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	token, err := p.vault.Tokenize(card)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, status.Errorf(codes.Internal, "failed to tokenize card: %v", err)
+	}
+	log.Printf("PaymentService#TokenizeCard issued token for credit_card_type=%s", cardType)
+
+	return &pb.TokenizeCardResponse{
+		PaymentToken: token,
+		CardType:     cardType,
+	}, nil
+}
+
+// GetCardDetails RPC: returns the type and BIN of the card behind a payment
+// token, so that checkout can screen tokenized orders for fraud.
+func (p *PaymentService) GetCardDetails(ctx context.Context, req *pb.GetCardDetailsRequest) (*pb.GetCardDetailsResponse, error) {
+	_, span := p.tracer.Start(ctx, "GetCardDetails")
+	defer span.End()
+
+	card, err := p.vault.Detokenize(req.GetPaymentToken())
+	if errors.Is(err, ErrUnknownToken) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to read card from vault: %v", err)
+	}
+	cardType := getCardType(card.CreditCardNumber)
+	span.SetAttributes(attribute.String("credit_card.type", cardType))
+
+	bin := card.CreditCardNumber
+	if len(bin) > 6 {
+		bin = bin[:6]
+	}
+	return &pb.GetCardDetailsResponse{
+		CardType: cardType,
+		Bin:      bin,
+	}, nil
+}
+
 // Charge RPC: processes a credit card charge.
 func (p *PaymentService) Charge(ctx context.Context, req *pb.ChargeRequest) (*pb.ChargeResponse, error) {
 	_, span := p.tracer.Start(ctx, "Charge")
 	defer span.End()
 
-	log.Printf("PaymentService#Charge invoked with request: amount=%v, credit_card_number=%s",
-		req.Amount, maskCreditCard(req.CreditCard.CreditCardNumber))
+	card, err := p.resolveCard(req)
+	if err != nil {
+		span.SetAttributes(attribute.String("error", err.Error()))
+		return nil, err
+	}
+	cardType := getCardType(card.CreditCardNumber)
+
+	// Never log or record any part of the card number or the CVV; the card
+	// type is enough to correlate failures.
+	log.Printf("PaymentService#Charge invoked with request: amount=%v, credit_card_type=%s, tokenized=%t",
+		req.Amount, cardType, req.GetPaymentToken() != "")
 
 	span.SetAttributes(
-		attribute.String("payment.currency", req.Amount.CurrencyCode),
-		attribute.Int64("payment.units", req.Amount.Units),
-		attribute.Int64("payment.nanos", int64(req.Amount.Nanos)),
-		attribute.String("credit_card.type", getCardType(req.CreditCard.CreditCardNumber)),
+		attribute.String("payment.currency", req.Amount.GetCurrencyCode()),
+		attribute.Int64("payment.units", req.Amount.GetUnits()),
+		attribute.Int64("payment.nanos", int64(req.Amount.GetNanos())),
+		attribute.String("credit_card.type", cardType),
+		attribute.Bool("payment.tokenized", req.GetPaymentToken() != ""),
 	)
 
 	// Validate credit card.
-	if err := p.validateCreditCard(req.CreditCard); err != nil {
+	if err := p.validateCreditCard(card); err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
-		return nil, err
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
 	// Generate transaction ID.
 	transactionID := uuid.New().String()
 
-	log.Printf("Transaction processed: %s ending %s Amount: %s%d.%02d",
-		getCardType(req.CreditCard.CreditCardNumber),
-		req.CreditCard.CreditCardNumber[len(req.CreditCard.CreditCardNumber)-4:],
-		req.Amount.CurrencyCode,
-		req.Amount.Units,
-		req.Amount.Nanos/10000000)
+	log.Printf("Transaction processed: %s Amount: %s%d.%02d",
+		cardType,
+		req.Amount.GetCurrencyCode(),
+		req.Amount.GetUnits(),
+		req.Amount.GetNanos()/10000000)
 
 	span.SetAttributes(attribute.String("transaction.id", transactionID))
 
@@ -65,6 +156,32 @@ func (p *PaymentService) Charge(ctx context.Context, req *pb.ChargeRequest) (*pb
 	}, nil
 }
 
+// resolveCard returns the card to charge: the one behind the payment token
+// if there is one, otherwise the raw card from the request.
+func (p *PaymentService) resolveCard(req *pb.ChargeRequest) (*pb.CreditCardInfo, error) {
+	if token := req.GetPaymentToken(); token != "" {
+		card, err := p.vault.Detokenize(token)
+		if errors.Is(err, ErrUnknownToken) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		} else if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read card from vault: %v", err)
+		}
+		return card, nil
+	}
+	if req.GetCreditCard() == nil {
+		return nil, status.Error(codes.InvalidArgument, "either payment token or credit card is required")
+	}
+	return normalizeCard(req.GetCreditCard()), nil
+}
+
+// normalizeCard returns a copy of card with spaces and dashes removed from
+// the number.
+func normalizeCard(card *pb.CreditCardInfo) *pb.CreditCardInfo {
+	out := proto.Clone(card).(*pb.CreditCardInfo)
+	out.CreditCardNumber = strings.NewReplacer(" ", "", "-", "").Replace(card.GetCreditCardNumber())
+	return out
+}
+
 // validateCreditCard validates the credit card.
 func (p *PaymentService) validateCreditCard(creditCard *pb.CreditCardInfo) error {
 	// Check card number format.
@@ -124,11 +241,3 @@ func getCardType(cardNumber string) string {
 	// Other card types.
 	return "unknown"
 }
-
-// maskCreditCard masks the credit card number.
-func maskCreditCard(cardNumber string) string {
-	if len(cardNumber) < 4 {
-		return "****"
-	}
-	return "****" + cardNumber[len(cardNumber)-4:]
-}
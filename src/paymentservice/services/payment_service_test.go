@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"github.com/norun9/microservices-demo-ambient/redact"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestChargeDoesNotLeakCardData runs Charge with log and span capture wired
+// the same way main does and scans everything that was emitted for the card
+// number, a masked card number and the CVV.
+func TestChargeDoesNotLeakCardData(t *testing.T) {
+	const (
+		pan = "4432801561520454"
+		cvv = "672"
+	)
+
+	var logs bytes.Buffer
+	log.SetOutput(redact.NewWriter(&logs))
+	defer log.SetOutput(os.Stderr)
+
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(redact.NewSpanExporter(spans)))
+	otel.SetTracerProvider(tp)
+	defer tp.Shutdown(context.Background())
+
+	svc, err := NewPaymentService()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card := &pb.CreditCardInfo{
+		CreditCardNumber:          pan,
+		CreditCardCvv:             672,
+		CreditCardExpirationYear:  int32(time.Now().Year() + 1),
+		CreditCardExpirationMonth: 1,
+	}
+	amount := &pb.Money{CurrencyCode: "USD", Units: 19, Nanos: 990000000}
+	if _, err := svc.Charge(context.Background(), &pb.ChargeRequest{Amount: amount, CreditCard: card}); err != nil {
+		t.Fatalf("Charge() failed: %v", err)
+	}
+
+	expired := &pb.CreditCardInfo{
+		CreditCardNumber:          pan,
+		CreditCardCvv:             672,
+		CreditCardExpirationYear:  2000,
+		CreditCardExpirationMonth: 1,
+	}
+	if _, err := svc.Charge(context.Background(), &pb.ChargeRequest{Amount: amount, CreditCard: expired}); err == nil {
+		t.Fatal("Charge() with an expired card succeeded")
+	}
+
+	// Careless callers that dump the whole request must be caught as well.
+	log.Printf("request: %v", &pb.ChargeRequest{Amount: amount, CreditCard: card})
+
+	var out strings.Builder
+	out.Write(logs.Bytes())
+	for _, s := range spans.GetSpans() {
+		fmt.Fprintf(&out, "%s %v %v %v\n", s.Name, s.Attributes, s.Events, s.Status)
+	}
+	if out.Len() == 0 {
+		t.Fatal("nothing was captured")
+	}
+
+	for _, leak := range []*regexp.Regexp{
+		regexp.MustCompile(pan),
+		regexp.MustCompile(`\*+` + pan[len(pan)-4:]),
+		regexp.MustCompile(`ending ` + pan[len(pan)-4:]),
+		regexp.MustCompile(`\b` + cvv + `\b`),
+	} {
+		if leak.MatchString(out.String()) {
+			t.Errorf("captured output matches %q:\n%s", leak, out.String())
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// paymentservice-go/services/vault.go
+
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// ErrUnknownToken is returned when a token is not in the vault.
+var ErrUnknownToken = errors.New("unknown payment token")
+
+// DefaultTokenTTL is how long a token stays valid when none is configured.
+// Checkout charges a token within minutes of the frontend issuing it.
+const DefaultTokenTTL = time.Hour
+
+// sealedRecord is a vault entry: the encrypted vaultRecord, the fingerprint
+// of the card it holds and when its token expires.
+type sealedRecord struct {
+	data        []byte // nonce||ciphertext
+	fingerprint string
+	expires     time.Time
+}
+
+// vaultRecord is the plaintext stored behind a token. The CVV is never
+// stored.
+type vaultRecord struct {
+	Number          string `json:"number"`
+	ExpirationYear  int32  `json:"exp_year"`
+	ExpirationMonth int32  `json:"exp_month"`
+}
+
+// Vault keeps card details encrypted with AES-GCM and hands out opaque
+// tokens for them. Records are only kept in memory: tokens are lost when the
+// service restarts, and a token issued by one replica is unknown to the
+// others. Tokens expire ttl after they were last issued, and expired records
+// are dropped so the vault does not grow without bound. It is safe for
+// concurrent use.
+type Vault struct {
+	aead   cipher.AEAD
+	macKey []byte
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	sealed map[string]sealedRecord // token -> record
+	tokens map[string]string       // card fingerprint -> token
+}
+
+// NewVault returns a vault that encrypts with the given 32-byte key and
+// whose tokens are valid for ttl. A non-positive ttl uses DefaultTokenTTL.
+func NewVault(key []byte, ttl time.Duration) (*Vault, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("vault key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// Derive a separate key for fingerprints so they reveal nothing about
+	// the encryption key.
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("payment-vault-fingerprint"))
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &Vault{
+		aead:   aead,
+		macKey: mac.Sum(nil),
+		ttl:    ttl,
+		now:    time.Now,
+		sealed: make(map[string]sealedRecord),
+		tokens: make(map[string]string),
+	}, nil
+}
+
+// NewVaultFromEnv builds a vault from a base64-encoded 32-byte key. An empty
+// value generates a random key.
+func NewVaultFromEnv(encodedKey string, ttl time.Duration) (*Vault, error) {
+	if encodedKey == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		return NewVault(key, ttl)
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault key: %w", err)
+	}
+	return NewVault(key, ttl)
+}
+
+// Tokenize stores the card and returns its token. Tokenizing the same card
+// again before its token expires returns the same token and extends it.
+func (v *Vault) Tokenize(card *pb.CreditCardInfo) (string, error) {
+	rec := vaultRecord{
+		Number:          card.GetCreditCardNumber(),
+		ExpirationYear:  card.GetCreditCardExpirationYear(),
+		ExpirationMonth: card.GetCreditCardExpirationMonth(),
+	}
+	plain, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, v.macKey)
+	mac.Write(plain)
+	fingerprint := hex.EncodeToString(mac.Sum(nil))
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := v.now()
+	v.sweep(now)
+	if token, ok := v.tokens[fingerprint]; ok {
+		rec := v.sealed[token]
+		rec.expires = now.Add(v.ttl)
+		v.sealed[token] = rec
+		return token, nil
+	}
+
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	token := "tok_" + hex.EncodeToString(id)
+	// Bind the ciphertext to its token so records cannot be swapped.
+	v.sealed[token] = sealedRecord{
+		data:        v.aead.Seal(nonce, nonce, plain, []byte(token)),
+		fingerprint: fingerprint,
+		expires:     now.Add(v.ttl),
+	}
+	v.tokens[fingerprint] = token
+	return token, nil
+}
+
+// sweep drops the records whose tokens expired before now. v.mu must be
+// held.
+func (v *Vault) sweep(now time.Time) {
+	for token, rec := range v.sealed {
+		if !now.Before(rec.expires) {
+			v.remove(token, rec)
+		}
+	}
+}
+
+// remove deletes token and its record. v.mu must be held.
+func (v *Vault) remove(token string, rec sealedRecord) {
+	delete(v.sealed, token)
+	delete(v.tokens, rec.fingerprint)
+}
+
+// Detokenize returns the card stored behind token. The CVV is not part of
+// the result. An expired token is dropped and reported as unknown.
+func (v *Vault) Detokenize(token string) (*pb.CreditCardInfo, error) {
+	v.mu.Lock()
+	sealed, ok := v.sealed[token]
+	if ok && !v.now().Before(sealed.expires) {
+		v.remove(token, sealed)
+		ok = false
+	}
+	v.mu.Unlock()
+	if !ok {
+		return nil, ErrUnknownToken
+	}
+
+	n := v.aead.NonceSize()
+	plain, err := v.aead.Open(nil, sealed.data[:n], sealed.data[n:], []byte(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt vault record: %w", err)
+	}
+	var rec vaultRecord
+	if err := json.Unmarshal(plain, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode vault record: %w", err)
+	}
+	return &pb.CreditCardInfo{
+		CreditCardNumber:          rec.Number,
+		CreditCardExpirationYear:  rec.ExpirationYear,
+		CreditCardExpirationMonth: rec.ExpirationMonth,
+	}, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	v, err := NewVault(bytes.Repeat([]byte{7}, 32), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := &pb.CreditCardInfo{
+		CreditCardNumber:          "4432801561520454",
+		CreditCardCvv:             672,
+		CreditCardExpirationYear:  2030,
+		CreditCardExpirationMonth: 1,
+	}
+
+	token, err := v.Tokenize(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, "tok_") || strings.Contains(token, card.CreditCardNumber) {
+		t.Errorf("Tokenize() = %q, want an opaque tok_ token", token)
+	}
+	again, err := v.Tokenize(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != token {
+		t.Errorf("Tokenize() of the same card = %q, want %q", again, token)
+	}
+	other, err := v.Tokenize(&pb.CreditCardInfo{CreditCardNumber: "5555555555554444", CreditCardExpirationYear: 2030, CreditCardExpirationMonth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == token {
+		t.Error("Tokenize() returned the same token for different cards")
+	}
+
+	got, err := v.Detokenize(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetCreditCardNumber() != card.CreditCardNumber || got.GetCreditCardExpirationYear() != 2030 || got.GetCreditCardExpirationMonth() != 1 {
+		t.Errorf("Detokenize() = %v, want %v", got, card)
+	}
+	if got.GetCreditCardCvv() != 0 {
+		t.Errorf("Detokenize() returned CVV %d, the vault must not store it", got.GetCreditCardCvv())
+	}
+
+	for _, rec := range v.sealed {
+		if bytes.Contains(rec.data, []byte(card.CreditCardNumber)) {
+			t.Error("vault stores the card number in plaintext")
+		}
+	}
+
+	if _, err := v.Detokenize("tok_unknown"); !errors.Is(err, ErrUnknownToken) {
+		t.Errorf("Detokenize(unknown) error = %v, want %v", err, ErrUnknownToken)
+	}
+}
+
+func TestVaultExpiresTokens(t *testing.T) {
+	v, err := NewVault(bytes.Repeat([]byte{7}, 32), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	v.now = func() time.Time { return now }
+	card := &pb.CreditCardInfo{CreditCardNumber: "4432801561520454", CreditCardExpirationYear: 2031, CreditCardExpirationMonth: 1}
+
+	token, err := v.Tokenize(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(50 * time.Second)
+	if again, _ := v.Tokenize(card); again != token {
+		t.Errorf("Tokenize() before expiry = %q, want %q", again, token)
+	}
+	// Tokenizing again extended the token, so it outlives the first minute.
+	now = now.Add(50 * time.Second)
+	if _, err := v.Detokenize(token); err != nil {
+		t.Errorf("Detokenize() of an extended token failed: %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := v.Detokenize(token); !errors.Is(err, ErrUnknownToken) {
+		t.Errorf("Detokenize(expired) error = %v, want %v", err, ErrUnknownToken)
+	}
+	if len(v.sealed) != 0 || len(v.tokens) != 0 {
+		t.Errorf("vault kept %d records and %d fingerprints after expiry", len(v.sealed), len(v.tokens))
+	}
+
+	// Expired records are swept when new cards are tokenized.
+	if _, err := v.Tokenize(card); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := v.Tokenize(&pb.CreditCardInfo{CreditCardNumber: "5555555555554444", CreditCardExpirationYear: 2031, CreditCardExpirationMonth: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.sealed) != 1 || len(v.tokens) != 1 {
+		t.Errorf("vault kept %d records and %d fingerprints, want 1 of each", len(v.sealed), len(v.tokens))
+	}
+}
+
+func TestNewVaultRejectsShortKey(t *testing.T) {
+	if _, err := NewVault([]byte("short"), 0); err == nil {
+		t.Error("NewVault() accepted a 5-byte key")
+	}
+	if _, err := NewVaultFromEnv("not base64!", 0); err == nil {
+		t.Error("NewVaultFromEnv() accepted an invalid key")
+	}
+}
+
+func TestChargeWithToken(t *testing.T) {
+	svc, err := NewPaymentService()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tok, err := svc.TokenizeCard(ctx, &pb.TokenizeCardRequest{CreditCard: &pb.CreditCardInfo{
+		CreditCardNumber:          "4432-8015-6152-0454",
+		CreditCardCvv:             672,
+		CreditCardExpirationYear:  int32(time.Now().Year() + 1),
+		CreditCardExpirationMonth: 1,
+	}})
+	if err != nil {
+		t.Fatalf("TokenizeCard() failed: %v", err)
+	}
+	if tok.GetCardType() != "visa" {
+		t.Errorf("TokenizeCard() card type = %q, want visa", tok.GetCardType())
+	}
+
+	details, err := svc.GetCardDetails(ctx, &pb.GetCardDetailsRequest{PaymentToken: tok.GetPaymentToken()})
+	if err != nil {
+		t.Fatalf("GetCardDetails() failed: %v", err)
+	}
+	if details.GetBin() != "443280" || details.GetCardType() != "visa" {
+		t.Errorf("GetCardDetails() = %v, want visa 443280", details)
+	}
+	if _, err := svc.GetCardDetails(ctx, &pb.GetCardDetailsRequest{PaymentToken: "tok_forged"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetCardDetails() with unknown token = %v, want NotFound", err)
+	}
+
+	amount := &pb.Money{CurrencyCode: "USD", Units: 10}
+	if _, err := svc.Charge(ctx, &pb.ChargeRequest{Amount: amount, PaymentToken: tok.GetPaymentToken()}); err != nil {
+		t.Errorf("Charge() with token failed: %v", err)
+	}
+	if _, err := svc.Charge(ctx, &pb.ChargeRequest{Amount: amount, PaymentToken: "tok_forged"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Charge() with unknown token = %v, want InvalidArgument", err)
+	}
+	if _, err := svc.Charge(ctx, &pb.ChargeRequest{Amount: amount}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Charge() without card = %v, want InvalidArgument", err)
+	}
+
+	later := time.Now().Add(DefaultTokenTTL + time.Minute)
+	svc.vault.now = func() time.Time { return later }
+	if _, err := svc.GetCardDetails(ctx, &pb.GetCardDetailsRequest{PaymentToken: tok.GetPaymentToken()}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetCardDetails() with expired token = %v, want NotFound", err)
+	}
+	if _, err := svc.Charge(ctx, &pb.ChargeRequest{Amount: amount, PaymentToken: tok.GetPaymentToken()}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Charge() with expired token = %v, want InvalidArgument", err)
+	}
+
+	_, err = svc.TokenizeCard(ctx, &pb.TokenizeCardRequest{CreditCard: &pb.CreditCardInfo{
+		CreditCardNumber:          "4432801561520454",
+		CreditCardExpirationYear:  2000,
+		CreditCardExpirationMonth: 1,
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("TokenizeCard() with expired card = %v, want InvalidArgument", err)
+	}
+}
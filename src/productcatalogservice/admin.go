@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// productIDPattern matches product IDs such as "OLJCESPC7Z".
+var productIDPattern = regexp.MustCompile(`^[A-Z0-9]{10}$`)
+
+// knownCategories are the categories products may be filed under.
+var knownCategories = map[string]bool{
+	"accessories": true,
+	"beauty":      true,
+	"clothing":    true,
+	"decor":       true,
+	"footwear":    true,
+	"hair":        true,
+	"home":        true,
+	"kitchen":     true,
+	"tops":        true,
+}
+
+// productEtag returns a version of p that changes whenever any of its
+// fields, other than the etag itself, does.
+func productEtag(p *pb.Product) string {
+	c := proto.Clone(p).(*pb.Product)
+	c.Etag = ""
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(c)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// validateProduct checks the fields of a product sent to the admin RPCs. It
+// returns the violations as BadRequest field violations.
+func validateProduct(p *pb.Product) []*errdetails.BadRequest_FieldViolation {
+	var violations []*errdetails.BadRequest_FieldViolation
+	violate := func(field, format string, args ...interface{}) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       strings.TrimSuffix("product."+field, "."),
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+	if p == nil {
+		violate("", "product is required")
+		return violations
+	}
+	if !productIDPattern.MatchString(p.GetId()) {
+		violate("id", "%q is not ten upper-case letters and digits", p.GetId())
+	}
+	if p.GetName() == "" {
+		violate("name", "name is required")
+	}
+	price := p.GetPriceUsd()
+	switch {
+	case price == nil:
+		violate("price_usd", "price is required")
+	case price.GetCurrencyCode() != "USD":
+		violate("price_usd.currency_code", "price must be in USD, not %q", price.GetCurrencyCode())
+	case price.GetUnits() < 0 || price.GetNanos() < 0:
+		violate("price_usd", "price must not be negative")
+	case price.GetNanos() > 999_999_999:
+		violate("price_usd.nanos", "nanos must be less than a unit")
+	}
+	for i, c := range p.GetCategories() {
+		if !knownCategories[c] {
+			violate(fmt.Sprintf("categories[%d]", i), "unknown category %q", c)
+		}
+	}
+	skus := make(map[string]bool, len(p.GetVariants()))
+	for i, v := range p.GetVariants() {
+		field := fmt.Sprintf("variants[%d]", i)
+		switch {
+		case v.GetSku() == "":
+			violate(field+".sku", "SKU is required")
+		case skus[v.GetSku()]:
+			violate(field+".sku", "SKU %q is used by another variant", v.GetSku())
+		}
+		skus[v.GetSku()] = true
+		if len(v.GetAttributes()) == 0 {
+			violate(field+".attributes", "attributes are required to tell variants apart")
+		}
+		if price := v.GetPriceUsd(); price != nil {
+			switch {
+			case price.GetCurrencyCode() != "USD":
+				violate(field+".price_usd.currency_code", "price must be in USD, not %q", price.GetCurrencyCode())
+			case price.GetUnits() < 0 || price.GetNanos() < 0:
+				violate(field+".price_usd", "price must not be negative")
+			case price.GetNanos() > 999_999_999:
+				violate(field+".price_usd.nanos", "nanos must be less than a unit")
+			}
+		}
+	}
+	if p.GetWeightGrams() < 0 {
+		violate("weight_grams", "weight must not be negative")
+	}
+	if d := p.GetDimensions(); d.GetLengthMm() < 0 || d.GetWidthMm() < 0 || d.GetHeightMm() < 0 {
+		violate("dimensions", "dimensions must not be negative")
+	}
+	return violations
+}
+
+func invalidProductError(violations []*errdetails.BadRequest_FieldViolation) error {
+	st, err := status.New(codes.InvalidArgument, "invalid product: "+violations[0].Description).
+		WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid product")
+	}
+	return st.Err()
+}
+
+// checkEtag returns an InvalidArgument error if etag is not set, and an
+// Aborted error if it is not the etag of p, so that changes never overwrite
+// concurrent ones.
+func checkEtag(p *pb.Product, etag string) error {
+	if etag == "" {
+		return status.Errorf(codes.InvalidArgument, "the etag of product %s is required", p.GetId())
+	}
+	if etag != p.GetEtag() {
+		return status.Errorf(codes.Aborted, "product %s was changed concurrently: etag %q does not match %q", p.GetId(), etag, p.GetEtag())
+	}
+	return nil
+}
+
+// checkSKUs returns an AlreadyExists error if another product in products
+// has a variant with one of the SKUs of p.
+func checkSKUs(products []*pb.Product, p *pb.Product) error {
+	for _, other := range products {
+		if other.GetId() == p.GetId() {
+			continue
+		}
+		for _, v := range other.GetVariants() {
+			if slices.ContainsFunc(p.GetVariants(), func(pv *pb.ProductVariant) bool { return pv.GetSku() == v.GetSku() }) {
+				return status.Errorf(codes.AlreadyExists, "SKU %s is used by product %s", v.GetSku(), other.GetId())
+			}
+		}
+	}
+	return nil
+}
+
+// changeCatalog applies change to a copy of the current products. change
+// checks the products and saves its change to the catalog source, and
+// changeCatalog then swaps the result in as the current catalog. Changes are
+// serialized with each other and with reloads.
+func (p *productCatalog) changeCatalog(change func(products []*pb.Product) ([]*pb.Product, error)) error {
+	if p.source == nil {
+		return status.Error(codes.FailedPrecondition, "the catalog is read-only")
+	}
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+
+	products, err := change(slices.Clone(parseCatalog().products))
+	if err != nil {
+		return err
+	}
+	current.Store(newCatalogSnapshot(products))
+	return nil
+}
+
+// saveError logs a failure to save a change to the catalog source and
+// returns it as an Internal error.
+func (p *productCatalog) saveError(err error) error {
+	log.Errorf("failed to save the catalog to %v: %v", p.source, err)
+	return status.Errorf(codes.Internal, "failed to save the catalog: %v", err)
+}
+
+func (p *productCatalog) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.Product, error) {
+	_, span := tracer.Start(ctx, "CreateProduct")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("request.product_id", req.GetProduct().GetId()),
+		attribute.String("request.type", "create"),
+	)
+
+	if violations := validateProduct(req.Product); len(violations) > 0 {
+		return nil, invalidProductError(violations)
+	}
+	created := proto.Clone(req.Product).(*pb.Product)
+	created.Etag = productEtag(created)
+	err := p.changeCatalog(func(products []*pb.Product) ([]*pb.Product, error) {
+		if slices.ContainsFunc(products, func(old *pb.Product) bool { return old.Id == created.Id }) {
+			return nil, status.Errorf(codes.AlreadyExists, "product %s already exists", created.Id)
+		}
+		if err := checkSKUs(products, created); err != nil {
+			return nil, err
+		}
+		if err := p.source.Put(created); err != nil {
+			return nil, p.saveError(err)
+		}
+		return append(products, created), nil
+	})
+	if err != nil {
+		span.SetAttributes(attribute.String("error.code", status.Code(err).String()))
+		return nil, err
+	}
+
+	log.Infof("created product %s", created.Id)
+	return created, nil
+}
+
+func (p *productCatalog) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.Product, error) {
+	_, span := tracer.Start(ctx, "UpdateProduct")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("request.product_id", req.GetProduct().GetId()),
+		attribute.String("request.type", "update"),
+	)
+
+	if violations := validateProduct(req.Product); len(violations) > 0 {
+		return nil, invalidProductError(violations)
+	}
+	updated := proto.Clone(req.Product).(*pb.Product)
+	updated.Etag = productEtag(updated)
+	err := p.changeCatalog(func(products []*pb.Product) ([]*pb.Product, error) {
+		i := slices.IndexFunc(products, func(old *pb.Product) bool { return old.Id == updated.Id })
+		if i < 0 {
+			return nil, status.Errorf(codes.NotFound, "no product with ID %s", updated.Id)
+		}
+		if err := checkEtag(products[i], req.Product.Etag); err != nil {
+			return nil, err
+		}
+		if err := checkSKUs(products, updated); err != nil {
+			return nil, err
+		}
+		if err := p.source.Put(updated); err != nil {
+			return nil, p.saveError(err)
+		}
+		products[i] = updated
+		return products, nil
+	})
+	if err != nil {
+		span.SetAttributes(attribute.String("error.code", status.Code(err).String()))
+		return nil, err
+	}
+
+	log.Infof("updated product %s", updated.Id)
+	return updated, nil
+}
+
+func (p *productCatalog) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.Empty, error) {
+	_, span := tracer.Start(ctx, "DeleteProduct")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("request.product_id", req.Id),
+		attribute.String("request.type", "delete"),
+	)
+
+	err := p.changeCatalog(func(products []*pb.Product) ([]*pb.Product, error) {
+		i := slices.IndexFunc(products, func(old *pb.Product) bool { return old.Id == req.Id })
+		if i < 0 {
+			return nil, status.Errorf(codes.NotFound, "no product with ID %s", req.Id)
+		}
+		if err := checkEtag(products[i], req.Etag); err != nil {
+			return nil, err
+		}
+		if len(products) == 1 {
+			return nil, status.Error(codes.FailedPrecondition, "cannot delete the last product of the catalog")
+		}
+		if err := p.source.Delete(req.Id); err != nil {
+			return nil, p.saveError(err)
+		}
+		return slices.Delete(products, i, i+1), nil
+	})
+	if err != nil {
+		span.SetAttributes(attribute.String("error.code", status.Code(err).String()))
+		return nil, err
+	}
+
+	log.Infof("deleted product %s", req.Id)
+	return &pb.Empty{}, nil
+}
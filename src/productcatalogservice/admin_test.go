@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestAdminRPCs(t *testing.T) {
+	previous := current.Load()
+	t.Cleanup(func() { current.Store(previous) })
+
+	source := newMemorySource(&pb.Product{Id: "MUG0000001", Name: "Mug", PriceUsd: usd(8), Categories: []string{"kitchen"}})
+	if _, err := reloadCatalog(source); err != nil {
+		t.Fatal(err)
+	}
+	svc := &productCatalog{source: source}
+	ctx := context.Background()
+
+	mug, err := svc.GetProduct(ctx, &pb.GetProductRequest{Id: "MUG0000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mug.Etag == "" {
+		t.Fatal("loaded product has no etag")
+	}
+
+	jar := &pb.Product{Id: "JAR0000001", Name: "Jar", PriceUsd: usd(12), Categories: []string{"kitchen", "decor"}}
+	created, err := svc.CreateProduct(ctx, &pb.CreateProductRequest{Product: jar})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Etag == "" || created.Name != "Jar" {
+		t.Errorf("CreateProduct() = %v", created)
+	}
+	if _, err := svc.CreateProduct(ctx, &pb.CreateProductRequest{Product: jar}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("creating an existing product: got %v, want AlreadyExists", err)
+	}
+
+	// Only the first of two updates from the same version succeeds.
+	first := proto.Clone(created).(*pb.Product)
+	first.Name = "Glass Jar"
+	updated, err := svc.UpdateProduct(ctx, &pb.UpdateProductRequest{Product: first})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Etag == created.Etag {
+		t.Error("etag did not change on update")
+	}
+	second := proto.Clone(created).(*pb.Product)
+	second.PriceUsd = usd(20)
+	if _, err := svc.UpdateProduct(ctx, &pb.UpdateProductRequest{Product: second}); status.Code(err) != codes.Aborted {
+		t.Errorf("updating a stale version: got %v, want Aborted", err)
+	}
+	// SKUs must be unique across the catalog.
+	sized := proto.Clone(jar).(*pb.Product)
+	sized.Id = "JAR0000002"
+	sized.Variants = []*pb.ProductVariant{{Sku: "JAR-S", Attributes: map[string]string{"size": "S"}}}
+	sizedCreated, err := svc.CreateProduct(ctx, &pb.CreateProductRequest{Product: sized})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sized.Id = "JAR0000003"
+	if _, err := svc.CreateProduct(ctx, &pb.CreateProductRequest{Product: sized}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("reusing a SKU: got %v, want AlreadyExists", err)
+	}
+	if _, err := svc.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: "JAR0000002", Etag: sizedCreated.Etag}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes without an etag could overwrite concurrent ones.
+	unversioned := proto.Clone(updated).(*pb.Product)
+	unversioned.Etag = ""
+	if _, err := svc.UpdateProduct(ctx, &pb.UpdateProductRequest{Product: unversioned}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("updating without an etag: got %v, want InvalidArgument", err)
+	}
+	if _, err := svc.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: "MUG0000001"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("deleting without an etag: got %v, want InvalidArgument", err)
+	}
+
+	missing := proto.Clone(jar).(*pb.Product)
+	missing.Id = "NONE000000"
+	if _, err := svc.UpdateProduct(ctx, &pb.UpdateProductRequest{Product: missing}); status.Code(err) != codes.NotFound {
+		t.Errorf("updating a missing product: got %v, want NotFound", err)
+	}
+
+	if _, err := svc.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: "MUG0000001", Etag: "stale"}); status.Code(err) != codes.Aborted {
+		t.Errorf("deleting with a stale etag: got %v, want Aborted", err)
+	}
+	if _, err := svc.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: "MUG0000001", Etag: mug.Etag}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: "MUG0000001"}); status.Code(err) != codes.NotFound {
+		t.Errorf("deleting a missing product: got %v, want NotFound", err)
+	}
+	if _, err := svc.DeleteProduct(ctx, &pb.DeleteProductRequest{Id: "JAR0000001", Etag: updated.Etag}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("deleting the last product: got %v, want FailedPrecondition", err)
+	}
+
+	// The changes were saved, with the same etags once reloaded.
+	want := parseCatalog().products
+	reloaded, err := reloadCatalog(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, reloaded.products, cmp.Comparer(proto.Equal)); diff != "" {
+		t.Errorf("reloaded catalog differs (-want +got):\n%s", diff)
+	}
+	if got := reloaded.Product("JAR0000001"); got.GetName() != "Glass Jar" || got.GetEtag() != updated.Etag {
+		t.Errorf("reloaded product = %v, want the updated one", got)
+	}
+
+	readOnly := &productCatalog{}
+	if _, err := readOnly.CreateProduct(ctx, &pb.CreateProductRequest{Product: missing}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("writing a read-only catalog: got %v, want FailedPrecondition", err)
+	}
+}
+
+func TestValidateProduct(t *testing.T) {
+	valid := func() *pb.Product {
+		return &pb.Product{
+			Id:         "OLJCESPC7Z",
+			Name:       "Sunglasses",
+			PriceUsd:   &pb.Money{CurrencyCode: "USD", Units: 19, Nanos: 990000000},
+			Categories: []string{"accessories"},
+		}
+	}
+	for _, tc := range []struct {
+		name   string
+		change func(p *pb.Product)
+		fields []string
+	}{
+		{"valid", func(p *pb.Product) {}, nil},
+		{"free", func(p *pb.Product) { p.PriceUsd = &pb.Money{CurrencyCode: "USD"} }, nil},
+		{"lower-case ID", func(p *pb.Product) { p.Id = "oljcespc7z" }, []string{"product.id"}},
+		{"short ID", func(p *pb.Product) { p.Id = "OLJ" }, []string{"product.id"}},
+		{"no name", func(p *pb.Product) { p.Name = "" }, []string{"product.name"}},
+		{"no price", func(p *pb.Product) { p.PriceUsd = nil }, []string{"product.price_usd"}},
+		{"negative price", func(p *pb.Product) { p.PriceUsd.Units = -1; p.PriceUsd.Nanos = 0 }, []string{"product.price_usd"}},
+		{"price in euros", func(p *pb.Product) { p.PriceUsd.CurrencyCode = "EUR" }, []string{"product.price_usd.currency_code"}},
+		{"unknown category", func(p *pb.Product) { p.Categories = []string{"accessories", "garden"} }, []string{"product.categories[1]"}},
+		{"negative weight", func(p *pb.Product) { p.WeightGrams = -1 }, []string{"product.weight_grams"}},
+		{"several", func(p *pb.Product) { p.Id = ""; p.Name = "" }, []string{"product.id", "product.name"}},
+		{"variants", func(p *pb.Product) {
+			p.Variants = []*pb.ProductVariant{
+				{Sku: "OLJCESPC7Z-S", Attributes: map[string]string{"size": "S"}},
+				{Sku: "OLJCESPC7Z-L", Attributes: map[string]string{"size": "L"}, PriceUsd: &pb.Money{CurrencyCode: "USD", Units: 21}},
+			}
+		}, nil},
+		{"invalid variants", func(p *pb.Product) {
+			p.Variants = []*pb.ProductVariant{
+				{Sku: "OLJCESPC7Z-S", Attributes: map[string]string{"size": "S"}},
+				{Sku: "OLJCESPC7Z-S", Attributes: map[string]string{"size": "M"}},
+				{Attributes: map[string]string{"size": "L"}, PriceUsd: &pb.Money{CurrencyCode: "EUR", Units: 21}},
+				{Sku: "OLJCESPC7Z-XL"},
+			}
+		}, []string{"product.variants[1].sku", "product.variants[2].sku", "product.variants[2].price_usd.currency_code", "product.variants[3].attributes"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := valid()
+			tc.change(p)
+			var fields []string
+			for _, v := range validateProduct(p) {
+				fields = append(fields, v.Field)
+			}
+			if diff := cmp.Diff(tc.fields, fields); diff != "" {
+				t.Errorf("violations (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	err := invalidProductError(validateProduct(nil))
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || len(st.Details()) != 1 {
+		t.Fatalf("got %v, want InvalidArgument with details", err)
+	}
+	if br, ok := st.Details()[0].(*errdetails.BadRequest); !ok || br.FieldViolations[0].Field != "product" {
+		t.Errorf("got details %v, want a violation of the product field", st.Details())
+	}
+}
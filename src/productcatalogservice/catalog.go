@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// catalogSnapshot is a loaded catalog with its indexes. A snapshot is never
+// modified once built, and loading the catalog swaps in a new one, so
+// requests can use the snapshot they started with without locking.
+type catalogSnapshot struct {
+	products   []*pb.Product
+	byID       map[string]*pb.Product
+	byCategory map[string][]*pb.Product
+	search     *searchIndex
+}
+
+func newCatalogSnapshot(products []*pb.Product) *catalogSnapshot {
+	s := &catalogSnapshot{
+		products:   products,
+		byID:       make(map[string]*pb.Product, len(products)),
+		byCategory: make(map[string][]*pb.Product),
+		search:     newSearchIndex(products),
+	}
+	for _, p := range products {
+		s.byID[p.GetId()] = p
+		for _, c := range p.GetCategories() {
+			c = strings.ToLower(c)
+			s.byCategory[c] = append(s.byCategory[c], p)
+		}
+	}
+	return s
+}
+
+// Product returns the product with the ID, or nil.
+func (s *catalogSnapshot) Product(id string) *pb.Product {
+	return s.byID[id]
+}
+
+// Category returns the products in a category, in catalog order. Categories
+// are matched regardless of case.
+func (s *catalogSnapshot) Category(name string) []*pb.Product {
+	return s.byCategory[strings.ToLower(name)]
+}
+
+// validateCatalog checks that products can be served: the catalog is not
+// empty, every product has a unique ID, a name and a price, and every variant
+// has a unique SKU and a price, if any, in USD.
+func validateCatalog(products []*pb.Product) error {
+	if len(products) == 0 {
+		return errors.New("catalog has no products")
+	}
+	seen := make(map[string]bool, len(products))
+	skus := make(map[string]bool)
+	for i, p := range products {
+		for j, v := range p.GetVariants() {
+			switch {
+			case v.GetSku() == "":
+				return fmt.Errorf("variant %d of product %s has no SKU", j, p.GetId())
+			case skus[v.GetSku()]:
+				return fmt.Errorf("SKU %s is not unique", v.GetSku())
+			case v.GetPriceUsd() != nil && v.GetPriceUsd().GetCurrencyCode() != "USD":
+				return fmt.Errorf("variant %s has no price in USD", v.GetSku())
+			}
+			skus[v.GetSku()] = true
+		}
+		switch {
+		case p.GetId() == "":
+			return fmt.Errorf("product %d has no ID", i)
+		case seen[p.GetId()]:
+			return fmt.Errorf("product ID %s is not unique", p.GetId())
+		case p.GetName() == "":
+			return fmt.Errorf("product %s has no name", p.GetId())
+		case p.GetPriceUsd().GetCurrencyCode() != "USD":
+			return fmt.Errorf("product %s has no price in USD", p.GetId())
+		}
+		seen[p.GetId()] = true
+	}
+	return nil
+}
+
+// emptyCatalog is served until a catalog has been loaded.
+var emptyCatalog = newCatalogSnapshot(nil)
+
+// current holds the catalog snapshot being served.
+var current atomic.Pointer[catalogSnapshot]
+
+// catalogLoaded is closed once a catalog has been loaded from the source.
+var (
+	catalogLoaded     = make(chan struct{})
+	catalogLoadedOnce sync.Once
+)
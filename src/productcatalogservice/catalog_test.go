@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+func TestCatalogSnapshot(t *testing.T) {
+	s := newCatalogSnapshot([]*pb.Product{
+		{Id: "a", Categories: []string{"kitchen"}},
+		{Id: "b", Categories: []string{"Kitchen", "decor"}},
+		{Id: "c"},
+	})
+	if p := s.Product("b"); p.GetId() != "b" {
+		t.Errorf("Product(b) = %v", p)
+	}
+	if p := s.Product("d"); p != nil {
+		t.Errorf("Product(d) = %v, want nil", p)
+	}
+	ids := func(ps []*pb.Product) []string {
+		var ids []string
+		for _, p := range ps {
+			ids = append(ids, p.Id)
+		}
+		return ids
+	}
+	for category, want := range map[string][]string{
+		"kitchen": {"a", "b"},
+		"KITCHEN": {"a", "b"},
+		"decor":   {"b"},
+		"garden":  nil,
+	} {
+		if diff := cmp.Diff(want, ids(s.Category(category))); diff != "" {
+			t.Errorf("Category(%q) (-want +got):\n%s", category, diff)
+		}
+	}
+}
+
+// useSyntheticCatalog serves a catalog of n products for the rest of the
+// benchmark.
+func useSyntheticCatalog(b *testing.B, n int) []*pb.Product {
+	products := make([]*pb.Product, n)
+	for i := range products {
+		products[i] = &pb.Product{
+			Id:          fmt.Sprintf("P%08d", i),
+			Name:        fmt.Sprintf("Product %d", i),
+			Description: "A synthetic product for benchmarks.",
+			PriceUsd:    &pb.Money{CurrencyCode: "USD", Units: int64(i % 100)},
+			Categories:  []string{fmt.Sprintf("category%d", i%50)},
+		}
+	}
+	previous := current.Load()
+	current.Store(newCatalogSnapshot(products))
+	b.Cleanup(func() { current.Store(previous) })
+	return products
+}
+
+func BenchmarkGetProduct(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			products := useSyntheticCatalog(b, n)
+			svc := &productCatalog{}
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				id := products[i%n].Id
+				if _, err := svc.GetProduct(ctx, &pb.GetProductRequest{Id: id}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkListProducts(b *testing.B) {
+	for _, n := range []int{10_000, 100_000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			useSyntheticCatalog(b, n)
+			svc := &productCatalog{}
+			ctx := context.Background()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				res, err := svc.ListProducts(ctx, &pb.Empty{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(res.Products) != n {
+					b.Fatalf("got %d products, want %d", len(res.Products), n)
+				}
+			}
+		})
+	}
+}
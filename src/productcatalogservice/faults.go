@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"path"
+	"sync"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// anyMethod is the method name of the rule applied to RPCs without a rule of
+// their own.
+const anyMethod = "*"
+
+// faultRules maps RPC method names, such as "GetProduct", or anyMethod to the
+// faults injected into their calls.
+type faultRules map[string]faultRule
+
+// faultRule describes the faults injected into calls of an RPC. Each call is
+// first delayed by a latency drawn from Latency. It then fails with ErrorCode
+// with probability ErrorRate, or else, with probability PartialRate, returns
+// only PartialFraction of the products it would have.
+type faultRule struct {
+	Latency         *latencyDistribution `json:"latency,omitempty"`
+	ErrorRate       float64              `json:"error_rate,omitempty"`
+	ErrorCode       faultCode            `json:"error_code,omitempty"`
+	PartialRate     float64              `json:"partial_rate,omitempty"`
+	PartialFraction float64              `json:"partial_fraction,omitempty"`
+}
+
+// defaultPartialFraction is the share of products kept by partial responses
+// when the rule does not say.
+const defaultPartialFraction = 0.5
+
+func (r faultRule) validate() error {
+	switch {
+	case r.ErrorRate < 0 || r.ErrorRate > 1:
+		return fmt.Errorf("error rate %v is not between 0 and 1", r.ErrorRate)
+	case r.ErrorRate > 0 && r.ErrorCode == faultCode(codes.OK):
+		return fmt.Errorf("error rate %v needs an error code", r.ErrorRate)
+	case r.PartialRate < 0 || r.PartialRate > 1:
+		return fmt.Errorf("partial rate %v is not between 0 and 1", r.PartialRate)
+	case r.PartialFraction < 0 || r.PartialFraction >= 1:
+		return fmt.Errorf("partial fraction %v is not at least 0 and less than 1", r.PartialFraction)
+	}
+	if r.Latency != nil {
+		return r.Latency.validate()
+	}
+	return nil
+}
+
+// latencyDistribution is the distribution delays are drawn from:
+//
+//	fixed        always Value
+//	uniform      between Min and Max
+//	normal       around Mean with Stddev, and never negative
+//	exponential  with Mean
+type latencyDistribution struct {
+	Distribution string   `json:"distribution"`
+	Value        duration `json:"value,omitempty"`
+	Min          duration `json:"min,omitempty"`
+	Max          duration `json:"max,omitempty"`
+	Mean         duration `json:"mean,omitempty"`
+	Stddev       duration `json:"stddev,omitempty"`
+}
+
+func (d *latencyDistribution) validate() error {
+	if d.Value < 0 || d.Min < 0 || d.Max < 0 || d.Mean < 0 || d.Stddev < 0 {
+		return fmt.Errorf("%s latency has a negative duration", d.Distribution)
+	}
+	switch d.Distribution {
+	case "fixed", "normal", "exponential":
+		return nil
+	case "uniform":
+		if d.Max < d.Min {
+			return fmt.Errorf("uniform latency has max %v below min %v", d.Max, d.Min)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown latency distribution %q", d.Distribution)
+	}
+}
+
+// sample draws a delay from the distribution.
+func (d *latencyDistribution) sample(rng randSource) time.Duration {
+	var v float64
+	switch d.Distribution {
+	case "fixed":
+		v = float64(d.Value)
+	case "uniform":
+		v = float64(d.Min) + rng.Float64()*float64(d.Max-d.Min)
+	case "normal":
+		v = float64(d.Mean) + rng.NormFloat64()*float64(d.Stddev)
+	case "exponential":
+		v = rng.ExpFloat64() * float64(d.Mean)
+	}
+	return time.Duration(math.Max(0, v))
+}
+
+// duration is a time.Duration written in JSON as a string such as "250ms".
+type duration time.Duration
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"250ms\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// faultCode is a gRPC status code written in JSON by name, such as
+// "UNAVAILABLE".
+type faultCode codes.Code
+
+// faultCodeNames are the names of the gRPC status codes, as in the gRPC
+// specification.
+var faultCodeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+func (c faultCode) MarshalJSON() ([]byte, error) {
+	name, ok := faultCodeNames[codes.Code(c)]
+	if !ok {
+		return nil, fmt.Errorf("unknown error code %d", c)
+	}
+	return json.Marshal(name)
+}
+
+func (c *faultCode) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return fmt.Errorf("error code must be a name such as \"UNAVAILABLE\": %w", err)
+	}
+	for code, n := range faultCodeNames {
+		if n == name {
+			*c = faultCode(code)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error code %q", name)
+}
+
+// randSource is the randomness faults are drawn from.
+type randSource interface {
+	Float64() float64
+	NormFloat64() float64
+	ExpFloat64() float64
+}
+
+// globalRand draws from the top-level functions of math/rand/v2, which are
+// safe for concurrent use.
+type globalRand struct{}
+
+func (globalRand) Float64() float64     { return rand.Float64() }
+func (globalRand) NormFloat64() float64 { return rand.NormFloat64() }
+func (globalRand) ExpFloat64() float64  { return rand.ExpFloat64() }
+
+// faultInjector injects the faults of its rules into RPCs. Rules can be
+// changed while it is in use.
+type faultInjector struct {
+	rng randSource
+
+	mu    sync.RWMutex
+	rules faultRules
+}
+
+func newFaultInjector(rules faultRules) (*faultInjector, error) {
+	f := &faultInjector{rng: globalRand{}}
+	if err := f.setRules(rules); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Rules returns a copy of the current rules.
+func (f *faultInjector) Rules() faultRules {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	rules := make(faultRules, len(f.rules))
+	for m, r := range f.rules {
+		rules[m] = r
+	}
+	return rules
+}
+
+// checkMethod returns an error unless method is an RPC of the product catalog
+// or anyMethod, so that a misspelt method does not silently go unused.
+func checkMethod(method string) error {
+	if method == anyMethod {
+		return nil
+	}
+	for _, m := range pb.ProductCatalogService_ServiceDesc.Methods {
+		if m.MethodName == method {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown method %q", method)
+}
+
+// setRules validates rules and replaces the current rules with them.
+func (f *faultInjector) setRules(rules faultRules) error {
+	for m, r := range rules {
+		if err := checkMethod(m); err != nil {
+			return err
+		}
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("rule for %s: %w", m, err)
+		}
+	}
+	c := make(faultRules, len(rules))
+	for m, r := range rules {
+		c[m] = r
+	}
+	f.mu.Lock()
+	f.rules = c
+	f.mu.Unlock()
+	return nil
+}
+
+// setRule validates rule and makes it the rule of method.
+func (f *faultInjector) setRule(method string, rule faultRule) error {
+	if err := checkMethod(method); err != nil {
+		return err
+	}
+	if err := rule.validate(); err != nil {
+		return fmt.Errorf("rule for %s: %w", method, err)
+	}
+	f.mu.Lock()
+	f.rules[method] = rule
+	f.mu.Unlock()
+	return nil
+}
+
+// deleteRule removes the rule of method, if any.
+func (f *faultInjector) deleteRule(method string) {
+	f.mu.Lock()
+	delete(f.rules, method)
+	f.mu.Unlock()
+}
+
+// rule returns the rule of method, falling back to the rule of anyMethod.
+func (f *faultInjector) rule(method string) (faultRule, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if r, ok := f.rules[method]; ok {
+		return r, true
+	}
+	r, ok := f.rules[anyMethod]
+	return r, ok
+}
+
+// UnaryInterceptor injects faults into unary RPCs of the product catalog and
+// records them as attributes of the server span. Delays end early, with the
+// status of the context, if the call is canceled or times out.
+func (f *faultInjector) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	service, method := path.Split(info.FullMethod)
+	if service != "/"+pb.ProductCatalogService_ServiceDesc.ServiceName+"/" {
+		return handler(ctx, req)
+	}
+	rule, ok := f.rule(method)
+	if !ok {
+		return handler(ctx, req)
+	}
+	span := trace.SpanFromContext(ctx)
+
+	if rule.Latency != nil {
+		delay := rule.Latency.sample(f.rng)
+		span.SetAttributes(attribute.String("fault.delay", delay.String()))
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if rule.ErrorRate > 0 && f.rng.Float64() < rule.ErrorRate {
+		code := codes.Code(rule.ErrorCode)
+		span.SetAttributes(attribute.String("fault.error_code", code.String()))
+		return nil, status.Errorf(code, "fault injected into %s", method)
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil || rule.PartialRate == 0 || f.rng.Float64() >= rule.PartialRate {
+		return resp, err
+	}
+	fraction := rule.PartialFraction
+	if fraction == 0 {
+		fraction = defaultPartialFraction
+	}
+	// Responses share their products with the catalog snapshot, so they are
+	// copied rather than changed.
+	switch r := resp.(type) {
+	case *pb.ListProductsResponse:
+		n := int(float64(len(r.Products)) * fraction)
+		span.SetAttributes(attribute.Int("fault.partial.count", n))
+		return &pb.ListProductsResponse{Products: r.Products[:n], TotalSize: r.TotalSize, NextPageToken: r.NextPageToken}, nil
+	case *pb.SearchProductsResponse:
+		n := int(float64(len(r.Results)) * fraction)
+		span.SetAttributes(attribute.Int("fault.partial.count", n))
+		return &pb.SearchProductsResponse{Results: r.Results[:n], TotalSize: r.TotalSize, NextPageToken: r.NextPageToken}, nil
+	}
+	return resp, nil
+}
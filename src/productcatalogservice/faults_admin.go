@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// faultAdminHandler serves the fault rules of f over HTTP:
+//
+//	GET    /faults           all rules
+//	PUT    /faults           replace all rules
+//	GET    /faults/{method}  the rule of a method
+//	PUT    /faults/{method}  set the rule of a method
+//	DELETE /faults/{method}  remove the rule of a method
+//
+// Methods are RPC names such as GetProduct, or * for the default rule.
+func faultAdminHandler(f *faultInjector) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /faults", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, f.Rules())
+	})
+	mux.HandleFunc("PUT /faults", func(w http.ResponseWriter, r *http.Request) {
+		var rules faultRules
+		if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+			http.Error(w, "invalid rules: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := f.setRules(rules); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Infof("fault rules replaced: %d rules", len(rules))
+		writeJSON(w, f.Rules())
+	})
+	mux.HandleFunc("GET /faults/{method}", func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := f.Rules()[r.PathValue("method")]
+		if !ok {
+			http.Error(w, "no rule for "+r.PathValue("method"), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, rule)
+	})
+	mux.HandleFunc("PUT /faults/{method}", func(w http.ResponseWriter, r *http.Request) {
+		var rule faultRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "invalid rule: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := f.setRule(r.PathValue("method"), rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Infof("fault rule for %s set", r.PathValue("method"))
+		writeJSON(w, rule)
+	})
+	mux.HandleFunc("DELETE /faults/{method}", func(w http.ResponseWriter, r *http.Request) {
+		f.deleteRule(r.PathValue("method"))
+		log.Infof("fault rule for %s removed", r.PathValue("method"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warnf("failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// constRand always draws the same values.
+type constRand struct{ uniform, normal, exp float64 }
+
+func (r constRand) Float64() float64     { return r.uniform }
+func (r constRand) NormFloat64() float64 { return r.normal }
+func (r constRand) ExpFloat64() float64  { return r.exp }
+
+func TestLatencySample(t *testing.T) {
+	rng := constRand{uniform: 0.25, normal: -1.5, exp: 2}
+	ms := func(n int) duration { return duration(time.Duration(n) * time.Millisecond) }
+	for _, tc := range []struct {
+		dist latencyDistribution
+		want time.Duration
+	}{
+		{latencyDistribution{Distribution: "fixed", Value: ms(30)}, 30 * time.Millisecond},
+		{latencyDistribution{Distribution: "uniform", Min: ms(100), Max: ms(200)}, 125 * time.Millisecond},
+		{latencyDistribution{Distribution: "normal", Mean: ms(100), Stddev: ms(20)}, 70 * time.Millisecond},
+		{latencyDistribution{Distribution: "normal", Mean: ms(10), Stddev: ms(20)}, 0},
+		{latencyDistribution{Distribution: "exponential", Mean: ms(50)}, 100 * time.Millisecond},
+	} {
+		if got := tc.dist.sample(rng); got != tc.want {
+			t.Errorf("%s.sample() = %v, want %v", tc.dist.Distribution, got, tc.want)
+		}
+	}
+}
+
+func TestFaultCodeJSON(t *testing.T) {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		b, err := json.Marshal(faultCode(c))
+		if err != nil {
+			t.Errorf("json.Marshal(%v) = %v", c, err)
+			continue
+		}
+		var got faultCode
+		if err := json.Unmarshal(b, &got); err != nil || got != faultCode(c) {
+			t.Errorf("json.Unmarshal(%s) = %v, %v, want %v", b, codes.Code(got), err, c)
+		}
+	}
+	if b, _ := json.Marshal(faultCode(codes.Canceled)); string(b) != `"CANCELLED"` {
+		t.Errorf("json.Marshal(Canceled) = %s, want \"CANCELLED\"", b)
+	}
+	if _, err := json.Marshal(faultCode(codes.Unauthenticated + 1)); err == nil {
+		t.Error("json.Marshal() of an unknown code = nil error")
+	}
+	for _, invalid := range []string{`"CANCELED"`, `"Unavailable"`, `14`} {
+		var c faultCode
+		if err := json.Unmarshal([]byte(invalid), &c); err == nil {
+			t.Errorf("json.Unmarshal(%s) = nil error", invalid)
+		}
+	}
+}
+
+func TestFaultRulesJSON(t *testing.T) {
+	const in = `{
+		"GetProduct": {"latency": {"distribution": "uniform", "min": "10ms", "max": "1s"}, "error_rate": 0.1, "error_code": "DEADLINE_EXCEEDED"},
+		"*": {"partial_rate": 0.5}
+	}`
+	var rules faultRules
+	if err := json.Unmarshal([]byte(in), &rules); err != nil {
+		t.Fatal(err)
+	}
+	want := faultRules{
+		"GetProduct": {
+			Latency:   &latencyDistribution{Distribution: "uniform", Min: duration(10 * time.Millisecond), Max: duration(time.Second)},
+			ErrorRate: 0.1,
+			ErrorCode: faultCode(codes.DeadlineExceeded),
+		},
+		anyMethod: {PartialRate: 0.5},
+	}
+	if diff := cmp.Diff(want, rules); diff != "" {
+		t.Errorf("rules (-want +got):\n%s", diff)
+	}
+	out, err := json.Marshal(rules["GetProduct"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(out), `{"latency":{"distribution":"uniform","min":"10ms","max":"1s"},"error_rate":0.1,"error_code":"DEADLINE_EXCEEDED"}`; got != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+
+	for _, invalid := range []faultRule{
+		{ErrorRate: 1.5, ErrorCode: faultCode(codes.Internal)},
+		{ErrorRate: 0.5},
+		{PartialRate: -1},
+		{PartialRate: 1, PartialFraction: 1},
+		{Latency: &latencyDistribution{Distribution: "pareto"}},
+		{Latency: &latencyDistribution{Distribution: "uniform", Min: duration(time.Second)}},
+		{Latency: &latencyDistribution{Distribution: "fixed", Value: duration(-time.Second)}},
+	} {
+		if err := invalid.validate(); err == nil {
+			t.Errorf("%+v.validate() = nil, want an error", invalid)
+		}
+	}
+}
+
+func TestFaultInterceptor(t *testing.T) {
+	products := []*pb.Product{{Id: "A"}, {Id: "B"}, {Id: "C"}, {Id: "D"}}
+	list := &pb.ListProductsResponse{Products: products, TotalSize: 4}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return list, nil }
+	info := func(method string) *grpc.UnaryServerInfo {
+		return &grpc.UnaryServerInfo{FullMethod: "/genproto.ProductCatalogService/" + method}
+	}
+
+	f, err := newFaultInjector(faultRules{
+		"GetProduct":   {ErrorRate: 1, ErrorCode: faultCode(codes.Unavailable)},
+		"ListProducts": {PartialRate: 1, PartialFraction: 0.25},
+		anyMethod:      {Latency: &latencyDistribution{Distribution: "fixed", Value: duration(time.Hour)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.rng = constRand{uniform: 0.5}
+	ctx := context.Background()
+
+	if _, err := f.UnaryInterceptor(ctx, nil, info("GetProduct"), handler); status.Code(err) != codes.Unavailable {
+		t.Errorf("GetProduct: got %v, want Unavailable", err)
+	}
+
+	resp, err := f.UnaryInterceptor(ctx, nil, info("ListProducts"), handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.(*pb.ListProductsResponse); len(got.Products) != 1 || got.TotalSize != 4 {
+		t.Errorf("ListProducts = %v, want 1 of 4 products", got)
+	}
+	if len(list.Products) != 4 {
+		t.Error("partial response changed the response of the handler")
+	}
+
+	// The hour-long delay of the default rule ends with the call.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.UnaryInterceptor(ctx, nil, info("SearchProducts"), handler); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("SearchProducts: got %v, want DeadlineExceeded", err)
+	}
+
+	// Other services, such as health checks, are left alone.
+	health := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	if _, err := f.UnaryInterceptor(ctx, nil, health, handler); err != nil {
+		t.Errorf("health check: %v", err)
+	}
+}
+
+func TestFaultAdminHandler(t *testing.T) {
+	f, err := newFaultInjector(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(faultAdminHandler(f))
+	defer srv.Close()
+
+	do := func(method, path, body string) int {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if code := do("PUT", "/faults/GetProduct", `{"error_rate": 0.2, "error_code": "INTERNAL"}`); code != http.StatusOK {
+		t.Fatalf("PUT rule: got status %d", code)
+	}
+	if got := f.Rules()["GetProduct"]; got.ErrorRate != 0.2 || codes.Code(got.ErrorCode) != codes.Internal {
+		t.Errorf("rule = %+v after PUT", got)
+	}
+	if code := do("PUT", "/faults/GetProduct", `{"error_rate": 2, "error_code": "INTERNAL"}`); code != http.StatusBadRequest {
+		t.Errorf("PUT invalid rule: got status %d, want 400", code)
+	}
+	for path, body := range map[string]string{
+		"/faults/GetProdcut": `{"error_rate": 0.2, "error_code": "INTERNAL"}`,
+		"/faults":            `{"GetProdcut": {"error_rate": 0.2, "error_code": "INTERNAL"}}`,
+	} {
+		if code := do("PUT", path, body); code != http.StatusBadRequest {
+			t.Errorf("PUT %s %s: got status %d, want 400", path, body, code)
+		}
+	}
+	if code := do("GET", "/faults/ListProducts", ""); code != http.StatusNotFound {
+		t.Errorf("GET missing rule: got status %d, want 404", code)
+	}
+	if code := do("PUT", "/faults", `{"*": {"latency": {"distribution": "exponential", "mean": "20ms"}}}`); code != http.StatusOK {
+		t.Fatalf("PUT rules: got status %d", code)
+	}
+	if rules := f.Rules(); len(rules) != 1 || rules[anyMethod].Latency == nil {
+		t.Errorf("rules = %+v after PUT", rules)
+	}
+	if code := do("DELETE", "/faults/*", ""); code != http.StatusNoContent {
+		t.Errorf("DELETE rule: got status %d", code)
+	}
+	if rules := f.Rules(); len(rules) != 0 {
+		t.Errorf("rules = %+v after DELETE", rules)
+	}
+}
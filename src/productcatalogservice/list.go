@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Lists are paged, defaultPageSize items at a time unless the request asks
+// for a different size up to maxPageSize.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// page returns the bounds of the requested page of n items and the token for
+// the page after it. Page tokens are offsets into the list, so a page may
+// skip or repeat items if the catalog is reloaded between requests.
+func page(n int, pageSize int32, pageToken string) (start, end int, next string, err error) {
+	size := defaultPageSize
+	if pageSize < 0 {
+		return 0, 0, "", status.Errorf(codes.InvalidArgument, "page size %d is negative", pageSize)
+	} else if pageSize > 0 {
+		size = min(int(pageSize), maxPageSize)
+	}
+	if pageToken != "" {
+		if start, err = strconv.Atoi(pageToken); err != nil || start < 0 {
+			return 0, 0, "", status.Errorf(codes.InvalidArgument, "invalid page token %q", pageToken)
+		}
+	}
+	start = min(start, n)
+	end = min(start+size, n)
+	if end < n {
+		next = strconv.Itoa(end)
+	}
+	return start, end, next, nil
+}
+
+// nanos returns m as a number of billionths of its currency unit.
+func nanos(m *pb.Money) int64 {
+	return m.GetUnits()*1_000_000_000 + int64(m.GetNanos())
+}
+
+// priceRange bounds product prices in USD. Nil bounds are unset.
+type priceRange struct {
+	min, max *pb.Money
+}
+
+func (r priceRange) contains(p *pb.Product) bool {
+	price := nanos(p.GetPriceUsd())
+	if r.min != nil && price < nanos(r.min) {
+		return false
+	}
+	if r.max != nil && price > nanos(r.max) {
+		return false
+	}
+	return true
+}
+
+// priceRange converts the price bounds of req to USD.
+func (p *productCatalog) priceRange(ctx context.Context, req *pb.ListProductsRequest) (priceRange, error) {
+	var r priceRange
+	var err error
+	if req.MinPrice != nil {
+		if r.min, err = p.toUSD(ctx, req.MinPrice); err != nil {
+			return r, err
+		}
+	}
+	if req.MaxPrice != nil {
+		if r.max, err = p.toUSD(ctx, req.MaxPrice); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func (p *productCatalog) toUSD(ctx context.Context, m *pb.Money) (*pb.Money, error) {
+	switch {
+	case m.GetCurrencyCode() == "":
+		return nil, status.Error(codes.InvalidArgument, "price bound has no currency code")
+	case m.GetUnits() < 0 || m.GetNanos() < 0:
+		return nil, status.Error(codes.InvalidArgument, "price bound is negative")
+	case m.GetCurrencyCode() == "USD":
+		return m, nil
+	case p.currency == nil:
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot convert %s price bounds: no currency service configured", m.GetCurrencyCode())
+	}
+	usd, err := p.currency.Convert(ctx, &pb.CurrencyConversionRequest{From: m, ToCode: "USD"})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to convert %s price bound to USD: %v", m.GetCurrencyCode(), err)
+	}
+	return usd, nil
+}
+
+// sortProducts sorts products in place. Products that compare equal keep
+// their catalog order.
+func sortProducts(products []*pb.Product, order pb.ProductSortOrder) {
+	var less func(a, b *pb.Product) bool
+	switch order {
+	case pb.ProductSortOrder_SORT_BY_NAME:
+		less = func(a, b *pb.Product) bool { return a.GetName() < b.GetName() }
+	case pb.ProductSortOrder_SORT_BY_PRICE_ASCENDING:
+		less = func(a, b *pb.Product) bool { return nanos(a.GetPriceUsd()) < nanos(b.GetPriceUsd()) }
+	case pb.ProductSortOrder_SORT_BY_PRICE_DESCENDING:
+		less = func(a, b *pb.Product) bool { return nanos(a.GetPriceUsd()) > nanos(b.GetPriceUsd()) }
+	default:
+		return
+	}
+	sort.SliceStable(products, func(i, j int) bool { return less(products[i], products[j]) })
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// halfCurrency converts by halving amounts, whatever the currencies.
+type halfCurrency struct {
+	pb.CurrencyServiceClient
+}
+
+func (halfCurrency) Convert(_ context.Context, req *pb.CurrencyConversionRequest, _ ...grpc.CallOption) (*pb.Money, error) {
+	n := nanos(req.From) / 2
+	return &pb.Money{CurrencyCode: req.ToCode, Units: n / 1e9, Nanos: int32(n % 1e9)}, nil
+}
+
+func usd(units int64) *pb.Money { return &pb.Money{CurrencyCode: "USD", Units: units} }
+
+func TestListProductsPage(t *testing.T) {
+	previous := current.Load()
+	t.Cleanup(func() { current.Store(previous) })
+	current.Store(newCatalogSnapshot([]*pb.Product{
+		{Id: "mug", Name: "Mug", PriceUsd: usd(8), Categories: []string{"kitchen"}},
+		{Id: "jar", Name: "Jar", PriceUsd: usd(12), Categories: []string{"kitchen"}},
+		{Id: "lamp", Name: "Lamp", PriceUsd: usd(40), Categories: []string{"decor"}},
+		{Id: "bowl", Name: "Bowl", PriceUsd: usd(12), Categories: []string{"kitchen", "decor"}},
+	}))
+	svc := &productCatalog{currency: halfCurrency{}}
+
+	for _, tc := range []struct {
+		name string
+		req  *pb.ListProductsRequest
+		want []string
+	}{
+		{"all", &pb.ListProductsRequest{}, []string{"mug", "jar", "lamp", "bowl"}},
+		{"category", &pb.ListProductsRequest{Category: "Decor"}, []string{"lamp", "bowl"}},
+		{"unknown category", &pb.ListProductsRequest{Category: "garden"}, nil},
+		{"min price", &pb.ListProductsRequest{MinPrice: usd(12)}, []string{"jar", "lamp", "bowl"}},
+		{"max price", &pb.ListProductsRequest{MaxPrice: usd(12)}, []string{"mug", "jar", "bowl"}},
+		{"price range in another currency", &pb.ListProductsRequest{
+			MinPrice: &pb.Money{CurrencyCode: "EUR", Units: 20},
+			MaxPrice: &pb.Money{CurrencyCode: "EUR", Units: 30},
+		}, []string{"jar", "bowl"}},
+		{"by name", &pb.ListProductsRequest{SortOrder: pb.ProductSortOrder_SORT_BY_NAME}, []string{"bowl", "jar", "lamp", "mug"}},
+		{"by price", &pb.ListProductsRequest{SortOrder: pb.ProductSortOrder_SORT_BY_PRICE_ASCENDING}, []string{"mug", "jar", "bowl", "lamp"}},
+		{"by price descending in category", &pb.ListProductsRequest{
+			Category:  "kitchen",
+			SortOrder: pb.ProductSortOrder_SORT_BY_PRICE_DESCENDING,
+		}, []string{"jar", "bowl", "mug"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := svc.ListProductsPage(context.Background(), tc.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range res.Products {
+				got = append(got, p.Id)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+			if int(res.TotalSize) != len(tc.want) || res.NextPageToken != "" {
+				t.Errorf("got total size %d and token %q, want %d and no token", res.TotalSize, res.NextPageToken, len(tc.want))
+			}
+		})
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		req := &pb.ListProductsRequest{PageSize: 3, SortOrder: pb.ProductSortOrder_SORT_BY_NAME}
+		var got []string
+		for pages := 0; ; pages++ {
+			if pages > 2 {
+				t.Fatal("too many pages")
+			}
+			res, err := svc.ListProductsPage(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.TotalSize != 4 {
+				t.Errorf("got total size %d, want 4", res.TotalSize)
+			}
+			for _, p := range res.Products {
+				got = append(got, p.Id)
+			}
+			if res.NextPageToken == "" {
+				break
+			}
+			req.PageToken = res.NextPageToken
+		}
+		if diff := cmp.Diff([]string{"bowl", "jar", "lamp", "mug"}, got); diff != "" {
+			t.Errorf("(-want +got):\n%s", diff)
+		}
+	})
+
+	for _, tc := range []struct {
+		name string
+		svc  *productCatalog
+		req  *pb.ListProductsRequest
+		want codes.Code
+	}{
+		{"negative page size", svc, &pb.ListProductsRequest{PageSize: -1}, codes.InvalidArgument},
+		{"bad page token", svc, &pb.ListProductsRequest{PageToken: "x"}, codes.InvalidArgument},
+		{"no currency code", svc, &pb.ListProductsRequest{MinPrice: &pb.Money{Units: 1}}, codes.InvalidArgument},
+		{"negative price", svc, &pb.ListProductsRequest{MaxPrice: usd(-1)}, codes.InvalidArgument},
+		{"no currency service", &productCatalog{}, &pb.ListProductsRequest{MinPrice: &pb.Money{CurrencyCode: "EUR", Units: 1}}, codes.FailedPrecondition},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.svc.ListProductsPage(context.Background(), tc.req); status.Code(err) != tc.want {
+				t.Errorf("got %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// Field boosts weigh a word in the product name above one in its categories,
+// and both above one in its description.
+const (
+	nameBoost        = 3.0
+	categoryBoost    = 2.0
+	descriptionBoost = 1.0
+)
+
+// prefixWeight scales the score of a query word that only matches the start
+// of an indexed word, such as "sun" for "sunglasses". Query words shorter
+// than minPrefixLength must match whole words.
+const (
+	prefixWeight    = 0.5
+	minPrefixLength = 2
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "its": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "these": true, "this": true, "to": true, "will": true,
+	"with": true, "you": true, "your": true,
+}
+
+// tokenize splits s into lower-cased, stemmed words, leaving out stop words.
+func tokenize(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		if !stopWords[w] {
+			tokens = append(tokens, stem(w))
+		}
+	}
+	return tokens
+}
+
+// stem reduces plurals and common verb endings so that, for example,
+// "candles", "candle" and "accessories", "accessory" index alike. It is a
+// small subset of the Porter stemmer, enough for product copy.
+func stem(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "ss"), strings.HasSuffix(w, "us"), strings.HasSuffix(w, "is"):
+		return w
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	for _, suffix := range []string{"ing", "ed"} {
+		if base := strings.TrimSuffix(w, suffix); base != w && len(base) >= 3 && hasVowel(base) {
+			// "cropped" becomes "crop", but "dressed" stays "dress".
+			if n := len(base); base[n-1] == base[n-2] && !strings.ContainsRune("lsz", rune(base[n-1])) {
+				base = base[:n-1]
+			}
+			return base
+		}
+	}
+	return w
+}
+
+func hasVowel(s string) bool { return strings.ContainsAny(s, "aeiouy") }
+
+// posting records that a word occurs in a product, with the sum of the boosts
+// of the fields it occurs in.
+type posting struct {
+	product int
+	weight  float64
+}
+
+// searchIndex is an inverted index over the name, categories and description
+// of products. It is built once per catalog load and never modified, so it is
+// safe for concurrent use.
+type searchIndex struct {
+	products []*pb.Product
+	postings map[string][]posting
+	// words holds the keys of postings in order, for prefix lookups.
+	words []string
+}
+
+func newSearchIndex(products []*pb.Product) *searchIndex {
+	ix := &searchIndex{products: products, postings: make(map[string][]posting)}
+	for i, p := range products {
+		weights := make(map[string]float64)
+		for _, w := range tokenize(p.GetName()) {
+			weights[w] += nameBoost
+		}
+		for _, c := range p.GetCategories() {
+			for _, w := range tokenize(c) {
+				weights[w] += categoryBoost
+			}
+		}
+		for _, w := range tokenize(p.GetDescription()) {
+			weights[w] += descriptionBoost
+		}
+		for w, weight := range weights {
+			ix.postings[w] = append(ix.postings[w], posting{product: i, weight: weight})
+		}
+	}
+	for w := range ix.postings {
+		ix.words = append(ix.words, w)
+	}
+	sort.Strings(ix.words)
+	return ix
+}
+
+// idf is the inverse document frequency of an indexed word, so that words
+// shared by many products count less than rare ones.
+func (ix *searchIndex) idf(w string) float64 {
+	return 1 + math.Log(float64(len(ix.products))/float64(len(ix.postings[w])))
+}
+
+// Search returns the products matching any word of the query, most relevant
+// first. Products with equal scores are ordered by name and then ID. A query
+// without searchable words matches nothing.
+func (ix *searchIndex) Search(query string) []*pb.Product {
+	scores := make(map[int]float64)
+	seen := make(map[string]bool)
+	for _, q := range tokenize(query) {
+		if seen[q] {
+			continue
+		}
+		seen[q] = true
+
+		// A product scores once per query word, on its best matching word.
+		best := make(map[int]float64)
+		match := func(w string, weight float64) {
+			for _, p := range ix.postings[w] {
+				best[p.product] = math.Max(best[p.product], weight*p.weight*ix.idf(w))
+			}
+		}
+		match(q, 1)
+		if len(q) >= minPrefixLength {
+			for i := sort.SearchStrings(ix.words, q); i < len(ix.words) && strings.HasPrefix(ix.words[i], q); i++ {
+				if ix.words[i] != q {
+					match(ix.words[i], prefixWeight)
+				}
+			}
+		}
+		for p, s := range best {
+			scores[p] += s
+		}
+	}
+
+	results := make([]int, 0, len(scores))
+	for p := range scores {
+		results = append(results, p)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		x, y := ix.products[a], ix.products[b]
+		if x.GetName() != y.GetName() {
+			return x.GetName() < y.GetName()
+		}
+		return x.GetId() < y.GetId()
+	})
+	products := make([]*pb.Product, len(results))
+	for i, p := range results {
+		products[i] = ix.products[p]
+	}
+	return products
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenize(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string
+	}{
+		{"Sunglasses", []string{"sunglass"}},
+		{"Add a modern touch to your outfits", []string{"add", "modern", "touch", "outfit"}},
+		{"gold-tone stainless steel", []string{"gold", "tone", "stainless", "steel"}},
+		{"Accessories, candles & mugs", []string{"accessory", "candle", "mug"}},
+		{"cropped, dressed, cooking", []string{"crop", "dress", "cook"}},
+		{"bus glass", []string{"bus", "glass"}},
+		{"  ", []string{}},
+	} {
+		if diff := cmp.Diff(tc.want, tokenize(tc.in)); diff != "" {
+			t.Errorf("tokenize(%q) (-want +got):\n%s", tc.in, diff)
+		}
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	products := []*pb.Product{
+		{Id: "mug", Name: "Mug", Description: "A simple mug with a mustard interior.", Categories: []string{"kitchen"}},
+		{Id: "jar", Name: "Salt & Pepper Shakers", Description: "Add some flavor to your kitchen.", Categories: []string{"kitchen"}},
+		{Id: "candle", Name: "Candle Holder", Description: "Holds a candle.", Categories: []string{"decor", "home"}},
+		{Id: "glasses", Name: "Sunglasses", Description: "Sleek aviator sunglasses.", Categories: []string{"accessories"}},
+		{Id: "watch", Name: "Watch", Description: "Goes with sunglasses.", Categories: []string{"accessories"}},
+	}
+	ix := newSearchIndex(products)
+	ids := func(ps []*pb.Product) []string {
+		var ids []string
+		for _, p := range ps {
+			ids = append(ids, p.Id)
+		}
+		return ids
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"sunglasses", []string{"glasses", "watch"}},
+		{"SUNGLASS", []string{"glasses", "watch"}},
+		{"sun", []string{"glasses", "watch"}},
+		{"candles", []string{"candle"}},
+		// The shakers also mention the kitchen in their description.
+		{"kitchen", []string{"jar", "mug"}},
+		{"kitchen mug", []string{"mug", "jar"}},
+		{"accessory", []string{"glasses", "watch"}},
+		{"mu", []string{"mug"}},
+		{"m", nil},
+		{"the", nil},
+		{"", nil},
+		{"telescope", nil},
+	} {
+		if diff := cmp.Diff(tc.want, ids(ix.Search(tc.query))); diff != "" {
+			t.Errorf("Search(%q) (-want +got):\n%s", tc.query, diff)
+		}
+	}
+}
+
+func TestSearchProductsPagination(t *testing.T) {
+	ctx := context.Background()
+	svc := &productCatalog{}
+	all, err := svc.SearchProducts(ctx, &pb.SearchProductsRequest{Query: "kitchen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.TotalSize != 3 || len(all.Results) != 3 || all.NextPageToken != "" {
+		t.Fatalf("got %d of %d results and token %q, want 3 of 3 and no token", len(all.Results), all.TotalSize, all.NextPageToken)
+	}
+
+	var paged []*pb.Product
+	req := &pb.SearchProductsRequest{Query: "kitchen", PageSize: 2}
+	for pages := 0; ; pages++ {
+		if pages > 2 {
+			t.Fatal("too many pages")
+		}
+		res, err := svc.SearchProducts(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.TotalSize != 3 {
+			t.Errorf("got total size %d, want 3", res.TotalSize)
+		}
+		paged = append(paged, res.Results...)
+		if res.NextPageToken == "" {
+			break
+		}
+		req.PageToken = res.NextPageToken
+	}
+	if diff := cmp.Diff(all.Results, paged, cmp.Comparer(func(a, b *pb.Product) bool { return a.Id == b.Id })); diff != "" {
+		t.Errorf("paged results differ (-want +got):\n%s", diff)
+	}
+
+	for _, req := range []*pb.SearchProductsRequest{
+		{Query: "kitchen", PageSize: -1},
+		{Query: "kitchen", PageToken: "next"},
+		{Query: "kitchen", PageToken: "-2"},
+	} {
+		if _, err := svc.SearchProducts(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("SearchProducts(%v) got %v, want InvalidArgument", req, err)
+		}
+	}
+}
@@ -18,15 +18,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
-	"os/signal"
-	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/norun9/microservices-demo-ambient/chaos"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
@@ -43,23 +41,28 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 
-	"google.golang.org/protobuf/encoding/protojson"
-
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 )
 
 var (
-	cat          pb.ListProductsResponse
 	catalogMutex *sync.Mutex
 	log          *logrus.Logger
-	extraLatency time.Duration
 	tracer       trace.Tracer
 
 	port = "3550"
+)
+
+const (
+	// defaultCatalogSource is used unless CATALOG_SOURCE says otherwise; see
+	// newCatalogSource.
+	defaultCatalogSource = "json:products.json"
 
-	reloadCatalog bool
+	// catalogReloadDebounce is how long the catalog files must stay unchanged
+	// before they are reloaded.
+	catalogReloadDebounce = 200 * time.Millisecond
 )
 
 func init() {
@@ -73,11 +76,8 @@ func init() {
 		TimestampFormat: time.RFC3339Nano,
 	}
 	log.Out = os.Stdout
+	tracer = otel.Tracer("productcatalogservice")
 	catalogMutex = &sync.Mutex{}
-	err := readCatalogFile(&cat)
-	if err != nil {
-		log.Warnf("could not parse product catalog")
-	}
 }
 
 func InitTracerProvider() *sdktrace.TracerProvider {
@@ -132,94 +132,158 @@ func main() {
 
 	flag.Parse()
 
-	// set injected latency
+	// EXTRA_LATENCY is kept as a shorthand for a fixed delay of every RPC.
+	rules := faultRules{}
 	if s := os.Getenv("EXTRA_LATENCY"); s != "" {
 		v, err := time.ParseDuration(s)
 		if err != nil {
 			log.Fatalf("failed to parse EXTRA_LATENCY (%s) as time.Duration: %+v", v, err)
 		}
-		extraLatency = v
-		log.Infof("extra latency enabled (duration: %v)", extraLatency)
-	} else {
-		extraLatency = time.Duration(0)
+		rules[anyMethod] = faultRule{Latency: &latencyDistribution{Distribution: "fixed", Value: duration(v)}}
+		log.Infof("extra latency enabled (duration: %v)", v)
+	}
+	faults, err := newFaultInjector(rules)
+	if err != nil {
+		log.Fatalf("invalid fault rules: %v", err)
+	}
+	if adminPort := os.Getenv("FAULT_ADMIN_PORT"); adminPort != "" {
+		log.Infof("starting fault injection admin endpoint at :%s", adminPort)
+		go func() {
+			err := http.ListenAndServe(":"+adminPort, faultAdminHandler(faults))
+			log.Errorf("fault injection admin endpoint stopped: %v", err)
+		}()
 	}
 
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGUSR1, syscall.SIGUSR2)
-	go func() {
-		for {
-			sig := <-sigs
-			log.Printf("Received signal: %s", sig)
-			if sig == syscall.SIGUSR1 {
-				reloadCatalog = true
-				log.Infof("Enable catalog reloading")
-			} else {
-				reloadCatalog = false
-				log.Infof("Disable catalog reloading")
-			}
+	spec := defaultCatalogSource
+	if s := os.Getenv("CATALOG_SOURCE"); s != "" {
+		spec = s
+	}
+	source, err := newCatalogSource(spec)
+	if err != nil {
+		log.Fatalf("failed to open catalog source %q: %v", spec, err)
+	}
+	if _, err := reloadCatalog(source); err != nil {
+		log.Warnf("could not load product catalog, not serving until it can: %v", err)
+	}
+	if ws, ok := source.(watchedSource); ok {
+		watcher, err := watchCatalog(ws, catalogReloadDebounce)
+		if err != nil {
+			log.Warnf("catalog hot reload disabled: %v", err)
+		} else {
+			defer watcher.Close()
 		}
-	}()
+	} else {
+		log.Infof("catalog hot reload not supported for %v", source)
+	}
 
 	if os.Getenv("PORT") != "" {
 		port = os.Getenv("PORT")
 	}
 	log.Infof("starting grpc server at :%s", port)
-	run(port)
+	svc := &productCatalog{source: source}
+	if addr := os.Getenv("CURRENCY_SERVICE_ADDR"); addr != "" {
+		conn, err := grpc.NewClient(addr,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		)
+		if err != nil {
+			log.Fatalf("failed to connect currency service at %q: %v", addr, err)
+		}
+		defer conn.Close()
+		svc.currency = pb.NewCurrencyServiceClient(conn)
+	} else {
+		log.Info("CURRENCY_SERVICE_ADDR not set; price filters must be in USD")
+	}
+	// Inject the faults of CHAOS_RULES_FILE, if set, as well.
+	chaosInjector, err := chaos.FromEnv(log.Infof)
+	if err != nil {
+		log.Fatalf("failed to load chaos rules: %v", err)
+	}
+	defer chaosInjector.Close()
+
+	run(port, svc, append(chaosInjector.ServerOptions(),
+		grpc.ChainUnaryInterceptor(faults.UnaryInterceptor))...)
 	select {}
 }
 
-func run(port string) string {
+// run serves svc on port with the extra server options, such as fault
+// injectors, and returns the address it listens on.
+func run(port string, svc *productCatalog, opts ...grpc.ServerOption) string {
 	l, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var srv *grpc.Server = grpc.NewServer(
+	var srv *grpc.Server = grpc.NewServer(append([]grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-	)
-
-	svc := &productCatalog{}
+	}, opts...)...)
 
 	pb.RegisterProductCatalogServiceServer(srv, svc)
 	healthSvc := health.NewServer()
 	healthpb.RegisterHealthServer(srv, healthSvc)
-	healthSvc.SetServingStatus("productcatalogservice", healthpb.HealthCheckResponse_SERVING)
+	reportHealth(healthSvc, catalogLoaded)
 	go srv.Serve(l)
 	return l.Addr().String()
 }
 
+// reportHealth reports the service as NOT_SERVING until loaded is closed, so
+// that it gets no traffic while it serves an empty catalog, and as SERVING
+// after.
+func reportHealth(healthSvc *health.Server, loaded <-chan struct{}) {
+	set := func(st healthpb.HealthCheckResponse_ServingStatus) {
+		healthSvc.SetServingStatus("", st)
+		healthSvc.SetServingStatus("productcatalogservice", st)
+	}
+	set(healthpb.HealthCheckResponse_NOT_SERVING)
+	go func() {
+		<-loaded
+		set(healthpb.HealthCheckResponse_SERVING)
+	}()
+}
+
 type productCatalog struct {
 	pb.UnimplementedProductCatalogServiceServer
+
+	// currency converts price filters to USD. Without it, only filters in
+	// USD are supported.
+	currency pb.CurrencyServiceClient
+
+	// source is where the admin RPCs save the catalog. Without it, the
+	// catalog is read-only.
+	source catalogSource
 }
 
-func readCatalogFile(catalog *pb.ListProductsResponse) error {
+// reloadCatalog loads and validates the catalog from source and swaps in a
+// snapshot of it as the current catalog. On error the current catalog is left
+// in place. Concurrent loads are serialized.
+func reloadCatalog(source catalogSource) (*catalogSnapshot, error) {
 	catalogMutex.Lock()
 	defer catalogMutex.Unlock()
 
-	catalogJSON, err := ioutil.ReadFile("products.json")
+	products, err := source.Load()
 	if err != nil {
-		log.Fatalf("failed to open product catalog json file: %v", err)
-		return err
+		return nil, err
 	}
-
-	if err := protojson.Unmarshal(catalogJSON, catalog); err != nil {
-		log.Warnf("failed to parse the catalog JSON: %v", err)
-		return err
+	if err := validateCatalog(products); err != nil {
+		return nil, fmt.Errorf("invalid catalog %v: %w", source, err)
+	}
+	for _, p := range products {
+		p.Etag = productEtag(p)
 	}
+	snapshot := newCatalogSnapshot(products)
+	current.Store(snapshot)
+	catalogLoadedOnce.Do(func() { close(catalogLoaded) })
 
-	log.Info("successfully parsed product catalog json")
-	return nil
+	log.Infof("successfully loaded product catalog from %v (%d products)", source, len(products))
+	return snapshot, nil
 }
 
-func parseCatalog() []*pb.Product {
-	if reloadCatalog || len(cat.Products) == 0 {
-		err := readCatalogFile(&cat)
-		if err != nil {
-			return []*pb.Product{}
-		}
+// parseCatalog returns the catalog snapshot being served.
+func parseCatalog() *catalogSnapshot {
+	if snapshot := current.Load(); snapshot != nil {
+		return snapshot
 	}
-
-	return cat.Products
+	return emptyCatalog
 }
 
 func (p *productCatalog) ListProducts(ctx context.Context, req *pb.Empty) (*pb.ListProductsResponse, error) {
@@ -228,18 +292,61 @@ func (p *productCatalog) ListProducts(ctx context.Context, req *pb.Empty) (*pb.L
 
 	span.SetAttributes(attribute.String("request.type", "list_all"))
 
-	time.Sleep(extraLatency)
-
-	products := parseCatalog()
+	products := parseCatalog().products
 
 	span.SetAttributes(
 		attribute.Int("response.products.count", len(products)),
-		attribute.String("extra_latency", extraLatency.String()),
 	)
 
 	return &pb.ListProductsResponse{Products: products}, nil
 }
 
+func (p *productCatalog) ListProductsPage(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	_, span := tracer.Start(ctx, "ListProductsPage")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("request.type", "list_page"),
+		attribute.Int("request.page_size", int(req.PageSize)),
+		attribute.String("request.category", req.Category),
+		attribute.String("request.sort_order", req.SortOrder.String()),
+	)
+
+	prices, err := p.priceRange(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	catalog := parseCatalog()
+	candidates := catalog.products
+	if req.Category != "" {
+		candidates = catalog.Category(req.Category)
+	}
+	var products []*pb.Product
+	for _, product := range candidates {
+		if prices.contains(product) {
+			products = append(products, product)
+		}
+	}
+	sortProducts(products, req.SortOrder)
+
+	start, end, next, err := page(len(products), req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.ListProductsResponse{
+		Products:      products[start:end],
+		TotalSize:     int32(len(products)),
+		NextPageToken: next,
+	}
+
+	span.SetAttributes(
+		attribute.Int("response.products.count", len(res.Products)),
+		attribute.Int("response.total_size", len(products)),
+	)
+
+	return res, nil
+}
+
 func (p *productCatalog) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.Product, error) {
 	_, span := tracer.Start(ctx, "GetProduct")
 	defer span.End()
@@ -249,18 +356,7 @@ func (p *productCatalog) GetProduct(ctx context.Context, req *pb.GetProductReque
 		attribute.String("request.type", "get_single"),
 	)
 
-	time.Sleep(extraLatency)
-
-	var found *pb.Product
-	products := parseCatalog()
-
-	for i := 0; i < len(products); i++ {
-		if req.Id == products[i].Id {
-			found = products[i]
-			break
-		}
-	}
-
+	found := parseCatalog().Product(req.Id)
 	if found == nil {
 		span.SetAttributes(
 			attribute.String("error", "product not found"),
@@ -272,7 +368,6 @@ func (p *productCatalog) GetProduct(ctx context.Context, req *pb.GetProductReque
 	span.SetAttributes(
 		attribute.String("response.product.name", found.Name),
 		attribute.String("response.product.id", found.Id),
-		attribute.String("extra_latency", extraLatency.String()),
 	)
 
 	return found, nil
@@ -285,27 +380,24 @@ func (p *productCatalog) SearchProducts(ctx context.Context, req *pb.SearchProdu
 	span.SetAttributes(
 		attribute.String("request.query", req.Query),
 		attribute.String("request.type", "search"),
+		attribute.Int("request.page_size", int(req.PageSize)),
 	)
 
-	time.Sleep(extraLatency)
-
-	// Intepret query as a substring match in name or description.
-	var ps []*pb.Product
-	products := parseCatalog()
-
-	for _, p := range products {
-		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(req.Query)) ||
-			strings.Contains(strings.ToLower(p.Description), strings.ToLower(req.Query)) {
-			ps = append(ps, p)
-		}
+	ps := parseCatalog().search.Search(req.Query)
+	start, end, next, err := page(len(ps), req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.SearchProductsResponse{
+		Results:       ps[start:end],
+		TotalSize:     int32(len(ps)),
+		NextPageToken: next,
 	}
 
 	span.SetAttributes(
-		attribute.Int("response.results.count", len(ps)),
-		attribute.String("extra_latency", extraLatency.String()),
+		attribute.Int("response.results.count", len(res.Results)),
+		attribute.Int("response.total_size", len(ps)),
 	)
 
-	return &pb.SearchProductsResponse{Results: ps}, nil
+	return res, nil
 }
-
-
@@ -16,20 +16,31 @@ package main
 
 import (
 	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
+func TestMain(m *testing.M) {
+	if _, err := reloadCatalog(newJSONFileSource("products.json")); err != nil {
+		log.Fatalf("could not load product catalog: %v", err)
+	}
+	os.Exit(m.Run())
+}
+
 func TestServer(t *testing.T) {
 	ctx := context.Background()
-	addr := run(port)
+	addr := run(port, &productCatalog{})
 	conn, err := grpc.NewClient(addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -41,7 +52,7 @@ func TestServer(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(res.Products, parseCatalog(), cmp.Comparer(proto.Equal)); diff != "" {
+	if diff := cmp.Diff(res.Products, parseCatalog().products, cmp.Comparer(proto.Equal)); diff != "" {
 		t.Error(diff)
 	}
 
@@ -49,7 +60,7 @@ func TestServer(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if want := parseCatalog()[0]; !proto.Equal(got, want) {
+	if want := parseCatalog().products[0]; !proto.Equal(got, want) {
 		t.Errorf("got %v, want %v", got, want)
 	}
 	_, err = client.GetProduct(ctx, &pb.GetProductRequest{Id: "N/A"})
@@ -61,7 +72,32 @@ func TestServer(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if diff := cmp.Diff(sres.Results, []*pb.Product{parseCatalog()[0]}, cmp.Comparer(proto.Equal)); diff != "" {
+	if diff := cmp.Diff(sres.Results, []*pb.Product{parseCatalog().products[0]}, cmp.Comparer(proto.Equal)); diff != "" {
 		t.Error(diff)
 	}
 }
+
+func TestReportHealth(t *testing.T) {
+	ctx := context.Background()
+	healthSvc := health.NewServer()
+	loaded := make(chan struct{})
+	reportHealth(healthSvc, loaded)
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		res, err := healthSvc.Check(ctx, &healthpb.HealthCheckRequest{Service: "productcatalogservice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Status
+	}
+
+	if got := check(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("health before the catalog is loaded = %v, want NOT_SERVING", got)
+	}
+	close(loaded)
+	for deadline := time.Now().Add(time.Second); check() != healthpb.HealthCheckResponse_SERVING; {
+		if time.Now().After(deadline) {
+			t.Fatal("health after the catalog is loaded is not SERVING")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
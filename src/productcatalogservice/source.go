@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// catalogSource is where the catalog is stored. Implementations must be safe
+// for concurrent use. Etags are not stored; they are computed on load.
+type catalogSource interface {
+	// Load returns all products, in catalog order.
+	Load() ([]*pb.Product, error)
+	// Put saves a new product, or replaces the product with the same ID in
+	// place. New products go at the end of the catalog unless the source has
+	// an order of its own.
+	Put(p *pb.Product) error
+	// Delete removes the product with the ID, if any.
+	Delete(id string) error
+}
+
+// watchedSource is a source stored in files that can be watched for changes.
+type watchedSource interface {
+	catalogSource
+	// watchDir returns the directory holding the files of the source.
+	watchDir() string
+	// affects reports whether a change to the file at path may change the
+	// catalog.
+	affects(path string) bool
+}
+
+// newCatalogSource returns the source described by spec, which has the form
+// "kind:location":
+//
+//	json:products.json     a JSON file like products.json
+//	yaml:/catalog          a directory with one YAML file per product
+//	sqlite:/data/catalog.db  a SQLite database
+//
+// A spec without a kind is the path of a JSON file.
+func newCatalogSource(spec string) (catalogSource, error) {
+	kind, location, ok := strings.Cut(spec, ":")
+	if !ok {
+		kind, location = "json", spec
+	}
+	if location == "" {
+		return nil, fmt.Errorf("catalog source %q has no location", spec)
+	}
+	switch kind {
+	case "json":
+		return newJSONFileSource(location), nil
+	case "yaml":
+		return newYAMLDirSource(location)
+	case "sqlite":
+		return newSQLiteSource(location)
+	default:
+		return nil, fmt.Errorf("unknown kind of catalog source %q", kind)
+	}
+}
+
+// withoutEtag returns a copy of p without its etag, for storage.
+func withoutEtag(p *pb.Product) *pb.Product {
+	c := proto.Clone(p).(*pb.Product)
+	c.Etag = ""
+	return c
+}
+
+// writeFileAtomic replaces the file at path with data, so that readers never
+// see half of it.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// jsonFileSource is a catalog in a single JSON file in the format of
+// products.json. Changes rewrite the whole file.
+type jsonFileSource struct {
+	path string
+	mu   sync.Mutex // serializes changes
+}
+
+func newJSONFileSource(path string) *jsonFileSource {
+	return &jsonFileSource{path: filepath.Clean(path)}
+}
+
+func (s *jsonFileSource) Load() ([]*pb.Product, error) {
+	catalogJSON, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open product catalog json file: %w", err)
+	}
+	var catalog pb.ListProductsResponse
+	if err := protojson.Unmarshal(catalogJSON, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse the catalog JSON: %w", err)
+	}
+	return catalog.Products, nil
+}
+
+func (s *jsonFileSource) Put(p *pb.Product) error {
+	return s.change(func(products []*pb.Product) []*pb.Product {
+		if i := slices.IndexFunc(products, func(old *pb.Product) bool { return old.GetId() == p.GetId() }); i >= 0 {
+			products[i] = p
+			return products
+		}
+		return append(products, p)
+	})
+}
+
+func (s *jsonFileSource) Delete(id string) error {
+	return s.change(func(products []*pb.Product) []*pb.Product {
+		return slices.DeleteFunc(products, func(p *pb.Product) bool { return p.GetId() == id })
+	})
+}
+
+func (s *jsonFileSource) change(f func([]*pb.Product) []*pb.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products, err := s.Load()
+	if err != nil {
+		return err
+	}
+	catalog := &pb.ListProductsResponse{}
+	for _, p := range f(products) {
+		catalog.Products = append(catalog.Products, withoutEtag(p))
+	}
+	b, err := protojson.MarshalOptions{Multiline: true, Indent: "    "}.Marshal(catalog)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, append(b, '\n'))
+}
+
+func (s *jsonFileSource) watchDir() string { return filepath.Dir(s.path) }
+
+func (s *jsonFileSource) affects(path string) bool { return path == s.path }
+
+func (s *jsonFileSource) String() string { return "json:" + s.path }
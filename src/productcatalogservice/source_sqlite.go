@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// sqliteSchema keeps each product as its JSON encoding, with its position in
+// the catalog.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS products (
+	id       TEXT PRIMARY KEY,
+	position INTEGER NOT NULL,
+	product  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS products_position ON products (position);
+`
+
+// sqliteSource is a catalog in a SQLite database, for catalogs too large to
+// rewrite on every change. The database and its table are created if they do
+// not exist.
+type sqliteSource struct {
+	path string
+	db   *sql.DB
+}
+
+func newSQLiteSource(path string) (*sqliteSource, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the catalog table in %s: %w", path, err)
+	}
+	return &sqliteSource{path: path, db: db}, nil
+}
+
+func (s *sqliteSource) Load() ([]*pb.Product, error) {
+	rows, err := s.db.Query(`SELECT id, product FROM products ORDER BY position, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var products []*pb.Product
+	for rows.Next() {
+		var id, product string
+		if err := rows.Scan(&id, &product); err != nil {
+			return nil, err
+		}
+		p := &pb.Product{}
+		if err := protojson.Unmarshal([]byte(product), p); err != nil {
+			return nil, fmt.Errorf("failed to parse product %s: %w", id, err)
+		}
+		products = append(products, p)
+	}
+	return products, rows.Err()
+}
+
+func (s *sqliteSource) Put(p *pb.Product) error {
+	product, err := protojson.Marshal(withoutEtag(p))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO products (id, position, product)
+		VALUES (?, (SELECT COALESCE(MAX(position), 0) + 1 FROM products), ?)
+		ON CONFLICT (id) DO UPDATE SET product = excluded.product`,
+		p.GetId(), string(product))
+	return err
+}
+
+func (s *sqliteSource) Delete(id string) error {
+	_, err := s.db.Exec(`DELETE FROM products WHERE id = ?`, id)
+	return err
+}
+
+// Close closes the database.
+func (s *sqliteSource) Close() error { return s.db.Close() }
+
+func (s *sqliteSource) String() string { return "sqlite:" + s.path }
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCatalogSources(t *testing.T) {
+	mug := &pb.Product{
+		Id:          "MUG0000001",
+		Name:        "Mug",
+		Description: "A mug: for tea, or \"coffee\".",
+		Picture:     "/static/img/products/mug.jpg",
+		PriceUsd:    usd(8),
+		Categories:  []string{"kitchen"},
+		WeightGrams: 350,
+		Dimensions:  &pb.Dimensions{LengthMm: 120, WidthMm: 90, HeightMm: 100},
+		Variants: []*pb.ProductVariant{
+			{Sku: "MUG0000001-W", Attributes: map[string]string{"color": "white", "size": "small"}},
+			{Sku: "MUG0000001-B", Attributes: map[string]string{"color": "black", "size": "large"}, PriceUsd: usd(9)},
+		},
+	}
+	jar := &pb.Product{Id: "JAR0000001", Name: "Jar", PriceUsd: usd(12), Categories: []string{"kitchen", "decor"}, Etag: "ignored"}
+	glassJar := proto.Clone(jar).(*pb.Product)
+	glassJar.Name = "Glass Jar"
+
+	sources := map[string]func(t *testing.T) catalogSource{
+		"json": func(t *testing.T) catalogSource {
+			path := filepath.Join(t.TempDir(), "products.json")
+			writeFile(t, path, `{"products": []}`)
+			return newJSONFileSource(path)
+		},
+		"yaml": func(t *testing.T) catalogSource {
+			s, err := newYAMLDirSource(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		"sqlite": func(t *testing.T) catalogSource {
+			s, err := newSQLiteSource(filepath.Join(t.TempDir(), "catalog.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+		"memory": func(t *testing.T) catalogSource { return newMemorySource() },
+	}
+	for name, open := range sources {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			for _, p := range []*pb.Product{mug, jar, glassJar} {
+				if err := s.Put(p); err != nil {
+					t.Fatalf("Put(%s): %v", p.Id, err)
+				}
+			}
+			check := func(want ...*pb.Product) {
+				t.Helper()
+				got, err := s.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i, p := range want {
+					want[i] = withoutEtag(p)
+				}
+				opts := []cmp.Option{cmp.Comparer(proto.Equal)}
+				if name == "yaml" {
+					// Products are in the order of their file names.
+					opts = append(opts, cmpopts.SortSlices(func(a, b *pb.Product) bool { return a.Id < b.Id }))
+				}
+				if diff := cmp.Diff(want, got, opts...); diff != "" {
+					t.Errorf("Load() differs (-want +got):\n%s", diff)
+				}
+			}
+			check(mug, glassJar)
+			if err := s.Delete(mug.Id); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Delete("NONE000000"); err != nil {
+				t.Errorf("deleting a missing product: %v", err)
+			}
+			check(glassJar)
+		})
+	}
+}
+
+func TestYAMLDirSourceFieldNames(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), "id: AAAAAAAAAA\nname: A\nprice_usd: {currency_code: USD, units: 1}\n")
+	writeFile(t, filepath.Join(dir, "b.yml"), "id: BBBBBBBBBB\nname: B\npriceUsd: {currencyCode: USD, units: 2}\nweightGrams: 5\n")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "not a product")
+	s, err := newYAMLDirSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*pb.Product{
+		{Id: "AAAAAAAAAA", Name: "A", PriceUsd: usd(1)},
+		{Id: "BBBBBBBBBB", Name: "B", PriceUsd: usd(2), WeightGrams: 5},
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(proto.Equal)); diff != "" {
+		t.Errorf("Load() differs (-want +got):\n%s", diff)
+	}
+
+	writeFile(t, filepath.Join(dir, "c.yaml"), "id: CCCCCCCCCC\nprice: 3\n")
+	if _, err := s.Load(); err == nil {
+		t.Error("loading a product with an unknown field: got no error")
+	}
+}
+
+func TestNewCatalogSource(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "products.json", want: "json:products.json"},
+		{spec: "json:" + filepath.Join(dir, "products.json"), want: "json:" + filepath.Join(dir, "products.json")},
+		{spec: "yaml:" + dir, want: "yaml:" + dir},
+		{spec: "yaml:" + filepath.Join(dir, "missing"), wantErr: true},
+		{spec: "sqlite:" + filepath.Join(dir, "catalog.db"), want: "sqlite:" + filepath.Join(dir, "catalog.db")},
+		{spec: "json:", wantErr: true},
+		{spec: "xml:products.xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		s, err := newCatalogSource(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("newCatalogSource(%q): got no error", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("newCatalogSource(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.(interface{ String() string }).String(); got != tt.want {
+			t.Errorf("newCatalogSource(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+		if c, ok := s.(interface{ Close() error }); ok {
+			c.Close()
+		}
+	}
+}
+
+// memorySource is a catalog held in memory, for tests.
+type memorySource struct {
+	mu       sync.Mutex
+	products []*pb.Product
+}
+
+func newMemorySource(products ...*pb.Product) *memorySource {
+	s := &memorySource{}
+	for _, p := range products {
+		s.products = append(s.products, withoutEtag(p))
+	}
+	return s
+}
+
+func (s *memorySource) Load() ([]*pb.Product, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	products := make([]*pb.Product, len(s.products))
+	for i, p := range s.products {
+		products[i] = proto.Clone(p).(*pb.Product)
+	}
+	return products, nil
+}
+
+func (s *memorySource) Put(p *pb.Product) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p = withoutEtag(p)
+	if i := slices.IndexFunc(s.products, func(old *pb.Product) bool { return old.GetId() == p.GetId() }); i >= 0 {
+		s.products[i] = p
+	} else {
+		s.products = append(s.products, p)
+	}
+	return nil
+}
+
+func (s *memorySource) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.products = slices.DeleteFunc(s.products, func(p *pb.Product) bool { return p.GetId() == id })
+	return nil
+}
+
+func (s *memorySource) String() string { return "memory" }
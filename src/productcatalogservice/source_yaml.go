@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDirSource is a catalog stored as a directory with one YAML file per
+// product, in catalog order by file name. Fields use the names of the proto
+// definition, such as price_usd, or their JSON names, such as priceUsd. New
+// products are saved as <id>.yaml.
+type yamlDirSource struct {
+	dir string
+
+	mu sync.Mutex
+	// files maps product IDs to the files they were last loaded from.
+	files map[string]string
+}
+
+func newYAMLDirSource(dir string) (*yamlDirSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &yamlDirSource{dir: filepath.Clean(dir), files: make(map[string]string)}, nil
+}
+
+func isYAMLFile(name string) bool {
+	ext := filepath.Ext(name)
+	return (ext == ".yaml" || ext == ".yml") && !strings.HasPrefix(filepath.Base(name), ".")
+}
+
+func (s *yamlDirSource) Load() ([]*pb.Product, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && isYAMLFile(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	products := make([]*pb.Product, 0, len(names))
+	files := make(map[string]string, len(names))
+	for _, name := range names {
+		path := filepath.Join(s.dir, name)
+		p, err := readYAMLProduct(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		products = append(products, p)
+		files[p.GetId()] = path
+	}
+	s.mu.Lock()
+	s.files = files
+	s.mu.Unlock()
+	return products, nil
+}
+
+// readYAMLProduct parses a product from a YAML file by way of JSON, so that
+// it gets the same field names and checks as products.json.
+func readYAMLProduct(path string) (*pb.Product, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	p := &pb.Product{}
+	if err := protojson.Unmarshal(j, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *yamlDirSource) Put(p *pb.Product) error {
+	b, err := yaml.Marshal(yamlNode(withoutEtag(p).ProtoReflect()))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, ok := s.files[p.GetId()]
+	if !ok {
+		path = filepath.Join(s.dir, p.GetId()+".yaml")
+	}
+	if err := writeFileAtomic(path, b); err != nil {
+		return err
+	}
+	s.files[p.GetId()] = path
+	return nil
+}
+
+func (s *yamlDirSource) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, ok := s.files[id]
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(s.files, id)
+	return nil
+}
+
+func (s *yamlDirSource) watchDir() string { return s.dir }
+
+func (s *yamlDirSource) affects(path string) bool {
+	return filepath.Dir(path) == s.dir && isYAMLFile(path)
+}
+
+func (s *yamlDirSource) String() string { return "yaml:" + s.dir }
+
+// yamlNode returns m as a YAML mapping with its fields in declaration order.
+func yamlNode(m protoreflect.Message) *yaml.Node {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !m.Has(fd) {
+			continue
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: string(fd.Name())}
+		n.Content = append(n.Content, key, yamlField(fd, m.Get(fd)))
+	}
+	return n
+}
+
+func yamlField(fd protoreflect.FieldDescriptor, v protoreflect.Value) *yaml.Node {
+	switch {
+	case fd.IsList():
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		l := v.List()
+		for i := 0; i < l.Len(); i++ {
+			n.Content = append(n.Content, yamlValue(fd, l.Get(i)))
+		}
+		return n
+	case fd.IsMap():
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		var keys []protoreflect.MapKey
+		v.Map().Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, k)
+			return true
+		})
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.String()}
+			n.Content = append(n.Content, key, yamlValue(fd.MapValue(), v.Map().Get(k)))
+		}
+		return n
+	default:
+		return yamlValue(fd, v)
+	}
+}
+
+// yamlValue returns a single value of the field.
+func yamlValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) *yaml.Node {
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return yamlNode(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return scalar("!!str", string(ev.Name()))
+		}
+		return scalar("!!int", strconv.Itoa(int(v.Enum())))
+	case protoreflect.StringKind:
+		return scalar("!!str", v.String())
+	case protoreflect.BoolKind:
+		return scalar("!!bool", strconv.FormatBool(v.Bool()))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return scalar("!!float", strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case protoreflect.BytesKind:
+		return scalar("!!str", base64.StdEncoding.EncodeToString(v.Bytes()))
+	default:
+		return scalar("!!int", v.String())
+	}
+}
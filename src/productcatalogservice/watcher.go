@@ -0,0 +1,86 @@
+package main
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configMapDataLink is the symlink Kubernetes swaps to update the files of a
+// mounted ConfigMap all at once.
+const configMapDataLink = "..data"
+
+// catalogWatcher reloads the catalog whenever its files change on disk.
+type catalogWatcher struct {
+	source   watchedSource
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+	done     chan struct{}
+}
+
+// watchCatalog starts reloading the catalog from source when its files
+// change. Changes are picked up after things have been quiet for debounce, so
+// that files written in several steps are loaded once. The directory rather
+// than the files is watched, so that editors and Kubernetes replacing the
+// files are noticed.
+func watchCatalog(source watchedSource, debounce time.Duration) (*catalogWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(source.watchDir()); err != nil {
+		w.Close()
+		return nil, err
+	}
+	cw := &catalogWatcher{
+		source:   source,
+		debounce: debounce,
+		watcher:  w,
+		done:     make(chan struct{}),
+	}
+	go cw.run()
+	return cw, nil
+}
+
+// Close stops watching and waits for a reload in progress to finish.
+func (cw *catalogWatcher) Close() error {
+	err := cw.watcher.Close()
+	<-cw.done
+	return err
+}
+
+func (cw *catalogWatcher) run() {
+	defer close(cw.done)
+	var reload <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-cw.watcher.Events:
+			if !ok {
+				return
+			}
+			if cw.affects(ev) {
+				reload = time.After(cw.debounce)
+			}
+		case err, ok := <-cw.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warnf("catalog watcher: %v", err)
+		case <-reload:
+			reload = nil
+			if _, err := reloadCatalog(cw.source); err != nil {
+				log.Warnf("keeping the current catalog: %v", err)
+			}
+		}
+	}
+}
+
+// affects reports whether ev may have changed the catalog.
+func (cw *catalogWatcher) affects(ev fsnotify.Event) bool {
+	if ev.Op == fsnotify.Chmod {
+		return false
+	}
+	name := filepath.Clean(ev.Name)
+	return cw.source.affects(name) || filepath.Base(name) == configMapDataLink
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+func catalogJSON(name string) string {
+	return fmt.Sprintf(`{"products": [
+		{"id": "A", "name": %[1]q, "priceUsd": {"currencyCode": "USD", "units": 1}, "categories": ["kitchen"]},
+		{"id": "B", "name": "Mug", "priceUsd": {"currencyCode": "USD", "units": 2}, "categories": ["kitchen"]}
+	]}`, name)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	// Write next to the file and rename, like most editors do.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForName waits until product A of the current catalog has the name.
+func waitForName(t *testing.T, name string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if parseCatalog().Product("A").GetName() == name {
+			return
+		}
+	}
+	t.Fatalf("catalog was not reloaded with product A named %q", name)
+}
+
+func TestCatalogWatcher(t *testing.T) {
+	previous := current.Load()
+	t.Cleanup(func() { current.Store(previous) })
+
+	path := filepath.Join(t.TempDir(), "products.json")
+	writeFile(t, path, catalogJSON("v0"))
+	source := newJSONFileSource(path)
+	if _, err := reloadCatalog(source); err != nil {
+		t.Fatal(err)
+	}
+	const debounce = 10 * time.Millisecond
+	w, err := watchCatalog(source, debounce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// Serve requests throughout, so that the race detector sees reloads
+	// happening under load.
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc := &productCatalog{}
+			for ctx.Err() == nil {
+				if _, err := svc.GetProduct(ctx, &pb.GetProductRequest{Id: "B"}); err != nil {
+					t.Error(err)
+					return
+				}
+				if res, err := svc.ListProducts(ctx, &pb.Empty{}); err != nil || len(res.Products) != 2 {
+					t.Errorf("ListProducts() = %v, %v", res, err)
+					return
+				}
+				if _, err := svc.SearchProducts(ctx, &pb.SearchProductsRequest{Query: "mug"}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for i := 1; i <= 3; i++ {
+		name := fmt.Sprintf("v%d", i)
+		writeFile(t, path, catalogJSON(name))
+		waitForName(t, name)
+	}
+
+	// Writing in place is picked up too.
+	if err := os.WriteFile(path, []byte(catalogJSON("in place")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitForName(t, "in place")
+
+	// Broken catalogs are ignored and the last good one is kept.
+	for _, bad := range []string{
+		`{"products": [`,
+		`{"products": []}`,
+		`{"products": [{"id": "A", "name": "dup", "priceUsd": {"currencyCode": "USD"}},
+			{"id": "A", "name": "dup", "priceUsd": {"currencyCode": "USD"}}]}`,
+	} {
+		writeFile(t, path, bad)
+		time.Sleep(10 * debounce)
+		if got := parseCatalog().Product("A").GetName(); got != "in place" {
+			t.Fatalf("after writing %s, product A is named %q, want the last good catalog", bad, got)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * debounce)
+	if got := parseCatalog().Product("A").GetName(); got != "in place" {
+		t.Fatalf("after removing the file, product A is named %q, want the last good catalog", got)
+	}
+
+	writeFile(t, path, catalogJSON("fixed"))
+	waitForName(t, "fixed")
+}
+
+func TestValidateCatalog(t *testing.T) {
+	price := &pb.Money{CurrencyCode: "USD", Units: 1}
+	for _, tc := range []struct {
+		name     string
+		products []*pb.Product
+		valid    bool
+	}{
+		{"valid", []*pb.Product{{Id: "A", Name: "a", PriceUsd: price}, {Id: "B", Name: "b", PriceUsd: price}}, true},
+		{"empty", nil, false},
+		{"no ID", []*pb.Product{{Name: "a", PriceUsd: price}}, false},
+		{"duplicate ID", []*pb.Product{{Id: "A", Name: "a", PriceUsd: price}, {Id: "A", Name: "b", PriceUsd: price}}, false},
+		{"no name", []*pb.Product{{Id: "A", PriceUsd: price}}, false},
+		{"no price", []*pb.Product{{Id: "A", Name: "a"}}, false},
+		{"price not in USD", []*pb.Product{{Id: "A", Name: "a", PriceUsd: &pb.Money{CurrencyCode: "EUR"}}}, false},
+		{"variants", []*pb.Product{{Id: "A", Name: "a", PriceUsd: price, Variants: []*pb.ProductVariant{{Sku: "A-S"}, {Sku: "A-L", PriceUsd: price}}}}, true},
+		{"variant without SKU", []*pb.Product{{Id: "A", Name: "a", PriceUsd: price, Variants: []*pb.ProductVariant{{}}}}, false},
+		{"duplicate SKU", []*pb.Product{
+			{Id: "A", Name: "a", PriceUsd: price, Variants: []*pb.ProductVariant{{Sku: "S"}}},
+			{Id: "B", Name: "b", PriceUsd: price, Variants: []*pb.ProductVariant{{Sku: "S"}}},
+		}, false},
+		{"variant price not in USD", []*pb.Product{{Id: "A", Name: "a", PriceUsd: price, Variants: []*pb.ProductVariant{{Sku: "S", PriceUsd: &pb.Money{CurrencyCode: "EUR"}}}}}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateCatalog(tc.products); (err == nil) != tc.valid {
+				t.Errorf("validateCatalog() = %v, want valid %v", err, tc.valid)
+			}
+		})
+	}
+}
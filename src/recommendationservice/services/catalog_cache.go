@@ -0,0 +1,141 @@
+// recommendationservice-go/services/catalog_cache.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"golang.org/x/sync/singleflight"
+)
+
+// Defaults of CATALOG_CACHE_TTL and CATALOG_CACHE_STALE.
+const (
+	DefaultCatalogCacheTTL   = time.Minute
+	DefaultCatalogCacheStale = 10 * time.Minute
+)
+
+// catalogFetchTimeout bounds the time spent fetching the catalog. Fetches are
+// shared by concurrent calls, so they do not end with the call that started
+// them.
+const catalogFetchTimeout = 5 * time.Second
+
+// How the catalog was served by the cache.
+const (
+	cacheHit          = "hit"            // fresh
+	cacheStale        = "stale"          // stale, refreshed in the background
+	cacheMiss         = "miss"           // fetched
+	cacheStaleIfError = "stale-if-error" // stale, as the fetch failed
+)
+
+// catalogCache keeps the catalog between calls. The catalog is fresh for ttl
+// after it was fetched. For stale more, it is still served while it is
+// refreshed in the background. After that, calls wait for the catalog to be
+// fetched again, but get the stale catalog if the fetch fails, so that
+// recommendations go on while the product catalog is down. Concurrent
+// fetches are collapsed into one. It is safe for concurrent use.
+type catalogCache struct {
+	fetch func(ctx context.Context) ([]*pb.Product, error)
+	ttl   time.Duration
+	stale time.Duration
+	now   func() time.Time
+
+	group singleflight.Group
+
+	mu        sync.RWMutex
+	products  []*pb.Product
+	fetchedAt time.Time
+}
+
+func newCatalogCache(client pb.ProductCatalogServiceClient, ttl, stale time.Duration) *catalogCache {
+	return &catalogCache{
+		fetch: func(ctx context.Context) ([]*pb.Product, error) {
+			resp, err := client.ListProducts(ctx, &pb.Empty{})
+			if err != nil {
+				return nil, err
+			}
+			return resp.Products, nil
+		},
+		ttl:   ttl,
+		stale: stale,
+		now:   time.Now,
+	}
+}
+
+// catalogCacheDurations returns the durations of CATALOG_CACHE_TTL and
+// CATALOG_CACHE_STALE, or their defaults.
+func catalogCacheDurations() (ttl, stale time.Duration, err error) {
+	ttl, stale = DefaultCatalogCacheTTL, DefaultCatalogCacheStale
+	for _, v := range []struct {
+		env string
+		d   *time.Duration
+	}{{"CATALOG_CACHE_TTL", &ttl}, {"CATALOG_CACHE_STALE", &stale}} {
+		s := os.Getenv(v.env)
+		if s == "" {
+			continue
+		}
+		if *v.d, err = time.ParseDuration(s); err != nil || *v.d < 0 {
+			return 0, 0, fmt.Errorf("invalid %s %q", v.env, s)
+		}
+	}
+	return ttl, stale, nil
+}
+
+// Products returns the catalog, and how the cache served it.
+func (c *catalogCache) Products(ctx context.Context) ([]*pb.Product, string, error) {
+	c.mu.RLock()
+	products, age := c.products, c.now().Sub(c.fetchedAt)
+	c.mu.RUnlock()
+
+	switch {
+	case products != nil && age < c.ttl:
+		return products, cacheHit, nil
+	case products != nil && age < c.ttl+c.stale:
+		c.refresh(ctx)
+		return products, cacheStale, nil
+	}
+	select {
+	case r := <-c.refresh(ctx):
+		if r.Err == nil {
+			return r.Val.([]*pb.Product), cacheMiss, nil
+		}
+		if products != nil {
+			return products, cacheStaleIfError, nil
+		}
+		return nil, cacheMiss, r.Err
+	case <-ctx.Done():
+		if products != nil {
+			return products, cacheStaleIfError, nil
+		}
+		return nil, cacheMiss, ctx.Err()
+	}
+}
+
+// refresh fetches the catalog, unless it is already being fetched, and
+// returns the channel the result of the fetch is sent to.
+func (c *catalogCache) refresh(ctx context.Context) <-chan singleflight.Result {
+	// The fetch keeps the trace of the call that starts it, but not its
+	// deadline.
+	ctx = context.WithoutCancel(ctx)
+	return c.group.DoChan("catalog", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(ctx, catalogFetchTimeout)
+		defer cancel()
+		products, err := c.fetch(ctx)
+		if err != nil {
+			log.Printf("Failed to refresh the catalog: %v", err)
+			return nil, err
+		}
+		if products == nil {
+			products = []*pb.Product{}
+		}
+		c.mu.Lock()
+		c.products, c.fetchedAt = products, c.now()
+		c.mu.Unlock()
+		return products, nil
+	})
+}
@@ -0,0 +1,137 @@
+// recommendationservice-go/services/catalog_cache_test.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// fakeCatalog counts fetches, and fails them while down is set.
+type fakeCatalog struct {
+	fetches atomic.Int32
+	down    atomic.Bool
+	// release, if not nil, holds fetches back until it is closed.
+	release chan struct{}
+}
+
+func (f *fakeCatalog) fetch(ctx context.Context) ([]*pb.Product, error) {
+	n := f.fetches.Add(1)
+	if f.release != nil {
+		<-f.release
+	}
+	if f.down.Load() {
+		return nil, errors.New("catalog is down")
+	}
+	return []*pb.Product{{Id: string(rune('A' + n - 1))}}, nil
+}
+
+func newTestCache(f *fakeCatalog) (*catalogCache, *time.Time) {
+	now := time.Unix(0, 0)
+	return &catalogCache{
+		fetch: f.fetch,
+		ttl:   time.Minute,
+		stale: 10 * time.Minute,
+		now:   func() time.Time { return now },
+	}, &now
+}
+
+func TestCatalogCache(t *testing.T) {
+	f := &fakeCatalog{}
+	c, now := newTestCache(f)
+	ctx := context.Background()
+
+	check := func(wantID, wantStatus string) {
+		t.Helper()
+		products, status, err := c.Products(ctx)
+		if err != nil {
+			t.Fatalf("Products() = %v", err)
+		}
+		if products[0].Id != wantID || status != wantStatus {
+			t.Errorf("Products() = %s (%s), want %s (%s)", products[0].Id, status, wantID, wantStatus)
+		}
+	}
+	// waitForFetch waits for the background refresh to be done.
+	waitForFetch := func() {
+		t.Helper()
+		c.group.Do("catalog", func() (interface{}, error) { return nil, nil })
+	}
+
+	check("A", cacheMiss)
+	check("A", cacheHit)
+
+	// A stale catalog is served while it is refreshed.
+	*now = now.Add(2 * time.Minute)
+	check("A", cacheStale)
+	waitForFetch()
+	check("B", cacheHit)
+
+	// A catalog too stale is fetched again.
+	*now = now.Add(time.Hour)
+	check("C", cacheMiss)
+
+	// While the product catalog is down, the stale catalog is served.
+	f.down.Store(true)
+	*now = now.Add(2 * time.Minute)
+	check("C", cacheStale)
+	waitForFetch()
+	*now = now.Add(time.Hour)
+	check("C", cacheStaleIfError)
+	if got := f.fetches.Load(); got != 5 {
+		t.Errorf("got %d fetches, want 5", got)
+	}
+}
+
+func TestCatalogCacheErrors(t *testing.T) {
+	f := &fakeCatalog{}
+	f.down.Store(true)
+	c, _ := newTestCache(f)
+	if _, _, err := c.Products(context.Background()); err == nil {
+		t.Error("Products() without a catalog = nil error")
+	}
+
+	// Calls end with their context, but not the fetch they wait for.
+	f.down.Store(false)
+	f.release = make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.Products(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Products() = %v, want DeadlineExceeded", err)
+	}
+	close(f.release)
+	if products, _, err := c.Products(context.Background()); err != nil || len(products) != 1 {
+		t.Errorf("Products() = %v, %v after the fetch", products, err)
+	}
+}
+
+func TestCatalogCacheSingleflight(t *testing.T) {
+	f := &fakeCatalog{release: make(chan struct{})}
+	c, _ := newTestCache(f)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := c.Products(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// Wait for the first fetch to start before letting it finish.
+	for f.fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(f.release)
+	wg.Wait()
+	if got := f.fetches.Load(); got != 1 {
+		t.Errorf("got %d fetches for concurrent calls, want 1", got)
+	}
+}
@@ -0,0 +1,152 @@
+// recommendationservice-go/services/content.go
+
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// categoryWeight is the weight of category overlap in the score of content
+// similarity, the rest being the similarity of descriptions.
+const categoryWeight = 0.5
+
+// stopWords are left out of descriptions.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "its": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "with": true, "your": true, "you": true,
+}
+
+// contentRecommender recommends the products most similar to the products of
+// the request: those sharing most of their categories and whose names and
+// descriptions use the same distinctive words.
+type contentRecommender struct{}
+
+// Recommend scores each product by its mean similarity to the requested
+// products. Similarity is the weighted sum of the Jaccard index of the
+// categories and the cosine similarity of the TF-IDF vectors of the names and
+// descriptions. Ties are broken by product ID.
+func (contentRecommender) Recommend(_ string, productIDs []string, catalog []*pb.Product, n int) []*pb.Recommendation {
+	requested := set(productIDs)
+	vectors := tfidf(catalog)
+	var seeds []int
+	for i, p := range catalog {
+		if requested[p.Id] {
+			seeds = append(seeds, i)
+		}
+	}
+	scores := make(map[string]float64)
+	if len(seeds) == 0 {
+		return top(scores, nil, n, ReasonSimilarContent)
+	}
+	for i, p := range catalog {
+		if requested[p.Id] {
+			continue
+		}
+		var sum float64
+		for _, s := range seeds {
+			sum += categoryWeight*jaccard(catalog[s].Categories, p.Categories) +
+				(1-categoryWeight)*cosine(vectors[s], vectors[i])
+		}
+		scores[p.Id] = sum / float64(len(seeds))
+	}
+	return top(scores, nil, n, ReasonSimilarContent)
+}
+
+// vector is a sparse vector of the weights of words, sorted by word so that
+// sums over it are always done in the same order.
+type vector []weightedWord
+
+type weightedWord struct {
+	word   string
+	weight float64
+}
+
+// tfidf returns the unit TF-IDF vector of the name and description of each
+// product, with the inverse document frequencies of the catalog.
+func tfidf(catalog []*pb.Product) []vector {
+	counts := make([]map[string]int, len(catalog))
+	df := make(map[string]int)
+	for i, p := range catalog {
+		counts[i] = make(map[string]int)
+		for _, w := range tokenize(p.Name + " " + p.Description) {
+			if counts[i][w] == 0 {
+				df[w]++
+			}
+			counts[i][w]++
+		}
+	}
+	// Smoothed so that words found in every product still count a little.
+	n := float64(len(catalog))
+	vectors := make([]vector, len(catalog))
+	for i, c := range counts {
+		v := make(vector, 0, len(c))
+		for w, count := range c {
+			v = append(v, weightedWord{w, float64(count) * (math.Log((1+n)/(1+float64(df[w]))) + 1)})
+		}
+		sort.Slice(v, func(a, b int) bool { return v[a].word < v[b].word })
+		var norm float64
+		for _, x := range v {
+			norm += x.weight * x.weight
+		}
+		norm = math.Sqrt(norm)
+		for j := range v {
+			v[j].weight /= norm
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// tokenize returns the lowercase words of s other than stop words.
+func tokenize(s string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !stopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// cosine returns the cosine similarity of the unit vectors a and b.
+func cosine(a, b vector) float64 {
+	var dot float64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].word < b[j].word:
+			i++
+		case a[i].word > b[j].word:
+			j++
+		default:
+			dot += a[i].weight * b[j].weight
+			i++
+			j++
+		}
+	}
+	return dot
+}
+
+// jaccard returns the share of the categories of either a or b found in both.
+func jaccard(a, b []string) float64 {
+	union := set(a)
+	var both int
+	for c := range set(b) {
+		if union[c] {
+			both++
+		}
+		union[c] = true
+	}
+	if len(union) == 0 {
+		return 0
+	}
+	return float64(both) / float64(len(union))
+}
@@ -0,0 +1,86 @@
+// recommendationservice-go/services/content_test.go
+
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+var contentCatalog = []*pb.Product{
+	{Id: "sunglasses", Name: "Sunglasses", Description: "Add a modern touch to your outfits with these sleek aviator sunglasses.", Categories: []string{"accessories"}},
+	{Id: "watch", Name: "Watch", Description: "This gold-tone stainless steel watch will work with most of your outfits.", Categories: []string{"accessories"}},
+	{Id: "loafers", Name: "Loafers", Description: "A neat addition to your summer wardrobe.", Categories: []string{"footwear", "clothing"}},
+	{Id: "tank", Name: "Tank Top", Description: "Perfectly cropped cotton tank, with a scooped neckline.", Categories: []string{"clothing", "tops"}},
+	{Id: "shirt", Name: "Cotton Shirt", Description: "A relaxed cotton shirt for summer.", Categories: []string{"clothing", "tops"}},
+	{Id: "mug", Name: "Mug", Description: "A simple ceramic mug with a mustard interior.", Categories: []string{"kitchen"}},
+	{Id: "jar-b", Name: "Jar", Description: "A simple glass jar.", Categories: []string{"kitchen"}},
+	{Id: "jar-a", Name: "Jar", Description: "A simple glass jar.", Categories: []string{"kitchen"}},
+	{Id: "gift", Name: "Gift Card"},
+}
+
+func TestContentRecommender(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		productIDs []string
+		n          int
+		want       []string
+	}{
+		// The shirt shares both categories and "cotton" with the tank top,
+		// the loafers only a category.
+		{"categories and description", []string{"tank"}, 3, []string{"shirt", "loafers"}},
+		// The watch is the only other accessory, and shares "outfits".
+		{"description within category", []string{"sunglasses"}, 5, []string{"watch"}},
+		// The jars are the same, and sorted by ID.
+		{"ties by ID", []string{"mug"}, 5, []string{"jar-a", "jar-b"}},
+		{"limit", []string{"mug"}, 1, []string{"jar-a"}},
+		// The shirt is like both the tank top and the loafers.
+		{"several products", []string{"tank", "loafers"}, 5, []string{"shirt"}},
+		{"unknown product", []string{"lamp"}, 5, []string{}},
+		{"no content", []string{"gift"}, 5, []string{}},
+		{"no products", nil, 5, []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ids(contentRecommender{}.Recommend("", tc.productIDs, contentCatalog, tc.n))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Recommend(%v) = %v, want %v", tc.productIDs, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("The gold-tone Watch, with 2 hands!")
+	want := []string{"gold", "tone", "watch", "2", "hands"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize() = %v, want %v", got, want)
+	}
+}
+
+func TestSimilarities(t *testing.T) {
+	for _, tc := range []struct {
+		a, b []string
+		want float64
+	}{
+		{[]string{"clothing", "tops"}, []string{"tops", "clothing"}, 1},
+		{[]string{"clothing", "tops"}, []string{"clothing", "footwear"}, 1.0 / 3},
+		{[]string{"kitchen"}, []string{"kitchen", "kitchen"}, 1},
+		{[]string{"kitchen"}, []string{"decor"}, 0},
+		{nil, nil, 0},
+	} {
+		if got := jaccard(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("jaccard(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+
+	v := tfidf(contentCatalog)
+	if got := cosine(v[6], v[7]); math.Abs(got-1) > 1e-9 {
+		t.Errorf("cosine of the same jars = %v, want 1", got)
+	}
+	if got := cosine(v[0], v[5]); got != 0 {
+		t.Errorf("cosine of sunglasses and mug = %v, want 0", got)
+	}
+}
@@ -0,0 +1,202 @@
+// recommendationservice-go/services/cooccurrence.go
+
+package services
+
+import (
+	"container/list"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+const (
+	// maxUserProducts is how many of the products they bought last are kept
+	// for each user.
+	maxUserProducts = 20
+	// maxUsers is how many of the users who ordered last are remembered.
+	maxUsers = 10000
+	// maxOrders is how many of the last orders are counted, so that the
+	// counts follow what is bought lately and stay within bounds.
+	maxOrders = 10000
+	// maxOrderProducts is how many products of an order are counted, as an
+	// order counts every pair of its products.
+	maxOrderProducts = 50
+)
+
+// userProductWeight is the weight of the products a user bought before,
+// relative to the products of the request, in the score of a product.
+const userProductWeight = 0.5
+
+// OrderHistory counts how often products are bought together in the last
+// orders placed, and remembers what the users who ordered last bought last. It
+// is safe for concurrent use.
+type OrderHistory struct {
+	mu sync.RWMutex
+	// orders counts the orders of each product, and together the orders of
+	// each pair of products, both ways, among the orders of recent, oldest
+	// first.
+	orders   map[string]int
+	together map[string]map[string]int
+	recent   [][]string
+	// users holds the elements of userOrder, which lists the products of
+	// each user from the user who ordered first to the user who ordered last.
+	users     map[string]*list.Element
+	userOrder *list.List
+}
+
+// userProducts are the products a user bought last, oldest first.
+type userProducts struct {
+	userID   string
+	products []string
+}
+
+// NewOrderHistory returns an empty history.
+func NewOrderHistory() *OrderHistory {
+	return &OrderHistory{
+		orders:    make(map[string]int),
+		together:  make(map[string]map[string]int),
+		users:     make(map[string]*list.Element),
+		userOrder: list.New(),
+	}
+}
+
+// Record adds an order of the products of productIDs by userID. Once
+// maxOrders orders are counted, the oldest stops counting.
+func (h *OrderHistory) Record(userID string, productIDs []string) {
+	ids := make([]string, 0, len(productIDs))
+	seen := make(map[string]bool)
+	for _, id := range productIDs {
+		if id != "" && !seen[id] && len(ids) < maxOrderProducts {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count(ids, 1)
+	h.recent = append(h.recent, ids)
+	if len(h.recent) > maxOrders {
+		h.count(h.recent[0], -1)
+		h.recent[0] = nil
+		h.recent = h.recent[1:]
+	}
+	if userID != "" {
+		h.remember(userID, ids)
+	}
+}
+
+// count adds delta to the counts of the products of an order and of their
+// pairs, and drops the counts that reach zero.
+func (h *OrderHistory) count(ids []string, delta int) {
+	for i, a := range ids {
+		if h.orders[a] += delta; h.orders[a] == 0 {
+			delete(h.orders, a)
+		}
+		for _, b := range ids[i+1:] {
+			h.pair(a, b, delta)
+			h.pair(b, a, delta)
+		}
+	}
+}
+
+func (h *OrderHistory) pair(a, b string, delta int) {
+	m := h.together[a]
+	if m == nil {
+		m = make(map[string]int)
+		h.together[a] = m
+	}
+	if m[b] += delta; m[b] == 0 {
+		delete(m, b)
+		if len(m) == 0 {
+			delete(h.together, a)
+		}
+	}
+}
+
+// remember adds the products of an order to those userID bought last, and
+// forgets the user who ordered first once more than maxUsers are remembered.
+func (h *OrderHistory) remember(userID string, ids []string) {
+	var u *userProducts
+	if e, ok := h.users[userID]; ok {
+		h.userOrder.MoveToBack(e)
+		u = e.Value.(*userProducts)
+	} else {
+		u = &userProducts{userID: userID}
+		h.users[userID] = h.userOrder.PushBack(u)
+		if h.userOrder.Len() > maxUsers {
+			first := h.userOrder.Remove(h.userOrder.Front()).(*userProducts)
+			delete(h.users, first.userID)
+		}
+	}
+	// Products bought again move to the end rather than take another place.
+	products := slices.DeleteFunc(u.products, func(id string) bool { return slices.Contains(ids, id) })
+	products = append(products, ids...)
+	if len(products) > maxUserProducts {
+		products = slices.Clone(products[len(products)-maxUserProducts:])
+	}
+	u.products = products
+}
+
+// bought returns the products userID bought last, if any.
+func (h *OrderHistory) bought(userID string) []string {
+	if e, ok := h.users[userID]; ok {
+		return e.Value.(*userProducts).products
+	}
+	return nil
+}
+
+// coOccurrenceRecommender recommends the products most often bought together
+// with the products of the request and those the user bought before, other
+// than these.
+type coOccurrenceRecommender struct {
+	orders *OrderHistory
+}
+
+// Recommend scores each product by how often it was bought together with each
+// seed product, normalized by how often both were bought at all (the cosine
+// similarity of their orders) so that popular products do not always win.
+func (r coOccurrenceRecommender) Recommend(userID string, productIDs []string, catalog []*pb.Product, n int) []*pb.Recommendation {
+	h := r.orders
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	seeds := make(map[string]float64)
+	for _, id := range h.bought(userID) {
+		seeds[id] = userProductWeight
+	}
+	for _, id := range productIDs {
+		seeds[id] = 1
+	}
+
+	// Only products of the catalog the user has neither bought nor asked about
+	// are recommended.
+	candidates := make(map[string]bool, len(catalog))
+	for _, p := range catalog {
+		if _, ok := seeds[p.Id]; !ok {
+			candidates[p.Id] = true
+		}
+	}
+	scores := make(map[string]float64)
+	counts := make(map[string]int)
+	// Seeds are visited in order so that equal scores add up the same way.
+	ids := make([]string, 0, len(seeds))
+	for id := range seeds {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, seed := range ids {
+		weight := seeds[seed]
+		for id, together := range h.together[seed] {
+			if !candidates[id] {
+				continue
+			}
+			scores[id] += weight * float64(together) / math.Sqrt(float64(h.orders[seed]*h.orders[id]))
+			counts[id] += together
+		}
+	}
+	return top(scores, counts, n, ReasonBoughtTogether)
+}
@@ -0,0 +1,82 @@
+// recommendationservice-go/services/experiment.go
+
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultExperiment is the name of the experiment unless
+// RECOMMENDATION_EXPERIMENT_NAME says otherwise.
+const DefaultExperiment = "recommendations"
+
+// Experiment splits users into arms that get the recommendations of different
+// strategies, so that the strategies can be compared.
+type Experiment struct {
+	// Name identifies the experiment, and tells how users are split: users
+	// are assigned to other arms in an experiment with another name.
+	Name string
+	Arms []Arm
+}
+
+// Arm is a share of the users of an experiment, who get the recommendations
+// of the strategy the arm is named after.
+type Arm struct {
+	Name        string
+	Weight      int
+	Recommender Recommender
+}
+
+// ParseExperiment returns the experiment name with the arms of spec, a list of
+// strategies with their weights such as "cooccurrence:2,random:1". A strategy
+// without a weight weighs 1.
+func ParseExperiment(name, spec string, orders *OrderHistory) (*Experiment, error) {
+	e := &Experiment{Name: name}
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(spec, ",") {
+		strategy, weight, hasWeight := strings.Cut(strings.TrimSpace(field), ":")
+		arm := Arm{Name: strategy, Weight: 1}
+		if hasWeight {
+			w, err := strconv.Atoi(weight)
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("invalid weight %q of arm %q", weight, strategy)
+			}
+			arm.Weight = w
+		}
+		if seen[strategy] {
+			return nil, fmt.Errorf("arm %q is given twice", strategy)
+		}
+		seen[strategy] = true
+		var err error
+		if arm.Recommender, err = NewRecommender(strategy, orders); err != nil {
+			return nil, err
+		}
+		e.Arms = append(e.Arms, arm)
+	}
+	return e, nil
+}
+
+// Assign returns the arm of userID. Users are assigned to arms in proportion
+// to their weights by hashing their ID with the name of the experiment, so a
+// user always gets the same arm of an experiment.
+func (e *Experiment) Assign(userID string) *Arm {
+	var total int
+	for _, a := range e.Arms {
+		total += a.Weight
+	}
+	// Every bit of a SHA-256 digest depends on the whole input, so that users
+	// are split independently by experiments with different names.
+	sum := sha256.Sum256([]byte(e.Name + "/" + userID))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+	for i := range e.Arms {
+		if bucket < e.Arms[i].Weight {
+			return &e.Arms[i]
+		}
+		bucket -= e.Arms[i].Weight
+	}
+	panic("unreachable")
+}
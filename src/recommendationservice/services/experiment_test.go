@@ -0,0 +1,62 @@
+// recommendationservice-go/services/experiment_test.go
+
+package services
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestParseExperiment(t *testing.T) {
+	e, err := ParseExperiment("test", "cooccurrence:3, random", NewOrderHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Arms) != 2 || e.Arms[0].Name != "cooccurrence" || e.Arms[0].Weight != 3 || e.Arms[1].Name != "random" || e.Arms[1].Weight != 1 {
+		t.Errorf("arms = %+v", e.Arms)
+	}
+	for _, invalid := range []string{"", "popular", "random:0", "random:x", "random,random:2"} {
+		if _, err := ParseExperiment("test", invalid, nil); err == nil {
+			t.Errorf("ParseExperiment(%q) = nil error", invalid)
+		}
+	}
+}
+
+func TestAssign(t *testing.T) {
+	e, err := ParseExperiment("test", "content:3,random:1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const users = 10000
+	counts := make(map[string]int)
+	for i := 0; i < users; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		arm := e.Assign(user)
+		if again := e.Assign(user); again != arm {
+			t.Fatalf("%s was assigned to %s, then to %s", user, arm.Name, again.Name)
+		}
+		counts[arm.Name]++
+	}
+	// Arms get their share of users, give or take 2%.
+	if share := float64(counts["content"]) / users; math.Abs(share-0.75) > 0.02 {
+		t.Errorf("content arm got %.3f of the users, want 0.75", share)
+	}
+
+	// Another experiment splits users differently.
+	other, err := ParseExperiment("other", "content:3,random:1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := 0
+	for i := 0; i < users; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		if e.Assign(user).Name == other.Assign(user).Name {
+			same++
+		}
+	}
+	// Independent assignments agree for 0.75² + 0.25² of the users.
+	if share := float64(same) / users; math.Abs(share-0.625) > 0.02 {
+		t.Errorf("experiments agree for %.3f of the users, want 0.625", share)
+	}
+}
@@ -4,8 +4,9 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"math/rand"
 	"os"
 
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
@@ -14,38 +15,71 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+// maxResponses is the number of products recommended at most.
+const maxResponses = 5
+
 // RecommendationService implements the gRPC RecommendationService.
 type RecommendationService struct {
 	pb.UnimplementedRecommendationServiceServer
-	productCatalogClient pb.ProductCatalogServiceClient
-	tracer               trace.Tracer
+	catalog    *catalogCache
+	tracer     trace.Tracer
+	experiment *Experiment
+	orders     *OrderHistory
 }
 
-// NewRecommendationService constructor.
+// NewRecommendationService constructor. It returns an error if the service is
+// misconfigured.
 func NewRecommendationService() (*RecommendationService, error) {
-	// Establish a connection to the ProductCatalogService.
 	catalogAddr := os.Getenv("PRODUCT_CATALOG_SERVICE_ADDR")
 	if catalogAddr == "" {
-		log.Fatal("PRODUCT_CATALOG_SERVICE_ADDR environment variable not set")
+		return nil, errors.New("PRODUCT_CATALOG_SERVICE_ADDR environment variable not set")
+	}
+
+	// Cache the catalog between calls.
+	ttl, stale, err := catalogCacheDurations()
+	if err != nil {
+		return nil, err
+	}
+
+	// Split users between the strategies of the experiment, or give them
+	// all the recommendations of a single strategy.
+	spec := os.Getenv("RECOMMENDATION_EXPERIMENT")
+	if spec == "" {
+		spec = os.Getenv("RECOMMENDATION_STRATEGY")
 	}
+	if spec == "" {
+		spec = DefaultStrategy
+	}
+	name := os.Getenv("RECOMMENDATION_EXPERIMENT_NAME")
+	if name == "" {
+		name = DefaultExperiment
+	}
+	orders := NewOrderHistory()
+	experiment, err := ParseExperiment(name, spec, orders)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recommendation experiment: %w", err)
+	}
+	log.Printf("Recommending products in experiment %s with arms %s", name, spec)
 
+	// Establish a connection to the ProductCatalogService.
 	conn, err := grpc.NewClient(catalogAddr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	)
-
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to connect to the product catalog at %s: %w", catalogAddr, err)
 	}
 
-	productCatalogClient := pb.NewProductCatalogServiceClient(conn)
-
 	return &RecommendationService{
-		productCatalogClient: productCatalogClient,
-		tracer:               otel.Tracer("recommendationservice"),
+		catalog:    newCatalogCache(pb.NewProductCatalogServiceClient(conn), ttl, stale),
+		tracer:     otel.Tracer("recommendationservice"),
+		experiment: experiment,
+		orders:     orders,
 	}, nil
 }
 
@@ -61,63 +95,72 @@ func (r *RecommendationService) ListRecommendations(ctx context.Context, req *pb
 
 	log.Printf("Received ListRecommendations request for user: %s", req.UserId)
 
-	// Get the product list from the ProductCatalogService.
-	catResponse, err := r.productCatalogClient.ListProducts(ctx, &pb.Empty{})
+	// Get the product list from the cache of the ProductCatalogService.
+	products, cacheStatus, err := r.catalog.Products(ctx)
+	span.SetAttributes(attribute.String("catalog.cache", cacheStatus))
 	if err != nil {
 		span.SetAttributes(attribute.String("error", err.Error()))
 		log.Printf("Failed to get products from catalog: %v", err)
-		return &pb.ListRecommendationsResponse{}, nil // Ignore the error and continue.
+		return nil, catalogError(err)
 	}
 
-	// Create a list of product IDs.
-	var productIDs []string
-	for _, product := range catResponse.Products {
-		productIDs = append(productIDs, product.Id)
-	}
-
-	// Exclude the requested product IDs.
-	filteredProducts := make([]string, 0)
-	requestedSet := make(map[string]bool)
-	for _, id := range req.ProductIds {
-		requestedSet[id] = true
-	}
-
-	for _, id := range productIDs {
-		if !requestedSet[id] {
-			filteredProducts = append(filteredProducts, id)
-		}
-	}
-
-	// Randomly select up to 5 products.
-	maxResponses := 5
-	numProducts := len(filteredProducts)
-	numReturn := min(maxResponses, numProducts)
-
-	var recommendations []string
-	if numReturn > 0 {
-		// Randomly select indices.
-		indices := rand.Perm(numProducts)[:numReturn]
-		for _, idx := range indices {
-			recommendations = append(recommendations, filteredProducts[idx])
-		}
+	// Pick up to maxResponses products other than the requested ones, with
+	// the strategy of the arm of the user.
+	arm := r.experiment.Assign(req.UserId)
+	recommendations := arm.Recommender.Recommend(req.UserId, req.ProductIds, products, maxResponses)
+	productIDs := make([]string, len(recommendations))
+	reasons := make([]string, len(recommendations))
+	for i, rec := range recommendations {
+		productIDs[i], reasons[i] = rec.ProductId, rec.Reason
 	}
 
+	// Record the exposure of the user to the arm, so that arms can be
+	// compared in traces.
 	span.SetAttributes(
+		attribute.String("experiment.name", r.experiment.Name),
+		attribute.String("experiment.arm", arm.Name),
+		attribute.Bool("experiment.exposed", len(recommendations) > 0),
 		attribute.Int("recommendations.count", len(recommendations)),
-		attribute.StringSlice("recommendations.product_ids", recommendations),
+		attribute.StringSlice("recommendations.product_ids", productIDs),
+		attribute.StringSlice("recommendations.reasons", reasons),
 	)
 
-	log.Printf("Returning %d recommendations for user %s", len(recommendations), req.UserId)
+	log.Printf("Returning %d recommendations of arm %s for user %s", len(recommendations), arm.Name, req.UserId)
 
 	return &pb.ListRecommendationsResponse{
-		ProductIds: recommendations,
+		ProductIds:      productIDs,
+		Experiment:      r.experiment.Name,
+		Arm:             arm.Name,
+		Recommendations: recommendations,
 	}, nil
 }
 
-// min returns the minimum of two integers.
-func min(a, b int) int {
-	if a < b {
-		return a
+// catalogError returns the status of a call that failed to get the catalog:
+// that of the context if the call ended, or else Unavailable, as the catalog
+// may be back soon.
+func catalogError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Errorf(codes.Unavailable, "failed to get products from catalog: %s", status.Convert(err).Message())
+}
+
+// RecordOrder RPC: learns which products were bought together from an order.
+func (r *RecommendationService) RecordOrder(ctx context.Context, req *pb.OrderEvent) (*pb.Empty, error) {
+	_, span := r.tracer.Start(ctx, "RecordOrder")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("user.id", req.UserId),
+		attribute.String("order.id", req.OrderId),
+		attribute.StringSlice("order.product_ids", req.ProductIds),
+	)
+
+	if len(req.ProductIds) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "order has no products")
 	}
-	return b
+	r.orders.Record(req.UserId, req.ProductIds)
+
+	log.Printf("Recorded order %s of %d products for user %s", req.OrderId, len(req.ProductIds), req.UserId)
+	return &pb.Empty{}, nil
 }
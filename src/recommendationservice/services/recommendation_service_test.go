@@ -0,0 +1,81 @@
+// recommendationservice-go/services/recommendation_service_test.go
+
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestService(t *testing.T, f *fakeCatalog) *RecommendationService {
+	t.Helper()
+	c, _ := newTestCache(f)
+	e, err := ParseExperiment("test", StrategyRandom, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &RecommendationService{
+		catalog:    c,
+		tracer:     otel.Tracer("test"),
+		experiment: e,
+		orders:     NewOrderHistory(),
+	}
+}
+
+func TestListRecommendations(t *testing.T) {
+	svc := newTestService(t, &fakeCatalog{})
+	resp, err := svc.ListRecommendations(context.Background(), &pb.ListRecommendationsRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ProductIds) != 1 || resp.Experiment != "test" || resp.Arm != StrategyRandom {
+		t.Errorf("ListRecommendations() = %v", resp)
+	}
+	if len(resp.Recommendations) != 1 || resp.Recommendations[0].Reason != ReasonRandom {
+		t.Errorf("recommendations = %v", resp.Recommendations)
+	}
+}
+
+func TestListRecommendationsErrors(t *testing.T) {
+	f := &fakeCatalog{}
+	f.down.Store(true)
+	svc := newTestService(t, f)
+	req := &pb.ListRecommendationsRequest{UserId: "u1"}
+
+	if _, err := svc.ListRecommendations(context.Background(), req); status.Code(err) != codes.Unavailable {
+		t.Errorf("ListRecommendations() with the catalog down = %v, want Unavailable", err)
+	}
+
+	f.down.Store(false)
+	f.release = make(chan struct{})
+	defer close(f.release)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := svc.ListRecommendations(ctx, req); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("ListRecommendations() past its deadline = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestNewRecommendationService(t *testing.T) {
+	t.Setenv("PRODUCT_CATALOG_SERVICE_ADDR", "")
+	if _, err := NewRecommendationService(); err == nil {
+		t.Error("NewRecommendationService() without a catalog = nil error")
+	}
+
+	t.Setenv("PRODUCT_CATALOG_SERVICE_ADDR", "localhost:3550")
+	t.Setenv("RECOMMENDATION_EXPERIMENT", "popular:1")
+	if _, err := NewRecommendationService(); err == nil {
+		t.Error("NewRecommendationService() with an unknown strategy = nil error")
+	}
+
+	t.Setenv("RECOMMENDATION_EXPERIMENT", "content:1,random:1")
+	if _, err := NewRecommendationService(); err != nil {
+		t.Errorf("NewRecommendationService() = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// recommendationservice-go/services/recommender.go
+
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// Recommender picks products of the catalog for a user who is looking at, or
+// has in their cart, the products of productIDs.
+type Recommender interface {
+	// Recommend returns up to n products of catalog, best first, with why
+	// they were recommended. It never returns the products of productIDs.
+	Recommend(userID string, productIDs []string, catalog []*pb.Product, n int) []*pb.Recommendation
+}
+
+// Reasons why products are recommended.
+const (
+	ReasonBoughtTogether = "bought-together"
+	ReasonSameCategory   = "same-category"
+	ReasonSimilarContent = "similar-content"
+	ReasonRandom         = "random"
+)
+
+// Strategies that can be selected with RECOMMENDATION_STRATEGY.
+const (
+	StrategyRandom       = "random"
+	StrategyCategory     = "category"
+	StrategyContent      = "content"
+	StrategyCoOccurrence = "cooccurrence"
+)
+
+// DefaultStrategy is the strategy used when RECOMMENDATION_STRATEGY is not set.
+const DefaultStrategy = StrategyRandom
+
+// NewRecommender returns the recommender of strategy. Strategies that may not
+// find enough products fall back to the simpler ones: co-occurrence falls back
+// to category similarity, and category and content similarity to random
+// products.
+func NewRecommender(strategy string, orders *OrderHistory) (Recommender, error) {
+	switch strategy {
+	case StrategyRandom:
+		return randomRecommender{}, nil
+	case StrategyCategory:
+		return fallback{categoryRecommender{}, randomRecommender{}}, nil
+	case StrategyContent:
+		return fallback{contentRecommender{}, randomRecommender{}}, nil
+	case StrategyCoOccurrence:
+		return fallback{coOccurrenceRecommender{orders}, categoryRecommender{}, randomRecommender{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown recommendation strategy %q", strategy)
+	}
+}
+
+// fallback recommends the products of its first recommender, and fills up the
+// list with those of the next ones.
+type fallback []Recommender
+
+func (f fallback) Recommend(userID string, productIDs []string, catalog []*pb.Product, n int) []*pb.Recommendation {
+	var recs []*pb.Recommendation
+	chosen := make(map[string]bool)
+	for _, r := range f {
+		if len(recs) == n {
+			break
+		}
+		for _, rec := range r.Recommend(userID, productIDs, catalog, n) {
+			if len(recs) < n && !chosen[rec.ProductId] {
+				chosen[rec.ProductId] = true
+				recs = append(recs, rec)
+			}
+		}
+	}
+	return recs
+}
+
+// randomRecommender recommends random products.
+type randomRecommender struct{}
+
+func (randomRecommender) Recommend(_ string, productIDs []string, catalog []*pb.Product, n int) []*pb.Recommendation {
+	candidates := others(productIDs, catalog)
+	// The top-level functions of math/rand are safe for concurrent use.
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	recs := make([]*pb.Recommendation, 0, n)
+	for _, p := range candidates {
+		if len(recs) == n {
+			break
+		}
+		recs = append(recs, &pb.Recommendation{ProductId: p.Id, Reason: ReasonRandom})
+	}
+	return recs
+}
+
+// categoryRecommender recommends the products that share the most categories
+// with the products of the request.
+type categoryRecommender struct{}
+
+func (categoryRecommender) Recommend(_ string, productIDs []string, catalog []*pb.Product, n int) []*pb.Recommendation {
+	categories := make(map[string]bool)
+	requested := set(productIDs)
+	for _, p := range catalog {
+		if requested[p.Id] {
+			for _, c := range p.Categories {
+				categories[c] = true
+			}
+		}
+	}
+	scores := make(map[string]float64)
+	for _, p := range others(productIDs, catalog) {
+		for _, c := range p.Categories {
+			if categories[c] {
+				scores[p.Id]++
+			}
+		}
+	}
+	return top(scores, nil, n, ReasonSameCategory)
+}
+
+// others returns the products of catalog that are not in productIDs.
+func others(productIDs []string, catalog []*pb.Product) []*pb.Product {
+	requested := set(productIDs)
+	products := make([]*pb.Product, 0, len(catalog))
+	for _, p := range catalog {
+		if !requested[p.Id] {
+			products = append(products, p)
+		}
+	}
+	return products
+}
+
+func set(ids []string) map[string]bool {
+	s := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		s[id] = true
+	}
+	return s
+}
+
+// top recommends for reason the n products with the highest positive scores.
+// Ties are broken by the higher count, if counts is not nil, and then by ID.
+func top(scores map[string]float64, counts map[string]int, n int, reason string) []*pb.Recommendation {
+	ids := make([]string, 0, len(scores))
+	for id, s := range scores {
+		if s > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := ids[i], ids[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	recs := make([]*pb.Recommendation, len(ids))
+	for i, id := range ids {
+		recs[i] = &pb.Recommendation{ProductId: id, Reason: reason}
+	}
+	return recs
+}
@@ -0,0 +1,148 @@
+// recommendationservice-go/services/recommender_test.go
+
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+var testCatalog = []*pb.Product{
+	{Id: "mug", Categories: []string{"kitchen"}},
+	{Id: "jar", Categories: []string{"kitchen"}},
+	{Id: "towel", Categories: []string{"kitchen", "home"}},
+	{Id: "lamp", Categories: []string{"home", "decor"}},
+	{Id: "tank", Categories: []string{"clothing", "tops"}},
+	{Id: "watch", Categories: []string{"accessories"}},
+}
+
+func testHistory() *OrderHistory {
+	h := NewOrderHistory()
+	h.Record("u1", []string{"mug", "jar"})
+	h.Record("u2", []string{"mug", "jar", "watch"})
+	h.Record("u3", []string{"mug", "watch", "watch"})
+	h.Record("u4", []string{"tank", "watch"})
+	h.Record("u4", []string{"tank", "watch"})
+	h.Record("u5", []string{"lamp"})
+	return h
+}
+
+// ids returns the products of recs.
+func ids(recs []*pb.Recommendation) []string {
+	ids := make([]string, len(recs))
+	for i, r := range recs {
+		ids[i] = r.ProductId
+	}
+	return ids
+}
+
+func TestRecommenders(t *testing.T) {
+	h := testHistory()
+	for _, tc := range []struct {
+		name       string
+		r          Recommender
+		userID     string
+		productIDs []string
+		n          int
+		want       []string
+	}{
+		// jar and watch are both bought with mug twice, but jar is bought
+		// less often in general.
+		{"cooccurrence", coOccurrenceRecommender{h}, "", []string{"mug"}, 5, []string{"jar", "watch"}},
+		{"cooccurrence limit", coOccurrenceRecommender{h}, "", []string{"mug"}, 1, []string{"jar"}},
+		{"cooccurrence never bought together", coOccurrenceRecommender{h}, "", []string{"lamp"}, 5, []string{}},
+		{"cooccurrence of the user's orders", coOccurrenceRecommender{h}, "u4", nil, 5, []string{"mug", "jar"}},
+		{"cooccurrence excludes requested", coOccurrenceRecommender{h}, "", []string{"mug", "jar"}, 5, []string{"watch"}},
+		{"category", categoryRecommender{}, "", []string{"towel"}, 5, []string{"jar", "lamp", "mug"}},
+		{"category without request", categoryRecommender{}, "", nil, 5, []string{}},
+		{"fallback", fallback{coOccurrenceRecommender{h}, categoryRecommender{}}, "", []string{"jar"}, 4, []string{"mug", "watch", "towel"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ids(tc.r.Recommend(tc.userID, tc.productIDs, testCatalog, tc.n))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Recommend(%q, %v) = %v, want %v", tc.userID, tc.productIDs, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewRecommender(t *testing.T) {
+	for _, strategy := range []string{StrategyRandom, StrategyCategory, StrategyContent, StrategyCoOccurrence} {
+		r, err := NewRecommender(strategy, testHistory())
+		if err != nil {
+			t.Fatalf("NewRecommender(%q): %v", strategy, err)
+		}
+		// Every strategy falls back to random products.
+		got := ids(r.Recommend("", []string{"lamp"}, testCatalog, 5))
+		if len(got) != 5 {
+			t.Errorf("%s: got %v, want 5 products", strategy, got)
+		}
+		for _, id := range got {
+			if id == "lamp" {
+				t.Errorf("%s: recommended the requested product", strategy)
+			}
+		}
+	}
+	if _, err := NewRecommender("popular", nil); err == nil {
+		t.Error("NewRecommender(popular) = nil error")
+	}
+}
+
+func TestReasons(t *testing.T) {
+	r, err := NewRecommender(StrategyCoOccurrence, testHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := r.Recommend("", []string{"jar"}, testCatalog, 4)
+	want := []string{ReasonBoughtTogether, ReasonBoughtTogether, ReasonSameCategory, ReasonRandom}
+	if len(got) != len(want) {
+		t.Fatalf("got %d recommendations, want %d", len(got), len(want))
+	}
+	for i, rec := range got {
+		if rec.Reason != want[i] {
+			t.Errorf("reason of %s = %q, want %q", rec.ProductId, rec.Reason, want[i])
+		}
+	}
+}
+
+func TestOrderHistory(t *testing.T) {
+	h := NewOrderHistory()
+	// Buying a product again does not push out the others.
+	for i := 0; i < maxUserProducts; i++ {
+		h.Record("u1", []string{"mug", string(rune('a' + i))})
+	}
+	got := h.bought("u1")
+	want := []string{"b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "mug", "t"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bought(u1) = %v, want %v", got, want)
+	}
+
+	// Orders stop counting once maxOrders newer ones are recorded.
+	h.Record("u2", []string{"lamp", "towel"})
+	for i := 0; i < maxOrders; i++ {
+		h.Record("", []string{"mug", "jar"})
+	}
+	if n := h.orders["lamp"]; n != 0 {
+		t.Errorf("orders of lamp = %d, want 0", n)
+	}
+	if _, ok := h.together["lamp"]; ok {
+		t.Errorf("lamp is still counted with %v", h.together["lamp"])
+	}
+	if n := h.together["mug"]["jar"]; n != maxOrders {
+		t.Errorf("orders of mug with jar = %d, want %d", n, maxOrders)
+	}
+	if len(h.recent) != maxOrders {
+		t.Errorf("%d orders counted, want %d", len(h.recent), maxOrders)
+	}
+
+	// The users who ordered first are forgotten once maxUsers ordered since.
+	for i := 0; i < maxUsers; i++ {
+		h.Record(fmt.Sprint("user", i), []string{"mug"})
+	}
+	if len(h.users) != maxUsers || h.bought("u1") != nil || h.bought("user0") == nil {
+		t.Errorf("%d users remembered, u1: %v", len(h.users), h.bought("u1"))
+	}
+}
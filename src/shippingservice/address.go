@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// countryRule describes how addresses are written in a country.
+type countryRule struct {
+	name    string
+	aliases []string
+	// postal matches a postal code after it has been upper-cased and had
+	// its spaces and dashes removed.
+	postal *regexp.Regexp
+	// format turns a matching compact postal code into its usual written
+	// form, such as "K1A 0B1".
+	format func(string) string
+	// zipDigits is the length of an all-digit postal code, used to restore
+	// the leading zeros lost in the numeric zip_code field.
+	zipDigits int
+	// states lists the valid state or province codes. Nil means the state is
+	// optional and not checked.
+	states map[string]bool
+}
+
+func set(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+func identity(s string) string { return s }
+
+// splitAt inserts sep before the last n characters.
+func splitAt(n int, sep string) func(string) string {
+	return func(s string) string { return s[:len(s)-n] + sep + s[len(s)-n:] }
+}
+
+var countryRules = []countryRule{
+	{
+		name:    "United States",
+		aliases: []string{"US", "USA", "United States of America"},
+		postal:  regexp.MustCompile(`^\d{5}(\d{4})?$`),
+		format: func(s string) string {
+			if len(s) == 9 {
+				return s[:5] + "-" + s[5:]
+			}
+			return s
+		},
+		zipDigits: 5,
+		states: set("AL", "AK", "AZ", "AR", "CA", "CO", "CT", "DE", "DC", "FL", "GA", "HI", "ID", "IL", "IN",
+			"IA", "KS", "KY", "LA", "ME", "MD", "MA", "MI", "MN", "MS", "MO", "MT", "NE", "NV", "NH", "NJ",
+			"NM", "NY", "NC", "ND", "OH", "OK", "OR", "PA", "RI", "SC", "SD", "TN", "TX", "UT", "VT", "VA",
+			"WA", "WV", "WI", "WY", "AS", "GU", "MP", "PR", "VI", "AA", "AE", "AP"),
+	},
+	{
+		name:    "Canada",
+		aliases: []string{"CA"},
+		postal:  regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY]\d[ABCEGHJ-NPRSTV-Z]\d[ABCEGHJ-NPRSTV-Z]\d$`),
+		format:  splitAt(3, " "),
+		states:  set("AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT"),
+	},
+	{
+		name:    "United Kingdom",
+		aliases: []string{"UK", "GB", "Great Britain", "England", "Scotland", "Wales", "Northern Ireland"},
+		postal:  regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]?\d[A-Z]{2}$`),
+		format:  splitAt(3, " "),
+	},
+	{
+		name:      "Germany",
+		aliases:   []string{"DE", "Deutschland"},
+		postal:    regexp.MustCompile(`^\d{5}$`),
+		format:    identity,
+		zipDigits: 5,
+	},
+	{
+		name:      "France",
+		aliases:   []string{"FR"},
+		postal:    regexp.MustCompile(`^\d{5}$`),
+		format:    identity,
+		zipDigits: 5,
+	},
+	{
+		name:    "Netherlands",
+		aliases: []string{"NL", "The Netherlands", "Holland"},
+		postal:  regexp.MustCompile(`^[1-9]\d{3}[A-Z]{2}$`),
+		format:  splitAt(2, " "),
+	},
+	{
+		name:      "Japan",
+		aliases:   []string{"JP"},
+		postal:    regexp.MustCompile(`^\d{7}$`),
+		format:    splitAt(4, "-"),
+		zipDigits: 7,
+	},
+	{
+		name:      "Australia",
+		aliases:   []string{"AU"},
+		postal:    regexp.MustCompile(`^\d{4}$`),
+		format:    identity,
+		zipDigits: 4,
+	},
+}
+
+// countryByName indexes countryRules by lower-cased name and alias.
+var countryByName = func() map[string]*countryRule {
+	m := make(map[string]*countryRule)
+	for i := range countryRules {
+		r := &countryRules[i]
+		m[strings.ToLower(r.name)] = r
+		for _, a := range r.aliases {
+			m[strings.ToLower(a)] = r
+		}
+	}
+	return m
+}()
+
+// canonicalCountry returns the usual name of a country written as a name or
+// code, such as "United Kingdom" for "UK".
+func canonicalCountry(country string) string {
+	country = clean(country)
+	if rule, ok := countryByName[strings.ToLower(country)]; ok {
+		return rule.name
+	}
+	return country
+}
+
+// maxPostalCodeLength bounds free-form postal codes of countries without a
+// rule.
+const maxPostalCodeLength = 10
+
+var spaces = regexp.MustCompile(`\s+`)
+
+// clean trims s and collapses runs of white space.
+func clean(s string) string {
+	return spaces.ReplaceAllString(strings.TrimSpace(s), " ")
+}
+
+// ValidateAddress normalizes an address and checks it against the rules of
+// its country. The normalized address is returned even when it is invalid.
+func ValidateAddress(a *pb.Address) (*pb.Address, []*pb.AddressViolation) {
+	if a == nil {
+		return nil, []*pb.AddressViolation{{Field: "address", Description: "address is required"}}
+	}
+	var violations []*pb.AddressViolation
+	violate := func(field, format string, args ...interface{}) {
+		violations = append(violations, &pb.AddressViolation{Field: field, Description: fmt.Sprintf(format, args...)})
+	}
+
+	out := &pb.Address{
+		StreetAddress: clean(a.GetStreetAddress()),
+		City:          clean(a.GetCity()),
+		State:         clean(a.GetState()),
+		Country:       clean(a.GetCountry()),
+	}
+	if out.StreetAddress == "" {
+		violate("street_address", "street address is required")
+	}
+	if out.City == "" {
+		violate("city", "city is required")
+	}
+	if out.Country == "" {
+		violate("country", "country is required")
+	}
+
+	rule := countryByName[strings.ToLower(out.Country)]
+	if rule != nil {
+		out.Country = rule.name
+	}
+
+	postal := strings.ToUpper(clean(a.GetPostalCode()))
+	if postal == "" && a.GetZipCode() > 0 {
+		postal = strconv.Itoa(int(a.GetZipCode()))
+		if rule != nil && rule.zipDigits > len(postal) {
+			postal = strings.Repeat("0", rule.zipDigits-len(postal)) + postal
+		}
+	}
+
+	if rule == nil {
+		if len(postal) > maxPostalCodeLength {
+			violate("postal_code", "postal code is longer than %d characters", maxPostalCodeLength)
+		}
+		out.PostalCode = postal
+		return out, violations
+	}
+
+	compact := strings.NewReplacer(" ", "", "-", "").Replace(postal)
+	switch {
+	case compact == "":
+		violate("postal_code", "postal code is required in %s", rule.name)
+	case !rule.postal.MatchString(compact):
+		violate("postal_code", "%q is not a valid postal code in %s", postal, rule.name)
+	default:
+		postal = rule.format(compact)
+	}
+	out.PostalCode = postal
+	if rule.zipDigits > 0 && len(compact) == rule.zipDigits {
+		// Keep the legacy numeric field in step for old clients.
+		zip, _ := strconv.Atoi(compact)
+		out.ZipCode = int32(zip)
+	}
+
+	if rule.states != nil {
+		out.State = strings.ToUpper(out.State)
+		switch {
+		case out.State == "":
+			violate("state", "state or province is required in %s", rule.name)
+		case !rule.states[out.State]:
+			violate("state", "%q is not a state or province code in %s", out.State, rule.name)
+		}
+	}
+	return out, violations
+}
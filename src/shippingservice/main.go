@@ -22,13 +22,18 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -36,11 +41,21 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/norun9/microservices-demo-ambient/chaos"
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 )
 
 const (
-	defaultPort = "50051"
+	defaultPort      = "50051"
+	defaultRatesFile = "data/shipping_rates.json"
+
+	// defaultSimulatedDay is how long a shipping day lasts in the simulated
+	// shipment lifecycle.
+	defaultSimulatedDay = time.Minute
+	// shipmentRetention is how long delivered shipments stay trackable.
+	shipmentRetention = 24 * time.Hour
+	// maxTrackingIDAttempts bounds the retries after a tracking ID collision.
+	maxTrackingIDAttempts = 5
 )
 
 var log *logrus.Logger
@@ -120,12 +135,53 @@ func main() {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
-	var srv *grpc.Server = grpc.NewServer(
+	// Inject the faults of CHAOS_RULES_FILE, if set.
+	chaosInjector, err := chaos.FromEnv(log.Infof)
+	if err != nil {
+		log.Fatalf("failed to load chaos rules: %v", err)
+	}
+	defer chaosInjector.Close()
+
+	var srv *grpc.Server = grpc.NewServer(append([]grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	}, chaosInjector.ServerOptions()...)...)
+
+	var catalogAddr string
+	mustMapEnv(&catalogAddr, "PRODUCT_CATALOG_SERVICE_ADDR")
+	catalogConn, err := grpc.NewClient(catalogAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	)
+	if err != nil {
+		log.Fatalf("failed to connect product catalog service at %q: %v", catalogAddr, err)
+	}
+	defer catalogConn.Close()
 
+	ratesFile := defaultRatesFile
+	if value, ok := os.LookupEnv("SHIPPING_RATES_FILE"); ok {
+		ratesFile = value
+	}
+	rates, err := LoadRateTable(ratesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Infof("loaded shipping rates from %q", ratesFile)
+
+	day := defaultSimulatedDay
+	if value, ok := os.LookupEnv("SHIPPING_SIMULATED_DAY"); ok {
+		if day, err = time.ParseDuration(value); err != nil || day <= 0 {
+			log.Fatalf("invalid SHIPPING_SIMULATED_DAY %q", value)
+		}
+	}
+
+	clock := systemClock{}
 	svc := &server{
-		tracer: otel.Tracer("shippingservice"),
+		tracer:      otel.Tracer("shippingservice"),
+		catalog:     pb.NewProductCatalogServiceClient(catalogConn),
+		rates:       rates,
+		lifecycle:   Lifecycle{Clock: clock, DayLength: day},
+		shipments:   NewShipmentStore(clock, shipmentRetention),
+		trackingIDs: NewTrackingIDGenerator(),
 	}
 	pb.RegisterShippingServiceServer(srv, svc)
 	healthSvc := health.NewServer()
@@ -140,53 +196,234 @@ func main() {
 	}
 }
 
+func mustMapEnv(target *string, envKey string) {
+	v := os.Getenv(envKey)
+	if v == "" {
+		panic(fmt.Sprintf("environment variable %q not set", envKey))
+	}
+	*target = v
+}
+
 // server controls RPC service responses.
 type server struct {
 	pb.UnimplementedShippingServiceServer
-	tracer trace.Tracer
+	tracer      trace.Tracer
+	catalog     pb.ProductCatalogServiceClient
+	rates       *RateTable
+	lifecycle   Lifecycle
+	shipments   *ShipmentStore
+	trackingIDs TrackingIDGenerator
 }
 
-// GetQuote produces a shipping quote (cost) in USD.
+// GetQuote produces a shipping quote (cost) in USD for every shipping method
+// available at the destination.
 func (s *server) GetQuote(ctx context.Context, in *pb.GetQuoteRequest) (*pb.GetQuoteResponse, error) {
-	_, span := s.tracer.Start(ctx, "GetQuote")
+	ctx, span := s.tracer.Start(ctx, "GetQuote")
 	defer span.End()
 
 	log.Info("[GetQuote] received request")
 	defer log.Info("[GetQuote] completed request")
 
-	// 1. Our quote system requires the total number of items to be shipped.
-	count := 0
-	for _, item := range in.Items {
-		count += int(item.Quantity)
+	// 1. Rates depend on the destination zone and on the billable weight of
+	// each package the order will be split into.
+	zone := s.rates.Zone(canonicalCountry(in.GetAddress().GetCountry()))
+	packages, err := s.pack(ctx, in.Items)
+	if err != nil {
+		return nil, err
 	}
+	span.SetAttributes(
+		attribute.String("shipping.zone", zone),
+		attribute.Int("shipping.packages", len(packages)),
+	)
 
-	// 2. Generate a quote based on the total number of items to be shipped.
-	quote := CreateQuoteFromCount(count)
+	// 2. Price every method offered in the zone.
+	options := s.rates.Options(zone, packages)
+	if len(options) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "no shipping method available to %q", in.GetAddress().GetCountry())
+	}
 
-	// 3. Generate a response.
+	// 3. Generate a response. The top-level cost is the cheapest option.
 	return &pb.GetQuoteResponse{
-		CostUsd: &pb.Money{
-			CurrencyCode: "USD",
-			Units:        int64(quote.Dollars),
-			Nanos:        int32(quote.Cents * 10000000)},
+		CostUsd: options[0].CostUsd,
+		Options: options,
 	}, nil
+}
 
+// pack looks up the size of each product in the catalog and splits the items
+// into packages. Products that cannot be looked up count with the rate
+// table's default weight and no volume. Orders needing too many packages are
+// rejected with InvalidArgument.
+func (s *server) pack(ctx context.Context, items []*pb.CartItem) ([]*Package, error) {
+	products := make(map[string]*pb.Product)
+	parcelItems := make([]parcelItem, len(items))
+	for i, item := range items {
+		p, ok := products[item.GetProductId()]
+		if !ok {
+			var err error
+			if p, err = s.catalog.GetProduct(ctx, &pb.GetProductRequest{Id: item.GetProductId()}); err != nil {
+				log.Warnf("using default weight for product %q: %v", item.GetProductId(), err)
+				p = &pb.Product{Id: item.GetProductId()}
+			}
+			products[item.GetProductId()] = p
+		}
+		parcelItems[i] = parcelItem{
+			ProductID:  item.GetProductId(),
+			VariantSKU: item.GetVariantSku(),
+			Quantity:   item.GetQuantity(),
+			UnitGrams:  s.rates.BillableGrams(p),
+			UnitVolume: volume(p),
+		}
+	}
+	packages, err := Pack(parcelItems, s.rates.Package)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return packages, nil
 }
 
 // ShipOrder mocks that the requested items will be shipped.
-// It supplies a tracking ID for notional lookup of shipment delivery status.
+// It splits the items into packages and supplies a tracking ID for each
+// that TrackShipment can look up.
 func (s *server) ShipOrder(ctx context.Context, in *pb.ShipOrderRequest) (*pb.ShipOrderResponse, error) {
-	_, span := s.tracer.Start(ctx, "ShipOrder")
+	ctx, span := s.tracer.Start(ctx, "ShipOrder")
 	defer span.End()
 
 	log.Info("[ShipOrder] received request")
 	defer log.Info("[ShipOrder] completed request")
-	// 1. Create a Tracking ID
-	baseAddress := fmt.Sprintf("%s, %s, %s", in.Address.StreetAddress, in.Address.City, in.Address.State)
-	id := CreateTrackingId(baseAddress)
 
-	// 2. Generate a response.
-	return &pb.ShipOrderResponse{
-		TrackingId: id,
+	// 1. Only ship to valid addresses.
+	address, violations := ValidateAddress(in.GetAddress())
+	if len(violations) > 0 {
+		return nil, invalidAddressError(violations)
+	}
+
+	// 2. Split the order into packages.
+	packages, err := s.pack(ctx, in.Items)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "order has no items to ship")
+	}
+
+	// 3. Find the chosen method, or the cheapest, for the destination.
+	zone := s.rates.Zone(address.Country)
+	options := s.rates.Options(zone, packages)
+	if len(options) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "no shipping method available to %q", address.Country)
+	}
+	option := options[0]
+	if method := in.GetShippingMethod(); method != "" {
+		option = nil
+		for _, o := range options {
+			if o.Method == method {
+				option = o
+			}
+		}
+		if option == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "shipping method %q is not available to %q", method, address.Country)
+		}
+	}
+	span.SetAttributes(
+		attribute.String("shipping.method", option.Method),
+		attribute.Int("shipping.packages", len(packages)),
+	)
+
+	// 4. Hand each package to the (simulated) carrier under its own
+	// tracking ID.
+	res := &pb.ShipOrderResponse{}
+	for _, p := range packages {
+		id, err := s.ship(p, option, address)
+		if err != nil {
+			return nil, err
+		}
+		res.Shipments = append(res.Shipments, &pb.Shipment{
+			TrackingId:  id,
+			Items:       p.Items,
+			WeightGrams: int32(p.Grams),
+		})
+	}
+
+	// 5. Generate a response.
+	res.TrackingId = res.Shipments[0].TrackingId
+	return res, nil
+}
+
+// ship stores a package as a new shipment and returns its tracking ID,
+// retrying on the rare collision with an existing one.
+func (s *server) ship(p *Package, option *pb.ShippingOption, address *pb.Address) (string, error) {
+	for attempt := 0; attempt < maxTrackingIDAttempts; attempt++ {
+		id := s.trackingIDs.NewTrackingID()
+		if s.shipments.Add(s.lifecycle.Schedule(id, option.Method, address, p.Items, option.MaxDays)) {
+			return id, nil
+		}
+		log.Warnf("[ShipOrder] tracking ID %q already in use, retrying", id)
+	}
+	return "", status.Error(codes.Internal, "failed to allocate a unique tracking ID")
+}
+
+// ValidateAddress checks an address against the rules of its country and
+// returns it in normalized form. Invalid addresses are reported in the
+// response rather than as an error.
+func (s *server) ValidateAddress(ctx context.Context, in *pb.ValidateAddressRequest) (*pb.ValidateAddressResponse, error) {
+	_, span := s.tracer.Start(ctx, "ValidateAddress")
+	defer span.End()
+
+	log.Info("[ValidateAddress] received request")
+	defer log.Info("[ValidateAddress] completed request")
+
+	address, violations := ValidateAddress(in.GetAddress())
+	span.SetAttributes(attribute.Int("shipping.address_violations", len(violations)))
+	return &pb.ValidateAddressResponse{
+		Valid:      len(violations) == 0,
+		Address:    address,
+		Violations: violations,
+	}, nil
+}
+
+// invalidAddressError returns an InvalidArgument error carrying the
+// violations as BadRequest field violations.
+func invalidAddressError(violations []*pb.AddressViolation) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       "address." + v.Field,
+			Description: v.Description,
+		})
+	}
+	st, err := status.New(codes.InvalidArgument, "invalid shipping address: "+violations[0].Description).WithDetails(br)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid shipping address")
+	}
+	return st.Err()
+}
+
+// TrackShipment returns the current status and history of a shipment.
+func (s *server) TrackShipment(ctx context.Context, in *pb.TrackShipmentRequest) (*pb.TrackShipmentResponse, error) {
+	_, span := s.tracer.Start(ctx, "TrackShipment")
+	defer span.End()
+
+	log.Info("[TrackShipment] received request")
+	defer log.Info("[TrackShipment] completed request")
+
+	if !ValidTrackingID(in.GetTrackingId()) {
+		return nil, status.Errorf(codes.InvalidArgument, "%q is not a valid tracking ID", in.GetTrackingId())
+	}
+	sh, ok := s.shipments.Get(in.GetTrackingId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no shipment with tracking ID %q", in.GetTrackingId())
+	}
+
+	now := s.lifecycle.Clock.Now()
+	st := sh.Status(now)
+	span.SetAttributes(attribute.String("shipping.status", st.String()))
+	return &pb.TrackShipmentResponse{
+		TrackingId:            sh.TrackingID,
+		Status:                st,
+		ShippingMethod:        sh.Method,
+		Address:               sh.Address,
+		Events:                sh.Events(now),
+		EstimatedDeliveryUnix: sh.Delivered.Unix(),
+		Items:                 sh.Items,
 	}, nil
 }
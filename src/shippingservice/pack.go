@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// maxPackages is the number of packages an order is split into at most, so
+// that a huge quantity cannot create as many shipments.
+const maxPackages = 100
+
+var errTooManyPackages = fmt.Errorf("order needs more than %d packages", maxPackages)
+
+// PackageLimits bounds what fits in one package. Zero means no limit.
+type PackageLimits struct {
+	MaxGrams     int64 `json:"max_grams"`
+	MaxVolumeMm3 int64 `json:"max_volume_mm3"`
+}
+
+// parcelItem is a cart item with the size of a single unit. Variants of a
+// product are separate items.
+type parcelItem struct {
+	ProductID  string
+	VariantSKU string
+	Quantity   int32
+	UnitGrams  int64 // billable weight
+	UnitVolume int64 // mm³, zero if unknown
+}
+
+// Package is a set of items shipped together.
+type Package struct {
+	Items  []*pb.CartItem
+	Grams  int64
+	Volume int64
+}
+
+// fits returns how many units of it can still go into p.
+func (p *Package) fits(it parcelItem, limits PackageLimits, want int32) int32 {
+	n := int64(want)
+	if limits.MaxGrams > 0 && it.UnitGrams > 0 {
+		n = min(n, (limits.MaxGrams-p.Grams)/it.UnitGrams)
+	}
+	if limits.MaxVolumeMm3 > 0 && it.UnitVolume > 0 {
+		n = min(n, (limits.MaxVolumeMm3-p.Volume)/it.UnitVolume)
+	}
+	return int32(max(n, 0))
+}
+
+func (p *Package) add(it parcelItem, n int32) {
+	p.Items = append(p.Items, &pb.CartItem{ProductId: it.ProductID, VariantSku: it.VariantSKU, Quantity: n})
+	p.Grams += int64(n) * it.UnitGrams
+	p.Volume += int64(n) * it.UnitVolume
+}
+
+// Pack splits items into packages within limits, heaviest units first, each
+// unit going into the first package it fits in (first-fit decreasing). A
+// unit that exceeds the limits on its own ships in a package by itself. An
+// empty cart packs into no packages. It fails with errTooManyPackages if more
+// than maxPackages are needed.
+func Pack(items []parcelItem, limits PackageLimits) ([]*Package, error) {
+	sorted := make([]parcelItem, 0, len(items))
+	for _, it := range items {
+		if it.Quantity > 0 {
+			sorted = append(sorted, it)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].UnitGrams > sorted[j].UnitGrams })
+
+	var packages []*Package
+	for _, it := range sorted {
+		left := it.Quantity
+		for _, p := range packages {
+			if left == 0 {
+				break
+			}
+			if n := p.fits(it, limits, left); n > 0 {
+				p.add(it, n)
+				left -= n
+			}
+		}
+		for left > 0 {
+			if len(packages) == maxPackages {
+				return nil, errTooManyPackages
+			}
+			p := &Package{}
+			n := p.fits(it, limits, left)
+			if n == 0 {
+				n = 1 // oversized
+			}
+			p.add(it, n)
+			left -= n
+			packages = append(packages, p)
+		}
+	}
+	return packages, nil
+}
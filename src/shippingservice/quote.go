@@ -17,6 +17,8 @@ package main
 import (
 	"fmt"
 	"math"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
 )
 
 // Quote represents a currency value.
@@ -27,27 +29,24 @@ type Quote struct {
 
 // String representation of the Quote.
 func (q Quote) String() string {
-	return fmt.Sprintf("$%d.%d", q.Dollars, q.Cents)
+	return fmt.Sprintf("$%d.%02d", q.Dollars, q.Cents)
 }
 
-// CreateQuoteFromCount takes a number of items and returns a Price struct.
-func CreateQuoteFromCount(count int) Quote {
-	return CreateQuoteFromFloat(quoteByCountFloat(count))
+// Money converts the Quote to a USD amount.
+func (q Quote) Money() *pb.Money {
+	return &pb.Money{
+		CurrencyCode: "USD",
+		Units:        int64(q.Dollars),
+		Nanos:        int32(q.Cents * 10000000),
+	}
 }
 
-// CreateQuoteFromFloat takes a price represented as a float and creates a Price struct.
+// CreateQuoteFromFloat takes a price represented as a float and creates a Price struct,
+// rounded to the nearest cent.
 func CreateQuoteFromFloat(value float64) Quote {
-	units, fraction := math.Modf(value)
+	cents := uint32(math.Round(value * 100))
 	return Quote{
-		uint32(units),
-		uint32(math.Trunc(fraction * 100)),
-	}
-}
-
-// quoteByCountFloat takes a number of items and generates a price quote represented as a float.
-func quoteByCountFloat(count int) float64 {
-	if count == 0 {
-		return 0
+		cents / 100,
+		cents % 100,
 	}
-	return 8.99
 }
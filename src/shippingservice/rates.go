@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// RateTable prices shipments by destination zone, shipping method and
+// billable weight.
+type RateTable struct {
+	// DimensionalDivisor converts a package volume in mm³ to a dimensional
+	// weight in grams (volume / divisor). 5000 is the usual courier value.
+	DimensionalDivisor int64 `json:"dimensional_divisor"`
+	// DefaultWeightGrams is used for products without a known weight.
+	DefaultWeightGrams int64 `json:"default_weight_grams"`
+
+	Zones []Zone `json:"zones"`
+	// DefaultZone is used for countries not listed in any zone.
+	DefaultZone string        `json:"default_zone"`
+	Methods     []MethodRates `json:"methods"`
+	// Package limits how orders are split into packages; see Pack.
+	Package   PackageLimits `json:"package"`
+	byCountry map[string]string
+}
+
+// Zone groups destination countries that share the same rates.
+type Zone struct {
+	Name      string   `json:"name"`
+	Countries []string `json:"countries"`
+}
+
+// MethodRates holds the rates of one shipping method. A method that has no
+// entry for a zone is not offered there.
+type MethodRates struct {
+	Method      string              `json:"method"`
+	DisplayName string              `json:"display_name"`
+	Zones       map[string]ZoneRate `json:"zones"`
+}
+
+// ZoneRate is the price of a method within a zone. The first band whose
+// MaxGrams is not exceeded applies; above the last band every started
+// kilogram adds PerExtraKgUSD.
+type ZoneRate struct {
+	MinDays       int32        `json:"min_days"`
+	MaxDays       int32        `json:"max_days"`
+	Bands         []WeightBand `json:"bands"`
+	PerExtraKgUSD float64      `json:"per_extra_kg_usd"`
+}
+
+// WeightBand is a flat price for shipments up to MaxGrams.
+type WeightBand struct {
+	MaxGrams int64   `json:"max_grams"`
+	USD      float64 `json:"usd"`
+}
+
+// LoadRateTable reads and validates a rate table from a JSON file.
+func LoadRateTable(path string) (*RateTable, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shipping rates: %w", err)
+	}
+	var t RateTable
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse shipping rates: %w", err)
+	}
+	if err := t.init(); err != nil {
+		return nil, fmt.Errorf("invalid shipping rates in %q: %w", path, err)
+	}
+	return &t, nil
+}
+
+func (t *RateTable) init() error {
+	if t.DimensionalDivisor <= 0 {
+		return fmt.Errorf("dimensional_divisor must be positive")
+	}
+	if t.DefaultWeightGrams <= 0 {
+		return fmt.Errorf("default_weight_grams must be positive")
+	}
+	if t.Package.MaxGrams < 0 || t.Package.MaxVolumeMm3 < 0 {
+		return fmt.Errorf("package limits must not be negative")
+	}
+	zones := make(map[string]bool)
+	t.byCountry = make(map[string]string)
+	for _, z := range t.Zones {
+		zones[z.Name] = true
+		for _, c := range z.Countries {
+			t.byCountry[normalizeCountry(c)] = z.Name
+		}
+	}
+	if !zones[t.DefaultZone] {
+		return fmt.Errorf("default_zone %q is not a zone", t.DefaultZone)
+	}
+	if len(t.Methods) == 0 {
+		return fmt.Errorf("no shipping methods")
+	}
+	offeredByDefault := false
+	for _, m := range t.Methods {
+		_, ok := m.Zones[t.DefaultZone]
+		offeredByDefault = offeredByDefault || ok
+		for zone, r := range m.Zones {
+			if !zones[zone] {
+				return fmt.Errorf("method %q: unknown zone %q", m.Method, zone)
+			}
+			if len(r.Bands) == 0 {
+				return fmt.Errorf("method %q: zone %q has no weight bands", m.Method, zone)
+			}
+			for i := 1; i < len(r.Bands); i++ {
+				if r.Bands[i].MaxGrams <= r.Bands[i-1].MaxGrams {
+					return fmt.Errorf("method %q: zone %q bands are not in increasing weight order", m.Method, zone)
+				}
+			}
+		}
+	}
+	if !offeredByDefault {
+		return fmt.Errorf("no method is offered in default_zone %q", t.DefaultZone)
+	}
+	return nil
+}
+
+// Zone returns the zone for a destination country.
+func (t *RateTable) Zone(country string) string {
+	if z, ok := t.byCountry[normalizeCountry(country)]; ok {
+		return z
+	}
+	return t.DefaultZone
+}
+
+// BillableGrams returns the weight a single unit of p is charged at: the
+// larger of its actual and dimensional weight.
+func (t *RateTable) BillableGrams(p *pb.Product) int64 {
+	actual := int64(p.GetWeightGrams())
+	if dim := volume(p) / t.DimensionalDivisor; dim > actual {
+		return dim
+	}
+	if actual <= 0 {
+		return t.DefaultWeightGrams
+	}
+	return actual
+}
+
+// volume returns the packaged volume of a single unit of p in mm³, or zero
+// if its dimensions are unknown.
+func volume(p *pb.Product) int64 {
+	d := p.GetDimensions()
+	return int64(d.GetLengthMm()) * int64(d.GetWidthMm()) * int64(d.GetHeightMm())
+}
+
+// Options returns every method offered in zone for shipping the packages,
+// cheapest first. Each package is priced on its own billable weight. No
+// packages ship for free.
+func (t *RateTable) Options(zone string, packages []*Package) []*pb.ShippingOption {
+	var out []*pb.ShippingOption
+	for _, m := range t.Methods {
+		r, ok := m.Zones[zone]
+		if !ok {
+			continue
+		}
+		var usd float64
+		for _, p := range packages {
+			usd += r.price(p.Grams)
+		}
+		out = append(out, &pb.ShippingOption{
+			Method:      m.Method,
+			DisplayName: m.DisplayName,
+			CostUsd:     CreateQuoteFromFloat(usd).Money(),
+			MinDays:     r.MinDays,
+			MaxDays:     r.MaxDays,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return quoteCents(out[i].CostUsd) < quoteCents(out[j].CostUsd)
+	})
+	return out
+}
+
+func (r ZoneRate) price(grams int64) float64 {
+	for _, b := range r.Bands {
+		if grams <= b.MaxGrams {
+			return b.USD
+		}
+	}
+	last := r.Bands[len(r.Bands)-1]
+	extraKg := (grams - last.MaxGrams + 999) / 1000
+	return last.USD + float64(extraKg)*r.PerExtraKgUSD
+}
+
+func quoteCents(m *pb.Money) int64 {
+	return m.GetUnits()*100 + int64(m.GetNanos()/10000000)
+}
+
+func normalizeCountry(c string) string {
+	return strings.ToLower(strings.TrimSpace(c))
+}
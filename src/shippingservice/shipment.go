@@ -0,0 +1,165 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/norun9/microservices-demo-ambient/genproto"
+)
+
+// Clock tells the current time. Tests replace it to move shipments through
+// their lifecycle.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Shipment is a parcel handed to the carrier and the times at which it
+// reaches each status.
+type Shipment struct {
+	TrackingID string
+	Method     string
+	Address    *pb.Address
+	Items      []*pb.CartItem
+	CreatedAt  time.Time
+	InTransit  time.Time
+	Delivered  time.Time
+}
+
+// Status returns the status of the shipment at t.
+func (s *Shipment) Status(t time.Time) pb.ShipmentStatus {
+	switch {
+	case !t.Before(s.Delivered):
+		return pb.ShipmentStatus_DELIVERED
+	case !t.Before(s.InTransit):
+		return pb.ShipmentStatus_IN_TRANSIT
+	default:
+		return pb.ShipmentStatus_LABEL_CREATED
+	}
+}
+
+// Events returns the status changes reached by t, oldest first.
+func (s *Shipment) Events(t time.Time) []*pb.ShipmentEvent {
+	events := []*pb.ShipmentEvent{{
+		Status:      pb.ShipmentStatus_LABEL_CREATED,
+		TimeUnix:    s.CreatedAt.Unix(),
+		Description: "Shipping label created",
+	}}
+	if !t.Before(s.InTransit) {
+		events = append(events, &pb.ShipmentEvent{
+			Status:      pb.ShipmentStatus_IN_TRANSIT,
+			TimeUnix:    s.InTransit.Unix(),
+			Description: "Picked up by carrier",
+		})
+	}
+	if !t.Before(s.Delivered) {
+		events = append(events, &pb.ShipmentEvent{
+			Status:      pb.ShipmentStatus_DELIVERED,
+			TimeUnix:    s.Delivered.Unix(),
+			Description: "Delivered",
+		})
+	}
+	return events
+}
+
+// Lifecycle schedules the status changes of new shipments. Shipping days are
+// simulated and last DayLength each, so that a demo order is delivered in
+// minutes rather than days.
+type Lifecycle struct {
+	Clock     Clock
+	DayLength time.Duration
+}
+
+// Schedule returns a shipment created now that is picked up half a day later
+// and delivered at the end of the method's delivery window.
+func (l Lifecycle) Schedule(trackingID, method string, address *pb.Address, items []*pb.CartItem, maxDays int32) *Shipment {
+	now := l.Clock.Now()
+	if maxDays < 1 {
+		maxDays = 1
+	}
+	return &Shipment{
+		TrackingID: trackingID,
+		Method:     method,
+		Address:    address,
+		Items:      items,
+		CreatedAt:  now,
+		InTransit:  now.Add(l.DayLength / 2),
+		Delivered:  now.Add(time.Duration(maxDays) * l.DayLength),
+	}
+}
+
+// ShipmentStore keeps shipments by tracking ID. Delivered shipments are
+// dropped once they are older than the retention period. It is safe for
+// concurrent use.
+type ShipmentStore struct {
+	clock     Clock
+	retention time.Duration
+
+	mu        sync.RWMutex
+	shipments map[string]*Shipment
+	// kept is the number of shipments left by the last sweep.
+	kept int
+}
+
+// minSweepSize is the smallest store that is swept, so that small stores are
+// not swept on every Add.
+const minSweepSize = 64
+
+// NewShipmentStore returns an empty store that keeps delivered shipments for
+// retention.
+func NewShipmentStore(clock Clock, retention time.Duration) *ShipmentStore {
+	return &ShipmentStore{
+		clock:     clock,
+		retention: retention,
+		shipments: make(map[string]*Shipment),
+	}
+}
+
+// Add adds a shipment to the store. It returns false, and leaves the store
+// unchanged, if a shipment with the same tracking ID already exists.
+func (s *ShipmentStore) Add(sh *Shipment) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, taken := s.shipments[sh.TrackingID]; taken {
+		return false
+	}
+	s.shipments[sh.TrackingID] = sh
+	// Sweeping once the store has doubled since the last sweep keeps it
+	// within twice the shipments in retention, for a constant amortized
+	// cost per Add.
+	if len(s.shipments) >= 2*max(s.kept, minSweepSize) {
+		s.sweep()
+	}
+	return true
+}
+
+// sweep drops the shipments past retention.
+func (s *ShipmentStore) sweep() {
+	now := s.clock.Now()
+	for id, sh := range s.shipments {
+		if s.expired(sh, now) {
+			delete(s.shipments, id)
+		}
+	}
+	s.kept = len(s.shipments)
+}
+
+// Get returns the shipment with the tracking ID, if any. Shipments past
+// retention are not returned, even if they have not been dropped yet.
+func (s *ShipmentStore) Get(trackingID string) (*Shipment, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sh, ok := s.shipments[trackingID]
+	if !ok || s.expired(sh, s.clock.Now()) {
+		return nil, false
+	}
+	return sh, true
+}
+
+// expired reports whether sh was delivered longer than retention before now.
+func (s *ShipmentStore) expired(sh *Shipment, now time.Time) bool {
+	return sh.Delivered.Before(now.Add(-s.retention))
+}
@@ -15,49 +15,122 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"go.opentelemetry.io/otel"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	pb "github.com/norun9/microservices-demo-ambient/genproto"
 )
 
-// TestGetQuote is a basic check on the GetQuote RPC service.
-func TestGetQuote(t *testing.T) {
-	s := server{}
+// fakeCatalog serves GetProduct from a fixed set of products.
+type fakeCatalog struct {
+	pb.ProductCatalogServiceClient
+	products map[string]*pb.Product
+}
 
-	// A basic test case to test logic and protobuf interactions.
-	req := &pb.GetQuoteRequest{
-		Address: &pb.Address{
-			StreetAddress: "Muffin Man",
-			City:          "London",
-			State:         "",
-			Country:       "England",
-		},
-		Items: []*pb.CartItem{
-			{
-				ProductId: "23",
-				Quantity:  1,
-			},
-			{
-				ProductId: "46",
-				Quantity:  3,
-			},
-		},
+func (f fakeCatalog) GetProduct(_ context.Context, req *pb.GetProductRequest, _ ...grpc.CallOption) (*pb.Product, error) {
+	p, ok := f.products[req.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no product with ID %s", req.Id)
 	}
+	return p, nil
+}
+
+// fakeClock is a Clock that only moves when told to.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
 
-	res, err := s.GetQuote(context.Background(), req)
+func newTestServer(t *testing.T) *server {
+	t.Helper()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	rates, err := LoadRateTable(defaultRatesFile)
 	if err != nil {
-		t.Errorf("TestGetQuote (%v) failed", err)
+		t.Fatal(err)
+	}
+	return &server{
+		tracer: otel.Tracer("shippingservice"),
+		catalog: fakeCatalog{products: map[string]*pb.Product{
+			"23":    {Id: "23", WeightGrams: 300},
+			"46":    {Id: "46", WeightGrams: 200},
+			"box":   {Id: "box", WeightGrams: 100, Dimensions: &pb.Dimensions{LengthMm: 300, WidthMm: 200, HeightMm: 100}},
+			"anvil": {Id: "anvil", WeightGrams: 7200},
+		}},
+		rates:       rates,
+		lifecycle:   Lifecycle{Clock: clock, DayLength: time.Hour},
+		shipments:   NewShipmentStore(clock, time.Hour),
+		trackingIDs: NewSeededTrackingIDGenerator(1),
+	}
+}
+
+func money(m *pb.Money) string {
+	return fmt.Sprintf("%d.%02d", m.GetUnits(), m.GetNanos()/10000000)
+}
+
+// TestGetQuote checks quotes across zones, weight bands and methods.
+func TestGetQuote(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name    string
+		country string
+		items   []*pb.CartItem
+		want    map[string]string
+	}{
+		{"europe", "England", []*pb.CartItem{{ProductId: "23", Quantity: 1}, {ProductId: "46", Quantity: 3}}, map[string]string{"standard": "19.99", "express": "44.99"}},
+		{"domestic", "United States", []*pb.CartItem{{ProductId: "23", Quantity: 1}}, map[string]string{"standard": "5.99", "express": "14.99"}},
+		{"dimensional weight", "united states", []*pb.CartItem{{ProductId: "box", Quantity: 1}}, map[string]string{"standard": "8.99", "express": "19.99"}},
+		{"above last band", "USA", []*pb.CartItem{{ProductId: "anvil", Quantity: 1}}, map[string]string{"standard": "17.49", "express": "40.49"}},
+		{"split into packages", "USA", []*pb.CartItem{{ProductId: "anvil", Quantity: 2}}, map[string]string{"standard": "34.98", "express": "80.98"}},
+		{"unknown product", "US", []*pb.CartItem{{ProductId: "missing", Quantity: 1}}, map[string]string{"standard": "5.99", "express": "14.99"}},
+		{"default zone", "", []*pb.CartItem{{ProductId: "23", Quantity: 1}}, map[string]string{"standard": "14.99"}},
+		{"empty cart", "Canada", nil, map[string]string{"standard": "0.00", "express": "0.00"}},
 	}
-	if res.CostUsd.GetUnits() != 8 || res.CostUsd.GetNanos() != 990000000 {
-		t.Errorf("TestGetQuote: Quote value '%d.%d' does not match expected '%s'", res.CostUsd.GetUnits(), res.CostUsd.GetNanos(), "11.220000000")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &pb.GetQuoteRequest{Items: tt.items}
+			if tt.country != "" {
+				req.Address = &pb.Address{Country: tt.country}
+			}
+			res, err := s.GetQuote(context.Background(), req)
+			if err != nil {
+				t.Fatalf("GetQuote() failed: %v", err)
+			}
+			got := make(map[string]string)
+			for _, o := range res.Options {
+				got[o.Method] = money(o.CostUsd)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetQuote() options = %v, want %v", got, tt.want)
+			}
+			if money(res.CostUsd) != money(res.Options[0].CostUsd) || res.CostUsd.CurrencyCode != "USD" {
+				t.Errorf("GetQuote() cost = %v, want the cheapest option %v", res.CostUsd, res.Options[0].CostUsd)
+			}
+		})
+	}
+}
+
+// TestCreateQuoteFromFloat checks that quotes are rounded to the cent.
+func TestCreateQuoteFromFloat(t *testing.T) {
+	for in, want := range map[float64]string{8.99: "$8.99", 17.49: "$17.49", 0.07: "$0.07", 3: "$3.00"} {
+		if got := CreateQuoteFromFloat(in).String(); got != want {
+			t.Errorf("CreateQuoteFromFloat(%v) = %s, want %s", in, got, want)
+		}
 	}
 }
 
 // TestShipOrder is a basic check on the ShipOrder RPC service.
 func TestShipOrder(t *testing.T) {
-	s := server{}
+	s := newTestServer(t)
 
 	// A basic test case to test logic and protobuf interactions.
 	req := &pb.ShipOrderRequest{
@@ -66,6 +139,7 @@ func TestShipOrder(t *testing.T) {
 			City:          "London",
 			State:         "",
 			Country:       "England",
+			PostalCode:    "sw1a1aa",
 		},
 		Items: []*pb.CartItem{
 			{
@@ -73,18 +147,378 @@ func TestShipOrder(t *testing.T) {
 				Quantity:  1,
 			},
 			{
-				ProductId: "46",
-				Quantity:  3,
+				ProductId:  "46",
+				VariantSku: "46-blue",
+				Quantity:   3,
 			},
 		},
+		ShippingMethod: "express",
 	}
 
 	res, err := s.ShipOrder(context.Background(), req)
 	if err != nil {
 		t.Errorf("TestShipOrder (%v) failed", err)
 	}
-	// @todo improve quality of this test to check for a pattern such as '[A-Z]{2}-\d+-\d+'.
-	if len(res.TrackingId) != 18 {
-		t.Errorf("TestShipOrder: Tracking ID is malformed - has %d characters, %d expected", len(res.TrackingId), 18)
+	if !ValidTrackingID(res.TrackingId) {
+		t.Errorf("TestShipOrder: Tracking ID %q is malformed", res.TrackingId)
+	}
+	if len(res.Shipments) != 1 || res.Shipments[0].TrackingId != res.TrackingId || len(res.Shipments[0].Items) != 2 {
+		t.Errorf("ShipOrder() shipments = %v, want one package with both items", res.Shipments)
+	}
+	if len(res.Shipments) == 1 && res.Shipments[0].Items[0].VariantSku != "46-blue" && res.Shipments[0].Items[1].VariantSku != "46-blue" {
+		t.Errorf("ShipOrder() items = %v, want the variant SKU of 46", res.Shipments[0].Items)
+	}
+
+	// Two anvils exceed the weight limit of a package.
+	heavy := &pb.ShipOrderRequest{Address: req.Address, Items: []*pb.CartItem{{ProductId: "anvil", Quantity: 2}, {ProductId: "23", Quantity: 1}}}
+	res, err = s.ShipOrder(context.Background(), heavy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Shipments) != 2 || res.Shipments[0].TrackingId == res.Shipments[1].TrackingId {
+		t.Fatalf("ShipOrder() shipments = %v, want two packages with their own tracking IDs", res.Shipments)
+	}
+	for _, sh := range res.Shipments {
+		tracked, err := s.TrackShipment(context.Background(), &pb.TrackShipmentRequest{TrackingId: sh.TrackingId})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tracked.Items) != len(sh.Items) {
+			t.Errorf("TrackShipment(%s) items = %v, want %v", sh.TrackingId, tracked.Items, sh.Items)
+		}
+	}
+
+	// Each oversized anvil would need its own package.
+	huge := &pb.ShipOrderRequest{Address: req.Address, Items: []*pb.CartItem{{ProductId: "anvil", Quantity: 100000}}}
+	if _, err := s.ShipOrder(context.Background(), huge); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ShipOrder() of 100000 anvils = %v, want InvalidArgument", err)
+	}
+
+	// Express is not offered outside the listed zones.
+	req.Address.Country = "Japan"
+	req.Address.PostalCode = "100-0001"
+	if _, err := s.ShipOrder(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ShipOrder() with unavailable method = %v, want InvalidArgument", err)
+	}
+
+	req.Address = nil
+	if _, err := s.ShipOrder(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ShipOrder() without address = %v, want InvalidArgument", err)
+	}
+}
+
+// TestValidateAddress checks normalization and the per-country rules.
+func TestValidateAddress(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name   string
+		in     *pb.Address
+		want   *pb.Address
+		fields []string
+	}{
+		{
+			"us zip code restores leading zero",
+			&pb.Address{StreetAddress: " 1 Main  St ", City: "Boston", State: "ma", Country: "usa", ZipCode: 2134},
+			&pb.Address{StreetAddress: "1 Main St", City: "Boston", State: "MA", Country: "United States", ZipCode: 2134, PostalCode: "02134"},
+			nil,
+		},
+		{
+			"us zip+4",
+			&pb.Address{StreetAddress: "1600 Amphitheatre Parkway", City: "Mountain View", State: "CA", Country: "United States", PostalCode: "940431351"},
+			&pb.Address{StreetAddress: "1600 Amphitheatre Parkway", City: "Mountain View", State: "CA", Country: "United States", PostalCode: "94043-1351"},
+			nil,
+		},
+		{
+			"canadian postal code",
+			&pb.Address{StreetAddress: "111 Wellington St", City: "Ottawa", State: "on", Country: "CA", PostalCode: "k1a0a9"},
+			&pb.Address{StreetAddress: "111 Wellington St", City: "Ottawa", State: "ON", Country: "Canada", PostalCode: "K1A 0A9"},
+			nil,
+		},
+		{
+			"uk postcode",
+			&pb.Address{StreetAddress: "10 Downing St", City: "London", Country: "England", PostalCode: "sw1a 2aa"},
+			&pb.Address{StreetAddress: "10 Downing St", City: "London", Country: "United Kingdom", PostalCode: "SW1A 2AA"},
+			nil,
+		},
+		{
+			"unknown country keeps postal code",
+			&pb.Address{StreetAddress: "Av. Paulista 1578", City: "Sao Paulo", Country: "Brazil", PostalCode: "01310-200"},
+			&pb.Address{StreetAddress: "Av. Paulista 1578", City: "Sao Paulo", Country: "Brazil", PostalCode: "01310-200"},
+			nil,
+		},
+		{
+			"bad postal code and state",
+			&pb.Address{StreetAddress: "1 Main St", City: "Springfield", State: "XX", Country: "US", PostalCode: "ABCDE"},
+			nil,
+			[]string{"postal_code", "state"},
+		},
+		{
+			"missing fields",
+			&pb.Address{Country: "Germany"},
+			nil,
+			[]string{"street_address", "city", "postal_code"},
+		},
+		{"nil address", nil, nil, []string{"address"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.ValidateAddress(context.Background(), &pb.ValidateAddressRequest{Address: tt.in})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, v := range res.Violations {
+				fields = append(fields, v.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.fields) || res.Valid != (len(tt.fields) == 0) {
+				t.Errorf("ValidateAddress() violations = %v (valid %v), want %v", res.Violations, res.Valid, tt.fields)
+			}
+			if tt.want != nil && !proto.Equal(res.Address, tt.want) {
+				t.Errorf("ValidateAddress() address = %v, want %v", res.Address, tt.want)
+			}
+		})
+	}
+}
+
+// TestTrackShipment follows a shipment through its lifecycle.
+func TestTrackShipment(t *testing.T) {
+	s := newTestServer(t)
+	clock := s.lifecycle.Clock.(*fakeClock)
+	ctx := context.Background()
+
+	shipped, err := s.ShipOrder(ctx, &pb.ShipOrderRequest{
+		Address:        &pb.Address{StreetAddress: "1600 Amphitheatre Parkway", City: "Mountain View", State: "CA", Country: "United States", PostalCode: "94043"},
+		Items:          []*pb.CartItem{{ProductId: "23", Quantity: 1}},
+		ShippingMethod: "express",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Domestic express takes at most 2 days; a simulated day lasts an hour.
+	for _, step := range []struct {
+		after  time.Duration
+		want   pb.ShipmentStatus
+		events int
+	}{
+		{0, pb.ShipmentStatus_LABEL_CREATED, 1},
+		{30 * time.Minute, pb.ShipmentStatus_IN_TRANSIT, 2},
+		{90 * time.Minute, pb.ShipmentStatus_DELIVERED, 3},
+	} {
+		clock.now = clock.now.Add(step.after)
+		res, err := s.TrackShipment(ctx, &pb.TrackShipmentRequest{TrackingId: shipped.TrackingId})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Status != step.want || len(res.Events) != step.events {
+			t.Errorf("TrackShipment() = %v with %d events, want %v with %d", res.Status, len(res.Events), step.want, step.events)
+		}
+		if res.ShippingMethod != "express" || res.Address.GetCity() != "Mountain View" {
+			t.Errorf("TrackShipment() = %v, want the shipped method and address", res)
+		}
+	}
+
+	// Delivered shipments are kept for an hour.
+	clock.now = clock.now.Add(time.Hour + time.Second)
+	if _, err := s.TrackShipment(ctx, &pb.TrackShipmentRequest{TrackingId: shipped.TrackingId}); status.Code(err) != codes.NotFound {
+		t.Errorf("TrackShipment() past retention = %v, want NotFound", err)
+	}
+
+	unknown := NewSeededTrackingIDGenerator(2).NewTrackingID()
+	if _, err := s.TrackShipment(ctx, &pb.TrackShipmentRequest{TrackingId: unknown}); status.Code(err) != codes.NotFound {
+		t.Errorf("TrackShipment(unknown) = %v, want NotFound", err)
+	}
+	for _, bad := range []string{"", "XX-0-0", shipped.TrackingId[:len(shipped.TrackingId)-1] + "x"} {
+		if _, err := s.TrackShipment(ctx, &pb.TrackShipmentRequest{TrackingId: bad}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("TrackShipment(%q) = %v, want InvalidArgument", bad, err)
+		}
+	}
+}
+
+// TestTrackingIDGenerator checks the ID format, the check digit and that
+// seeded generators are deterministic and safe for concurrent use.
+func TestTrackingIDGenerator(t *testing.T) {
+	a, b := NewSeededTrackingIDGenerator(42), NewSeededTrackingIDGenerator(42)
+	letters := make(map[byte]bool)
+	for i := 0; i < 2000; i++ {
+		id := a.NewTrackingID()
+		if other := b.NewTrackingID(); other != id {
+			t.Fatalf("seeded generators diverged: %q != %q", id, other)
+		}
+		if !ValidTrackingID(id) {
+			t.Fatalf("NewTrackingID() = %q, not a valid tracking ID", id)
+		}
+		letters[id[0]], letters[id[1]] = true, true
+	}
+	if len(letters) != 26 {
+		t.Errorf("generated IDs use %d distinct letters, want 26", len(letters))
+	}
+
+	// The check digit catches single-character typos and swapped digits.
+	id := NewSeededTrackingIDGenerator(7).NewTrackingID()
+	for i, c := range id[:len(id)-2] {
+		if c == '-' {
+			continue
+		}
+		typo := []byte(id)
+		if typo[i] == '9' || typo[i] == 'Z' {
+			typo[i]--
+		} else {
+			typo[i]++
+		}
+		if ValidTrackingID(string(typo)) {
+			t.Errorf("ValidTrackingID(%q) = true for a typo of %q", typo, id)
+		}
+	}
+	for i := 3; i < len(id)-3; i++ {
+		x, y := id[i], id[i+1]
+		if x == y || x+y == '0'+'9' {
+			// Luhn cannot see 09 <-> 90.
+			continue
+		}
+		swapped := id[:i] + string(y) + string(x) + id[i+2:]
+		if ValidTrackingID(swapped) {
+			t.Errorf("ValidTrackingID(%q) = true for a transposition of %q", swapped, id)
+		}
+	}
+
+	// Run with -race to check concurrent use.
+	g := NewTrackingIDGenerator()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.NewTrackingID()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fixedTrackingIDs hands out a fixed sequence of IDs.
+type fixedTrackingIDs struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+func (f *fixedTrackingIDs) NewTrackingID() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.ids[0]
+	f.ids = f.ids[1:]
+	return id
+}
+
+// TestShipmentStoreSweep checks that shipments past retention are dropped
+// as the store grows.
+func TestShipmentStoreSweep(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	store := NewShipmentStore(clock, time.Hour)
+	add := func(prefix string, n int) {
+		for i := 0; i < n; i++ {
+			store.Add(&Shipment{TrackingID: fmt.Sprintf("%s-%d", prefix, i), Delivered: clock.now})
+		}
+	}
+
+	add("old", minSweepSize)
+	clock.now = clock.now.Add(2 * time.Hour)
+	add("new", minSweepSize-1)
+	if len(store.shipments) != 2*minSweepSize-1 {
+		t.Fatalf("store has %d shipments before the sweep, want %d", len(store.shipments), 2*minSweepSize-1)
+	}
+	// The store doubles and the old shipments are swept.
+	add("last", 1)
+	if len(store.shipments) != minSweepSize {
+		t.Errorf("store has %d shipments after the sweep, want %d", len(store.shipments), minSweepSize)
+	}
+	if _, ok := store.shipments["old-0"]; ok {
+		t.Error("shipment past retention not swept")
+	}
+	if _, ok := store.Get("new-0"); !ok {
+		t.Error("shipment in retention swept")
+	}
+}
+
+// TestShipOrderTrackingIDCollision checks that ShipOrder never hands out an
+// ID that is already in use.
+func TestShipOrderTrackingIDCollision(t *testing.T) {
+	s := newTestServer(t)
+	seq := NewSeededTrackingIDGenerator(3)
+	first, second := seq.NewTrackingID(), seq.NewTrackingID()
+	s.trackingIDs = &fixedTrackingIDs{ids: []string{first, first, second, second, second, second, second, second}}
+
+	req := &pb.ShipOrderRequest{
+		Address: &pb.Address{StreetAddress: "1 Main St", City: "Boston", State: "MA", Country: "US", PostalCode: "02134"},
+		Items:   []*pb.CartItem{{ProductId: "23", Quantity: 1}},
+	}
+	var got []string
+	for i := 0; i < 2; i++ {
+		res, err := s.ShipOrder(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, res.TrackingId)
+	}
+	if got[0] != first || got[1] != second {
+		t.Errorf("ShipOrder() tracking IDs = %v, want [%s %s]", got, first, second)
+	}
+	// Every remaining ID collides.
+	if _, err := s.ShipOrder(context.Background(), req); status.Code(err) != codes.Internal {
+		t.Errorf("ShipOrder() when every ID collides = %v, want Internal", err)
+	}
+}
+
+// TestPack checks how items are split into packages.
+func TestPack(t *testing.T) {
+	limits := PackageLimits{MaxGrams: 1000, MaxVolumeMm3: 1000}
+	tests := []struct {
+		name   string
+		items  []parcelItem
+		limits PackageLimits
+		want   string
+	}{
+		{"empty", nil, limits, "[]"},
+		{"one package", []parcelItem{{"a", "", 2, 300, 100}, {"b", "", 1, 200, 100}}, limits, "[[a:2 b:1]]"},
+		{"split by weight", []parcelItem{{"a", "", 3, 400, 0}}, limits, "[[a:2] [a:1]]"},
+		{"split by volume", []parcelItem{{"a", "", 3, 10, 400}}, limits, "[[a:2] [a:1]]"},
+		{"heaviest first fills gaps", []parcelItem{{"light", "", 3, 100, 0}, {"heavy", "", 2, 600, 0}}, limits, "[[heavy:1 light:3] [heavy:1]]"},
+		{"oversized unit ships alone", []parcelItem{{"big", "", 2, 1500, 0}, {"small", "", 1, 100, 0}}, limits, "[[big:1] [big:1] [small:1]]"},
+		{"variants stay apart", []parcelItem{{"a", "a-red", 1, 300, 0}, {"a", "a-blue", 2, 300, 0}}, limits, "[[a/a-red:1 a/a-blue:2]]"},
+		{"unlimited", []parcelItem{{"a", "", 100, 1000, 1000}}, PackageLimits{}, "[[a:100]]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packages, err := Pack(tt.items, tt.limits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range packages {
+				var items []string
+				for _, it := range p.Items {
+					id := it.ProductId
+					if it.VariantSku != "" {
+						id += "/" + it.VariantSku
+					}
+					items = append(items, fmt.Sprintf("%s:%d", id, it.Quantity))
+				}
+				got = append(got, fmt.Sprint(items))
+			}
+			if s := "[" + strings.Join(got, " ") + "]"; s != tt.want {
+				t.Errorf("Pack() = %s, want %s", s, tt.want)
+			}
+		})
+	}
+
+	for _, items := range [][]parcelItem{
+		{{"big", "", 100000, 1500, 0}},
+		{{"a", "", 2 * maxPackages, 600, 0}, {"b", "", 1, 600, 0}},
+	} {
+		if _, err := Pack(items, limits); err != errTooManyPackages {
+			t.Errorf("Pack(%v) = %v, want %v", items, err, errTooManyPackages)
+		}
 	}
 }
@@ -15,42 +15,99 @@
 package main
 
 import (
-	"fmt"
+	crand "crypto/rand"
+	"encoding/binary"
 	"math/rand"
-	"time"
+	"regexp"
+	"sync"
 )
 
-// seeded determines if the random number generator is ready.
-var seeded bool = false
+// Tracking IDs look like "AB-1234567890123-7": two letters, thirteen digits
+// and a Luhn check digit computed over the letters and digits, with letters
+// counted as their two-digit values A=10 ... Z=35.
+const (
+	trackingIDLetters = 2
+	trackingIDDigits  = 13
+)
+
+var trackingIDPattern = regexp.MustCompile(`^[A-Z]{2}-\d{13}-\d$`)
+
+// TrackingIDGenerator creates tracking IDs. Implementations must be safe for
+// concurrent use. IDs are random, so callers that need them to be unique
+// check for collisions; see ShipmentStore.Add.
+type TrackingIDGenerator interface {
+	NewTrackingID() string
+}
+
+// randomTrackingIDs generates tracking IDs from a pseudo-random source.
+type randomTrackingIDs struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
 
-// CreateTrackingId generates a tracking ID.
-func CreateTrackingId(salt string) string {
-	if !seeded {
-		rand.Seed(time.Now().UnixNano())
-		seeded = true
+// NewTrackingIDGenerator returns a generator seeded from crypto/rand.
+func NewTrackingIDGenerator() TrackingIDGenerator {
+	var seed [8]byte
+	if _, err := crand.Read(seed[:]); err != nil {
+		panic(err)
 	}
+	return NewSeededTrackingIDGenerator(int64(binary.LittleEndian.Uint64(seed[:])))
+}
 
-	return fmt.Sprintf("%c%c-%d%s-%d%s",
-		getRandomLetterCode(),
-		getRandomLetterCode(),
-		len(salt),
-		getRandomNumber(3),
-		len(salt)/2,
-		getRandomNumber(7),
-	)
+// NewSeededTrackingIDGenerator returns a generator that produces the same
+// sequence of IDs for the same seed, for use in tests.
+func NewSeededTrackingIDGenerator(seed int64) TrackingIDGenerator {
+	return &randomTrackingIDs{rng: rand.New(rand.NewSource(seed))}
 }
 
-// getRandomLetterCode generates a code point value for a capital letter.
-func getRandomLetterCode() uint32 {
-	return 65 + uint32(rand.Intn(25))
+func (g *randomTrackingIDs) NewTrackingID() string {
+	b := make([]byte, 0, trackingIDLetters+trackingIDDigits+3)
+	g.mu.Lock()
+	for i := 0; i < trackingIDLetters; i++ {
+		b = append(b, byte('A'+g.rng.Intn(26)))
+	}
+	b = append(b, '-')
+	for i := 0; i < trackingIDDigits; i++ {
+		b = append(b, byte('0'+g.rng.Intn(10)))
+	}
+	g.mu.Unlock()
+	return string(append(b, '-', checkDigit(b)))
 }
 
-// getRandomNumber generates a string representation of a number with the requested number of digits.
-func getRandomNumber(digits int) string {
-	str := ""
-	for i := 0; i < digits; i++ {
-		str = fmt.Sprintf("%s%d", str, rand.Intn(10))
+// ValidTrackingID reports whether id is well-formed and its check digit
+// matches.
+func ValidTrackingID(id string) bool {
+	if !trackingIDPattern.MatchString(id) {
+		return false
 	}
+	return checkDigit([]byte(id[:len(id)-2])) == id[len(id)-1]
+}
 
-	return str
+// checkDigit returns the Luhn check digit for the letters and digits in s,
+// ignoring anything else.
+func checkDigit(s []byte) byte {
+	var digits []int
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			v := int(c-'A') + 10
+			digits = append(digits, v/10, v%10)
+		case c >= '0' && c <= '9':
+			digits = append(digits, int(c-'0'))
+		}
+	}
+	sum := 0
+	// Double every second digit, starting from the rightmost one, since the
+	// check digit will be appended to the right.
+	for i := len(digits) - 1; i >= 0; i -= 2 {
+		d := digits[i] * 2
+		if d > 9 {
+			d -= 9
+		}
+		sum += d
+		if i > 0 {
+			sum += digits[i-1]
+		}
+	}
+	return byte('0' + (10-sum%10)%10)
 }